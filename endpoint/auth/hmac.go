@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+)
+
+const (
+	defaultMaxSkew = time.Minute * 5
+	maxNonceLength = 128
+	noncePrefix    = "nonce:"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature does not match.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrStaleTimestamp is returned when the timestamp of a signature is
+	// outside of the allowed skew.
+	ErrStaleTimestamp = errors.New("stale timestamp")
+	// ErrNonceUsed is returned when the nonce of a signature has been used
+	// before.
+	ErrNonceUsed = errors.New("nonce already used")
+)
+
+// Secret is a shared secret of a client used to sign requests. Scopes,
+// topics, limit and quota are set as for a Key.
+type Secret struct {
+	Name   string           `json:"name"`
+	Secret string           `json:"secret"`
+	Scopes []string         `json:"scopes,omitempty"`
+	Topics []string         `json:"topics,omitempty"`
+	Limit  *ratelimit.Limit `json:"limit,omitempty"`
+	Quota  *usage.Quota     `json:"quota,omitempty"`
+}
+
+// ParseSecrets parses a JSON array of secrets.
+func ParseSecrets(b []byte) ([]Secret, error) {
+	var secrets []Secret
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, fmt.Errorf("parsing secrets: %w", err)
+	}
+	names := make(map[string]struct{}, len(secrets))
+	for i, secret := range secrets {
+		if len(secret.Name) == 0 {
+			return nil, fmt.Errorf("parsing secrets: secret %d: name is required", i)
+		}
+		if _, ok := names[secret.Name]; ok {
+			return nil, fmt.Errorf("parsing secrets: secret %d: name %q is used more than once", i, secret.Name)
+		}
+		names[secret.Name] = struct{}{}
+		if len(secret.Secret) < 32 {
+			return nil, fmt.Errorf("parsing secrets: secret %q: must be at least 32 characters", secret.Name)
+		}
+		if err := checkScopes(secret.Scopes); err != nil {
+			return nil, fmt.Errorf("parsing secrets: secret %q: %w", secret.Name, err)
+		}
+	}
+	return secrets, nil
+}
+
+// Signature is the signature of a request, signed with the secret of the
+// client with the name KeyID.
+type Signature struct {
+	KeyID     string
+	Timestamp string
+	Nonce     string
+	Method    string
+	Path      string
+	Query     string
+	Body      []byte
+	Signature string
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the request with the
+// provided secret. The signed string is the method, path, raw query,
+// timestamp, nonce and hex encoded SHA-256 hash of the body, separated by
+// newlines.
+func Sign(secret []byte, method, path, query, timestamp, nonce string, body []byte) string {
+	h := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{method, path, query, timestamp, nonce, hex.EncodeToString(h[:])}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// nonceStore is the interface that wraps around method Create.
+type nonceStore interface {
+	Create(key string, value []byte, ttl time.Duration) error
+}
+
+// HMACVerifier verifies HMAC signed requests. Nonces are kept in a store
+// to reject replayed requests.
+type HMACVerifier struct {
+	secrets map[string]Secret
+	nonces  nonceStore
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// HMACVerifierOptions contains options for a HMACVerifier.
+type HMACVerifierOptions struct {
+	// MaxSkew is the maximum difference between the timestamp of a
+	// signature and the current time.
+	MaxSkew time.Duration
+}
+
+// HMACVerifierOption is a function that sets *HMACVerifierOptions.
+type HMACVerifierOption func(o *HMACVerifierOptions)
+
+// NewHMACVerifier creates a new *HMACVerifier with the provided secrets
+// that keeps nonces in the provided store.
+func NewHMACVerifier(secrets []Secret, nonces nonceStore, options ...HMACVerifierOption) (*HMACVerifier, error) {
+	if nonces == nil {
+		return nil, errors.New("nonce store is nil")
+	}
+
+	opts := HMACVerifierOptions{
+		MaxSkew: defaultMaxSkew,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	m := make(map[string]Secret, len(secrets))
+	for _, secret := range secrets {
+		m[secret.Name] = secret
+	}
+
+	return &HMACVerifier{
+		secrets: m,
+		nonces:  nonces,
+		maxSkew: opts.MaxSkew,
+		now:     time.Now,
+	}, nil
+}
+
+// Verify the provided signature and return the identity of the client.
+// The timestamp, in Unix seconds, must be within the allowed skew and
+// the nonce must not have been used by the client before.
+func (v HMACVerifier) Verify(s Signature) (Identity, error) {
+	secret, ok := v.secrets[s.KeyID]
+	if !ok || len(s.Nonce) == 0 || len(s.Nonce) > maxNonceLength {
+		return Identity{}, ErrInvalidSignature
+	}
+	expected := Sign([]byte(secret.Secret), s.Method, s.Path, s.Query, s.Timestamp, s.Nonce, s.Body)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(s.Signature))) != 1 {
+		return Identity{}, ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(s.Timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, ErrInvalidSignature
+	}
+	if skew := v.now().Sub(time.Unix(ts, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return Identity{}, ErrStaleTimestamp
+	}
+
+	// A nonce is kept for as long as its timestamp is accepted.
+	if err := v.nonces.Create(noncePrefix+s.KeyID+":"+s.Nonce, []byte(s.Timestamp), v.maxSkew*2); err != nil {
+		if errors.Is(err, state.ErrExists) {
+			return Identity{}, ErrNonceUsed
+		}
+		return Identity{}, fmt.Errorf("storing nonce: %w", err)
+	}
+	return newIdentity(secret.Name, secret.Scopes, secret.Topics, secret.Limit, secret.Quota), nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSecrets(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    []Secret
+		wantErr bool
+	}{
+		{
+			name:  "Valid",
+			input: `[{"name":"client","secret":"` + secret + `"}]`,
+			want:  []Secret{{Name: "client", Secret: secret}},
+		},
+		{
+			name:    "Missing name",
+			input:   `[{"secret":"` + secret + `"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate name",
+			input:   `[{"name":"client","secret":"` + secret + `"},{"name":"client","secret":"` + secret + `"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Short secret",
+			input:   `[{"name":"client","secret":"secret"}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseSecrets([]byte(test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseSecrets() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("ParseSecrets() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestHMACVerifier_Verify(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	signature := func(modify func(s *Signature)) Signature {
+		s := Signature{
+			KeyID:     "client",
+			Timestamp: timestamp,
+			Nonce:     "nonce",
+			Method:    "POST",
+			Path:      "/reports",
+			Query:     "wait=true",
+			Body:      []byte(`{"data":"ZGF0YQ=="}`),
+		}
+		if modify != nil {
+			modify(&s)
+		}
+		if len(s.Signature) == 0 {
+			s.Signature = Sign([]byte(secret), s.Method, s.Path, s.Query, s.Timestamp, s.Nonce, s.Body)
+		}
+		return s
+	}
+
+	var tests = []struct {
+		name    string
+		input   Signature
+		nonces  *mockNonceStore
+		want    Identity
+		wantErr error
+	}{
+		{
+			name:   "Valid",
+			input:  signature(nil),
+			nonces: &mockNonceStore{},
+			want:   Identity{Name: "client", Scopes: DefaultScopes},
+		},
+		{
+			name: "Unknown key ID",
+			input: signature(func(s *Signature) {
+				s.KeyID = "other"
+			}),
+			nonces:  &mockNonceStore{},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Invalid signature",
+			input: signature(func(s *Signature) {
+				s.Signature = Sign([]byte(secret), "POST", "/reports", "wait=true", timestamp, "nonce", []byte("other"))
+			}),
+			nonces:  &mockNonceStore{},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Other query",
+			input: signature(func(s *Signature) {
+				s.Signature = Sign([]byte(secret), "POST", "/reports", "wait=false", timestamp, "nonce", []byte(`{"data":"ZGF0YQ=="}`))
+			}),
+			nonces:  &mockNonceStore{},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Stale timestamp",
+			input: signature(func(s *Signature) {
+				s.Timestamp = strconv.FormatInt(now.Add(-time.Minute*6).Unix(), 10)
+			}),
+			nonces:  &mockNonceStore{},
+			wantErr: ErrStaleTimestamp,
+		},
+		{
+			name:    "Used nonce",
+			input:   signature(nil),
+			nonces:  &mockNonceStore{keys: map[string]struct{}{"nonce:client:nonce": {}}},
+			wantErr: ErrNonceUsed,
+		},
+		{
+			name:    "Store error",
+			input:   signature(nil),
+			nonces:  &mockNonceStore{err: errors.New("error")},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := NewHMACVerifier([]Secret{{Name: "client", Secret: secret}}, test.nonces)
+			if err != nil {
+				t.Fatalf("NewHMACVerifier() = unexpected error: %v\n", err)
+			}
+			v.now = func() time.Time { return now }
+
+			got, gotErr := v.Verify(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Verify() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr == nil && gotErr != nil {
+				t.Errorf("Verify() = unexpected error: %v\n", gotErr)
+			}
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("Verify() = unexpected result, want error: %v, got nil\n", test.wantErr)
+			}
+			if test.wantErr != nil && gotErr != nil && test.wantErr.Error() != "error" && !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Verify() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+type mockNonceStore struct {
+	keys map[string]struct{}
+	err  error
+}
+
+func (s *mockNonceStore) Create(key string, value []byte, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.keys[key]; ok {
+		return state.ErrExists
+	}
+	if s.keys == nil {
+		s.keys = make(map[string]struct{})
+	}
+	s.keys[key] = struct{}{}
+	return nil
+}
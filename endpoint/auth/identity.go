@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+)
+
+// Scopes.
+const (
+	// ScopeReportsWrite allows a client to send reports.
+	ScopeReportsWrite = "reports:write"
+	// ScopeReportsRead allows a client to read the status of reports.
+	ScopeReportsRead = "reports:read"
+	// ScopeAdmin allows a client to use admin endpoints.
+	ScopeAdmin = "admin"
+)
+
+var (
+	// DefaultScopes are the scopes of a client without configured scopes.
+	DefaultScopes = []string{ScopeReportsWrite, ScopeReportsRead}
+)
+
+// identityKey is the context key for the identity of a client.
+type identityKey struct{}
+
+// Identity is the identity of an authenticated client. Topics is an
+// allow-list of the topics the client can send reports to. A client
+// without topics can send reports to every topic. Limit is the rate
+// limit and Quota the usage quota of the client, the defaults are used
+// if nil.
+type Identity struct {
+	Name   string
+	Scopes []string
+	Topics []string
+	Limit  *ratelimit.Limit
+	Quota  *usage.Quota
+}
+
+// newIdentity creates a new Identity. DefaultScopes are used if no scopes
+// are provided.
+func newIdentity(name string, scopes, topics []string, limit *ratelimit.Limit, quota *usage.Quota) Identity {
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	return Identity{Name: name, Scopes: scopes, Topics: topics, Limit: limit, Quota: quota}
+}
+
+// HasScope returns true if the identity has the provided scope.
+func (i Identity) HasScope(scope string) bool {
+	return slices.Contains(i.Scopes, scope)
+}
+
+// AllowsTopic returns true if the identity can send reports to the
+// provided topic.
+func (i Identity) AllowsTopic(topic string) bool {
+	return len(i.Topics) == 0 || slices.Contains(i.Topics, topic)
+}
+
+// WithIdentity returns a copy of ctx with the provided identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity in ctx, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// checkScopes returns an error if any of the provided scopes is unknown.
+func checkScopes(scopes []string) error {
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeReportsWrite, ScopeReportsRead, ScopeAdmin:
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdentityFromContext(t *testing.T) {
+	if _, ok := IdentityFromContext(context.Background()); ok {
+		t.Errorf("IdentityFromContext() = unexpected result, want false, got true\n")
+	}
+
+	want := Identity{Name: "client"}
+	got, ok := IdentityFromContext(WithIdentity(context.Background(), want))
+	if !ok {
+		t.Fatalf("IdentityFromContext() = unexpected result, want true, got false\n")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("IdentityFromContext() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestIdentity_HasScope(t *testing.T) {
+	identity := Identity{Name: "client", Scopes: []string{ScopeReportsRead}}
+	if !identity.HasScope(ScopeReportsRead) {
+		t.Errorf("HasScope() = unexpected result, want true, got false\n")
+	}
+	if identity.HasScope(ScopeReportsWrite) {
+		t.Errorf("HasScope() = unexpected result, want false, got true\n")
+	}
+}
+
+func TestIdentity_AllowsTopic(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Identity
+		want  bool
+	}{
+		{
+			name:  "Without topics",
+			input: Identity{},
+			want:  true,
+		},
+		{
+			name:  "Allowed topic",
+			input: Identity{Topics: []string{"create", "update"}},
+			want:  true,
+		},
+		{
+			name:  "Other topic",
+			input: Identity{Topics: []string{"update"}},
+			want:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.input.AllowsTopic("create"); got != test.want {
+				t.Errorf("AllowsTopic() = unexpected result, want: %t, got: %t\n", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	defaultJWKSTimeout         = time.Second * 10
+	// minJWKSRefreshInterval is the minimum time between refreshes of the
+	// keys when a token has an unknown key ID.
+	minJWKSRefreshInterval = time.Minute
+)
+
+var (
+	// ErrKeyNotFound is returned when no key with the key ID of a token
+	// is found.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS parses a JSON Web Key Set and returns the public signing keys by
+// key ID. RSA and EC keys are supported, other keys are skipped.
+func ParseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsa()
+		case "EC":
+			key, err = k.ec()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWKS: key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// rsa returns the RSA public key of the JWK.
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA key")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// ec returns the EC public key of the JWK.
+func (k jwk) ec() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("invalid EC key")
+	}
+	return key, nil
+}
+
+// JWKS is a cached JSON Web Key Set loaded from a file or a URL. The keys
+// are refreshed at an interval, and when a token has an unknown key ID.
+type JWKS struct {
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	refreshed time.Time
+	source    string
+	client    *http.Client
+	interval  time.Duration
+	onError   func(err error)
+	stop      chan struct{}
+	once      sync.Once
+	// group ensures that only one load of the keys for unknown key IDs
+	// runs at a time.
+	group singleflight.Group
+}
+
+// JWKSOptions contains options for a JWKS.
+type JWKSOptions struct {
+	// RefreshInterval is the interval the keys are refreshed with. Keys
+	// are not refreshed at an interval if less than 0.
+	RefreshInterval time.Duration
+	// Timeout is the timeout for loading the keys from a URL.
+	Timeout time.Duration
+	// OnError is called with errors from refreshing the keys. The previous
+	// keys are kept on error.
+	OnError func(err error)
+}
+
+// JWKSOption is a function that sets *JWKSOptions.
+type JWKSOption func(o *JWKSOptions)
+
+// NewJWKS creates a new *JWKS from the provided source, a file path or an
+// http(s) URL, and starts refreshing the keys.
+func NewJWKS(source string, options ...JWKSOption) (*JWKS, error) {
+	j, err := newJWKS(source, options...)
+	if err != nil {
+		return nil, err
+	}
+	if j.interval > 0 {
+		go j.refresh()
+	}
+	return j, nil
+}
+
+// newJWKS creates a new *JWKS from the provided source.
+func newJWKS(source string, options ...JWKSOption) (*JWKS, error) {
+	if len(source) == 0 {
+		return nil, errors.New("JWKS source is empty")
+	}
+
+	opts := JWKSOptions{
+		RefreshInterval: defaultJWKSRefreshInterval,
+		Timeout:         defaultJWKSTimeout,
+		OnError:         func(err error) {},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	j := &JWKS{
+		source:   source,
+		client:   &http.Client{Timeout: opts.Timeout},
+		interval: opts.RefreshInterval,
+		onError:  opts.OnError,
+		stop:     make(chan struct{}),
+	}
+	if err := j.Load(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Key returns the key with the provided key ID. If the key is not found the
+// keys are loaded again, at most once every minute. Concurrent calls share
+// the load.
+func (j *JWKS) Key(kid string) (crypto.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	refreshed := j.refreshed
+	j.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if time.Since(refreshed) < minJWKSRefreshInterval {
+		return nil, ErrKeyNotFound
+	}
+	_, err, _ := j.group.Do("load", func() (any, error) {
+		// The keys may have been loaded since they were checked.
+		j.mu.RLock()
+		refreshed := j.refreshed
+		j.mu.RUnlock()
+		if time.Since(refreshed) < minJWKSRefreshInterval {
+			return nil, nil
+		}
+		return nil, j.Load()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Load the keys from the source, replacing the current keys.
+func (j *JWKS) Load() error {
+	b, err := j.read()
+	if err == nil {
+		var keys map[string]crypto.PublicKey
+		if keys, err = ParseJWKS(b); err == nil {
+			j.mu.Lock()
+			j.keys = keys
+			j.refreshed = time.Now()
+			j.mu.Unlock()
+			return nil
+		}
+	}
+
+	j.mu.Lock()
+	j.refreshed = time.Now()
+	j.mu.Unlock()
+	return fmt.Errorf("loading JWKS: %w", err)
+}
+
+// Close stops refreshing the keys.
+func (j *JWKS) Close() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
+}
+
+// read reads the key set from the source.
+func (j *JWKS) read() ([]byte, error) {
+	if !strings.HasPrefix(j.source, "https://") && !strings.HasPrefix(j.source, "http://") {
+		return os.ReadFile(j.source)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// refresh loads the keys at every interval until the set is closed.
+func (j *JWKS) refresh() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			if err := j.Load(); err != nil {
+				j.onError(err)
+			}
+		}
+	}
+}
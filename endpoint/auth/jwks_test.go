@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	b := encodeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+	keys, err := ParseJWKS(b)
+	if err != nil {
+		t.Fatalf("ParseJWKS() = unexpected error: %v\n", err)
+	}
+	if got, ok := keys["rsa"].(*rsa.PublicKey); !ok || !got.Equal(&rsaKey.PublicKey) {
+		t.Errorf("ParseJWKS() = unexpected RSA key: %v\n", keys["rsa"])
+	}
+	if got, ok := keys["ec"].(*ecdsa.PublicKey); !ok || !got.Equal(&ecKey.PublicKey) {
+		t.Errorf("ParseJWKS() = unexpected EC key: %v\n", keys["ec"])
+	}
+
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"ec","crv":"P-256","x":"AQ","y":"AQ"}]}`)); err == nil {
+		t.Errorf("ParseJWKS() = unexpected result, want error for invalid EC key, got nil\n")
+	}
+	keys, err = ParseJWKS([]byte(`{"keys":[{"kty":"oct","kid":"oct","k":"AQ"},{"kty":"RSA","kid":"enc","use":"enc","n":"AQ","e":"AQAB"}]}`))
+	if err != nil || len(keys) != 0 {
+		t.Errorf("ParseJWKS() = unexpected result, want no keys, got: %v, %v\n", keys, err)
+	}
+}
+
+func TestJWKS_Key(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(encodeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey}))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, encodeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{srv.URL, path} {
+		j, err := NewJWKS(source, func(o *JWKSOptions) {
+			o.RefreshInterval = -1
+		})
+		if err != nil {
+			t.Fatalf("NewJWKS(%q) = unexpected error: %v\n", source, err)
+		}
+		if _, err := j.Key("rsa"); err != nil {
+			t.Errorf("Key() = unexpected error: %v\n", err)
+		}
+		// An unknown key ID within the minimum refresh interval does not
+		// load the keys again.
+		if _, err := j.Key("unknown"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Key() = unexpected error, want: %v, got: %v\n", ErrKeyNotFound, err)
+		}
+		j.Close()
+	}
+	if requests != 1 {
+		t.Errorf("Key() = unexpected number of requests, want: 1, got: %d\n", requests)
+	}
+
+	j, err := NewJWKS(srv.URL, func(o *JWKSOptions) {
+		o.RefreshInterval = -1
+	})
+	if err != nil {
+		t.Fatalf("NewJWKS() = unexpected error: %v\n", err)
+	}
+	j.refreshed = time.Now().Add(-minJWKSRefreshInterval)
+	if _, err := j.Key("unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key() = unexpected error, want: %v, got: %v\n", ErrKeyNotFound, err)
+	}
+	if requests != 3 {
+		t.Errorf("Key() = unexpected number of requests, want: 3, got: %d\n", requests)
+	}
+
+	if _, err := NewJWKS(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("NewJWKS() = unexpected result, want error, got nil\n")
+	}
+}
+
+func TestJWKS_Key_Concurrent(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(time.Millisecond * 50)
+		w.Write(encodeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey}))
+	}))
+	defer srv.Close()
+
+	j, err := NewJWKS(srv.URL, func(o *JWKSOptions) {
+		o.RefreshInterval = -1
+	})
+	if err != nil {
+		t.Fatalf("NewJWKS() = unexpected error: %v\n", err)
+	}
+	j.refreshed = time.Now().Add(-minJWKSRefreshInterval)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := j.Key("unknown"); !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("Key() = unexpected error, want: %v, got: %v\n", ErrKeyNotFound, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Key() = unexpected number of requests, want: 2, got: %d\n", got)
+	}
+}
+
+// encodeJWKS returns a JSON Web Key Set with the provided keys by key ID.
+func encodeJWKS(t *testing.T, keys map[string]any) []byte {
+	t.Helper()
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kty: "RSA", Kid: kid, Use: "sig", N: encode(k.N.Bytes()), E: encode(big.NewInt(int64(k.E)).Bytes())})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{Kty: "EC", Kid: kid, Crv: k.Params().Name, X: encode(k.X.Bytes()), Y: encode(k.Y.Bytes())})
+		}
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
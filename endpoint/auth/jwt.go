@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultNameClaim = "sub"
+	defaultLeeway    = time.Second * 30
+)
+
+var (
+	// ErrInvalidToken is returned when a token is not valid.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+var (
+	// defaultAlgorithms are the signing algorithms allowed by default.
+	defaultAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+// keySource is the interface that wraps around methods Key and Close.
+type keySource interface {
+	Key(kid string) (crypto.PublicKey, error)
+	Close()
+}
+
+// JWTValidator validates JWT bearer tokens against keys from a JWKS.
+type JWTValidator struct {
+	keys       keySource
+	issuer     string
+	audience   string
+	nameClaim  string
+	algorithms []string
+	leeway     time.Duration
+}
+
+// JWTValidatorOptions contains options for a JWTValidator.
+type JWTValidatorOptions struct {
+	// NameClaim is the claim used as the name of the identity.
+	NameClaim string
+	// Algorithms are the allowed signing algorithms.
+	Algorithms []string
+	// Leeway is the allowed clock skew when validating times.
+	Leeway time.Duration
+}
+
+// JWTValidatorOption is a function that sets *JWTValidatorOptions.
+type JWTValidatorOption func(o *JWTValidatorOptions)
+
+// NewJWTValidator creates a new *JWTValidator that validates tokens with the
+// provided keys, issuer and audience.
+func NewJWTValidator(keys *JWKS, issuer, audience string, options ...JWTValidatorOption) (*JWTValidator, error) {
+	if keys == nil {
+		return nil, errors.New("keys are nil")
+	}
+	return newJWTValidator(keys, issuer, audience, options...)
+}
+
+// newJWTValidator creates a new *JWTValidator.
+func newJWTValidator(keys keySource, issuer, audience string, options ...JWTValidatorOption) (*JWTValidator, error) {
+	if len(issuer) == 0 {
+		return nil, errors.New("issuer is required")
+	}
+	if len(audience) == 0 {
+		return nil, errors.New("audience is required")
+	}
+
+	opts := JWTValidatorOptions{
+		NameClaim:  defaultNameClaim,
+		Algorithms: defaultAlgorithms,
+		Leeway:     defaultLeeway,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &JWTValidator{
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+		nameClaim:  opts.NameClaim,
+		algorithms: opts.Algorithms,
+		leeway:     opts.Leeway,
+	}, nil
+}
+
+// Validate the signature, issuer, audience and expiry of the provided token
+// and return the identity of its subject. The scopes of the identity are
+// read from the claim "scope" or "scp". The returned error wraps
+// ErrInvalidToken.
+func (v JWTValidator) Validate(token string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, v.key,
+		jwt.WithValidMethods(v.algorithms),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	name, ok := claims[v.nameClaim].(string)
+	if !ok || len(name) == 0 {
+		return Identity{}, fmt.Errorf("%w: missing claim %q", ErrInvalidToken, v.nameClaim)
+	}
+	return newIdentity(name, scopes(claims), nil, nil, nil), nil
+}
+
+// scopes returns the scopes of the provided claims, from a space separated
+// "scope" claim or a "scp" claim that is either a space separated string or
+// an array.
+func scopes(claims jwt.MapClaims) []string {
+	for _, claim := range []string{"scope", "scp"} {
+		switch v := claims[claim].(type) {
+		case string:
+			return strings.Fields(v)
+		case []any:
+			scopes := make([]string, 0, len(v))
+			for _, scope := range v {
+				if s, ok := scope.(string); ok {
+					scopes = append(scopes, s)
+				}
+			}
+			return scopes
+		}
+	}
+	return nil
+}
+
+// key returns the key for the provided token based on its key ID.
+func (v JWTValidator) key(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	return v.keys.Key(kid)
+}
+
+// Close stops refreshing the keys of the validator.
+func (v JWTValidator) Close() {
+	v.keys.Close()
+}
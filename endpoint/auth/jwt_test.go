@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJWTValidator_Validate(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	v, err := newJWTValidator(mockKeySource{"kid": &key.PublicKey}, "issuer", "audience")
+	if err != nil {
+		t.Fatalf("newJWTValidator() = unexpected error: %v\n", err)
+	}
+
+	claims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": "issuer",
+			"aud": "audience",
+			"sub": "client",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    Identity
+		wantErr bool
+	}{
+		{
+			name:  "Valid",
+			input: signToken(t, jwt.SigningMethodRS256, key, "kid", claims(nil)),
+			want:  Identity{Name: "client", Scopes: DefaultScopes},
+		},
+		{
+			name:  "With scope claim",
+			input: signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { c["scope"] = "reports:read admin" })),
+			want:  Identity{Name: "client", Scopes: []string{ScopeReportsRead, ScopeAdmin}},
+		},
+		{
+			name:  "With scp claim",
+			input: signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { c["scp"] = []string{"reports:write"} })),
+			want:  Identity{Name: "client", Scopes: []string{ScopeReportsWrite}},
+		},
+		{
+			name:    "Wrong issuer",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { c["iss"] = "other" })),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong audience",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { c["aud"] = "other" })),
+			wantErr: true,
+		},
+		{
+			name:    "Expired",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })),
+			wantErr: true,
+		},
+		{
+			name:    "Without expiry",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantErr: true,
+		},
+		{
+			name:    "Without subject",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "kid", claims(func(c jwt.MapClaims) { delete(c, "sub") })),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong key",
+			input:   signToken(t, jwt.SigningMethodRS256, other, "kid", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown key ID",
+			input:   signToken(t, jwt.SigningMethodRS256, key, "other", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "Not allowed algorithm",
+			input:   signToken(t, jwt.SigningMethodHS256, []byte("secret"), "kid", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "Malformed",
+			input:   "token",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := v.Validate(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Validate() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("Validate() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+			if gotErr != nil && !errors.Is(gotErr, ErrInvalidToken) {
+				t.Errorf("Validate() = unexpected error, want: %v, got: %v\n", ErrInvalidToken, gotErr)
+			}
+		})
+	}
+}
+
+func TestNewJWTValidator(t *testing.T) {
+	if _, err := newJWTValidator(mockKeySource{}, "", "audience"); err == nil {
+		t.Errorf("newJWTValidator() = unexpected result, want error for missing issuer, got nil\n")
+	}
+	if _, err := newJWTValidator(mockKeySource{}, "issuer", ""); err == nil {
+		t.Errorf("newJWTValidator() = unexpected result, want error for missing audience, got nil\n")
+	}
+
+	got, err := newJWTValidator(mockKeySource{}, "issuer", "audience", func(o *JWTValidatorOptions) {
+		o.NameClaim = "azp"
+		o.Algorithms = []string{"RS256"}
+		o.Leeway = time.Minute
+	})
+	if err != nil {
+		t.Fatalf("newJWTValidator() = unexpected error: %v\n", err)
+	}
+	want := &JWTValidator{
+		keys:       mockKeySource{},
+		issuer:     "issuer",
+		audience:   "audience",
+		nameClaim:  "azp",
+		algorithms: []string{"RS256"},
+		leeway:     time.Minute,
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(JWTValidator{})); diff != "" {
+		t.Errorf("newJWTValidator() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+type mockKeySource map[string]crypto.PublicKey
+
+func (s mockKeySource) Key(kid string) (crypto.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s mockKeySource) Close() {}
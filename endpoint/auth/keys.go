@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+)
+
+const (
+	defaultReloadInterval = time.Minute
+)
+
+var (
+	// ErrInvalidKey is returned when a key does not match any key.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrKeyExpired is returned when a key has expired.
+	ErrKeyExpired = errors.New("key expired")
+)
+
+// Key is an API key of a client. Only the hex encoded SHA-256 hash of
+// the key is kept. A key without an expiry does not expire, a key without
+// scopes has DefaultScopes, a key without topics can send reports to
+// every topic and a key without a limit or quota has the default rate
+// limit or quota.
+type Key struct {
+	Name    string           `json:"name"`
+	Hash    string           `json:"hash"`
+	Expires time.Time        `json:"expires"`
+	Scopes  []string         `json:"scopes,omitempty"`
+	Topics  []string         `json:"topics,omitempty"`
+	Limit   *ratelimit.Limit `json:"limit,omitempty"`
+	Quota   *usage.Quota     `json:"quota,omitempty"`
+}
+
+// HashKey returns the hex encoded SHA-256 hash of the provided key.
+func HashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// ParseKeys parses a JSON array of keys.
+func ParseKeys(b []byte) ([]Key, error) {
+	var keys []Key
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("parsing keys: %w", err)
+	}
+	names := make(map[string]struct{}, len(keys))
+	for i, key := range keys {
+		if len(key.Name) == 0 {
+			return nil, fmt.Errorf("parsing keys: key %d: name is required", i)
+		}
+		if _, ok := names[key.Name]; ok {
+			return nil, fmt.Errorf("parsing keys: key %d: name %q is used more than once", i, key.Name)
+		}
+		names[key.Name] = struct{}{}
+		if b, err := hex.DecodeString(key.Hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("parsing keys: key %q: hash must be a hex encoded SHA-256 hash", key.Name)
+		}
+		if err := checkScopes(key.Scopes); err != nil {
+			return nil, fmt.Errorf("parsing keys: key %q: %w", key.Name, err)
+		}
+	}
+	return keys, nil
+}
+
+// Loader is the interface that wraps around method Load.
+type Loader interface {
+	Load() ([]Key, error)
+}
+
+// hashedKey is a key with a decoded hash.
+type hashedKey struct {
+	identity Identity
+	hash     []byte
+	expires  time.Time
+}
+
+// KeySet is a set of keys that is reloaded from a Loader.
+type KeySet struct {
+	mu       sync.RWMutex
+	keys     []hashedKey
+	loader   Loader
+	interval time.Duration
+	onError  func(err error)
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// KeySetOptions contains options for a KeySet.
+type KeySetOptions struct {
+	// ReloadInterval is the interval the keys are reloaded with. Keys are
+	// not reloaded if less than 0.
+	ReloadInterval time.Duration
+	// OnError is called with errors from reloading the keys. The previous
+	// keys are kept on error.
+	OnError func(err error)
+}
+
+// KeySetOption is a function that sets *KeySetOptions.
+type KeySetOption func(o *KeySetOptions)
+
+// NewKeySet creates a new *KeySet with keys from the provided loader, and
+// starts reloading the keys.
+func NewKeySet(loader Loader, options ...KeySetOption) (*KeySet, error) {
+	s, err := newKeySet(loader, options...)
+	if err != nil {
+		return nil, err
+	}
+	if s.interval > 0 {
+		go s.reload()
+	}
+	return s, nil
+}
+
+// newKeySet creates a new *KeySet with keys from the provided loader.
+func newKeySet(loader Loader, options ...KeySetOption) (*KeySet, error) {
+	if loader == nil {
+		return nil, errors.New("loader is nil")
+	}
+
+	opts := KeySetOptions{
+		ReloadInterval: defaultReloadInterval,
+		OnError:        func(err error) {},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	s := &KeySet{
+		loader:   loader,
+		interval: opts.ReloadInterval,
+		onError:  opts.OnError,
+		stop:     make(chan struct{}),
+	}
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Authenticate returns the identity of the provided key. The hash of the key
+// is compared in constant time with every key in the set.
+func (s *KeySet) Authenticate(key string) (Identity, error) {
+	h := sha256.Sum256([]byte(key))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var match *hashedKey
+	for i := range s.keys {
+		if subtle.ConstantTimeCompare(h[:], s.keys[i].hash) == 1 {
+			match = &s.keys[i]
+		}
+	}
+	if match == nil {
+		return Identity{}, ErrInvalidKey
+	}
+	if !match.expires.IsZero() && time.Now().After(match.expires) {
+		return Identity{}, ErrKeyExpired
+	}
+	return match.identity, nil
+}
+
+// Load the keys from the loader, replacing the current keys.
+func (s *KeySet) Load() error {
+	keys, err := s.loader.Load()
+	if err != nil {
+		return fmt.Errorf("loading keys: %w", err)
+	}
+
+	hashed := make([]hashedKey, 0, len(keys))
+	for _, key := range keys {
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil {
+			return fmt.Errorf("loading keys: key %q: %w", key.Name, err)
+		}
+		hashed = append(hashed, hashedKey{
+			identity: newIdentity(key.Name, key.Scopes, key.Topics, key.Limit, key.Quota),
+			hash:     hash,
+			expires:  key.Expires,
+		})
+	}
+
+	s.mu.Lock()
+	s.keys = hashed
+	s.mu.Unlock()
+	return nil
+}
+
+// Close stops reloading the keys.
+func (s *KeySet) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// reload loads the keys at every interval until the set is closed.
+func (s *KeySet) reload() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Load(); err != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
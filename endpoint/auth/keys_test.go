@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseKeys(t *testing.T) {
+	hash := HashKey("key")
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    []Key
+		wantErr bool
+	}{
+		{
+			name:  "Valid",
+			input: `[{"name":"client-a","hash":"` + hash + `"},{"name":"client-b","hash":"` + hash + `","expires":"2030-01-01T00:00:00Z","scopes":["admin"],"topics":["create"],"limit":{"rate":5,"burst":10},"quota":{"daily":{"reports":100}}}]`,
+			want: []Key{
+				{Name: "client-a", Hash: hash},
+				{Name: "client-b", Hash: hash, Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Scopes: []string{"admin"}, Topics: []string{"create"}, Limit: &ratelimit.Limit{Rate: 5, Burst: 10}, Quota: &usage.Quota{Daily: usage.Count{Reports: 100}}},
+			},
+		},
+		{
+			name:    "Missing name",
+			input:   `[{"hash":"` + hash + `"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate name",
+			input:   `[{"name":"client","hash":"` + hash + `"},{"name":"client","hash":"` + hash + `"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Unknown scope",
+			input:   `[{"name":"client","hash":"` + hash + `","scopes":["reports:delete"]}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid hash",
+			input:   `[{"name":"client","hash":"key"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			input:   `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseKeys([]byte(test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseKeys() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("ParseKeys() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestKeySet_Authenticate(t *testing.T) {
+	s, err := newKeySet(StaticLoader([]Key{
+		{Name: "client-a", Hash: HashKey("key-a")},
+		{Name: "client-b", Hash: HashKey("key-b"), Expires: time.Now().Add(time.Hour), Scopes: []string{ScopeReportsRead}, Topics: []string{"create"}},
+		{Name: "client-c", Hash: HashKey("key-c"), Expires: time.Now().Add(-time.Hour)},
+	}))
+	if err != nil {
+		t.Fatalf("newKeySet() = unexpected error: %v\n", err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    Identity
+		wantErr error
+	}{
+		{
+			name:  "Valid key",
+			input: "key-a",
+			want:  Identity{Name: "client-a", Scopes: DefaultScopes},
+		},
+		{
+			name:  "Valid key with expiry",
+			input: "key-b",
+			want:  Identity{Name: "client-b", Scopes: []string{ScopeReportsRead}, Topics: []string{"create"}},
+		},
+		{
+			name:    "Expired key",
+			input:   "key-c",
+			wantErr: ErrKeyExpired,
+		},
+		{
+			name:    "Invalid key",
+			input:   "key-d",
+			wantErr: ErrInvalidKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := s.Authenticate(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Authenticate() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Authenticate() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestKeySet_Reload(t *testing.T) {
+	var mu sync.Mutex
+	keys := []Key{{Name: "client-a", Hash: HashKey("key-a")}}
+	fail := false
+	loader := LoaderFunc(func() ([]Key, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return nil, errors.New("error")
+		}
+		return keys, nil
+	})
+
+	errs := make(chan error, 1)
+	s, err := NewKeySet(loader, func(o *KeySetOptions) {
+		o.ReloadInterval = time.Millisecond * 10
+		o.OnError = func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet() = unexpected error: %v\n", err)
+	}
+	defer s.Close()
+
+	if _, err := s.Authenticate("key-b"); err == nil {
+		t.Fatalf("Authenticate() = unexpected result, want error, got nil\n")
+	}
+
+	mu.Lock()
+	keys = []Key{{Name: "client-b", Hash: HashKey("key-b")}}
+	mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := s.Authenticate("key-b"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Authenticate() = unexpected error, keys not reloaded\n")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Errorf("OnError = not called\n")
+	}
+	if _, err := s.Authenticate("key-b"); err != nil {
+		t.Errorf("Authenticate() = unexpected error after failed reload: %v\n", err)
+	}
+}
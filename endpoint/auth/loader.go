@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	defaultSecretName    = "api-keys"
+	defaultSecretTimeout = time.Second * 10
+)
+
+// LoaderFunc is a function that implements Loader.
+type LoaderFunc func() ([]Key, error)
+
+// Load calls f.
+func (f LoaderFunc) Load() ([]Key, error) {
+	return f()
+}
+
+// StaticLoader returns a Loader that always loads the provided keys.
+func StaticLoader(keys []Key) Loader {
+	return LoaderFunc(func() ([]Key, error) {
+		return keys, nil
+	})
+}
+
+// FileLoader loads keys from a JSON file.
+type FileLoader struct {
+	path string
+}
+
+// NewFileLoader creates a new *FileLoader for the file at the provided path.
+func NewFileLoader(path string) *FileLoader {
+	return &FileLoader{path: path}
+}
+
+// Load keys from the file.
+func (l FileLoader) Load() ([]Key, error) {
+	b, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(b)
+}
+
+// secretClient is the interface that wraps around method GetSecret.
+type secretClient interface {
+	GetSecret(ctx context.Context, storeName, key string, meta map[string]string) (map[string]string, error)
+}
+
+// SecretLoader loads keys from a secret in a DAPR secret store. The
+// secret contains a JSON array of keys.
+type SecretLoader struct {
+	secretClient
+	store   string
+	name    string
+	key     string
+	timeout time.Duration
+}
+
+// SecretLoaderOptions contains options for a SecretLoader.
+type SecretLoaderOptions struct {
+	// Name is the name of the secret.
+	Name string
+	// Key is the key of the value in the secret. Defaults to Name.
+	Key     string
+	Timeout time.Duration
+}
+
+// SecretLoaderOption is a function that sets *SecretLoaderOptions.
+type SecretLoaderOption func(o *SecretLoaderOptions)
+
+// NewSecretLoader creates a new *SecretLoader for the provided secret store
+// with the provided options.
+func NewSecretLoader(store string, options ...SecretLoaderOption) (*SecretLoader, error) {
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	l := newSecretLoader(store, options...)
+	l.secretClient = client
+
+	return l, nil
+}
+
+// newSecretLoader creates a new *SecretLoader for the provided secret store
+// with the provided options.
+func newSecretLoader(store string, options ...SecretLoaderOption) *SecretLoader {
+	opts := SecretLoaderOptions{
+		Name:    defaultSecretName,
+		Timeout: defaultSecretTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if len(opts.Key) == 0 {
+		opts.Key = opts.Name
+	}
+
+	return &SecretLoader{
+		store:   store,
+		name:    opts.Name,
+		key:     opts.Key,
+		timeout: opts.Timeout,
+	}
+}
+
+// Load keys from the secret.
+func (l SecretLoader) Load() ([]Key, error) {
+	b, err := l.Read()
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(b)
+}
+
+// Read the value of the secret.
+func (l SecretLoader) Read() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	secret, err := l.GetSecret(ctx, l.store, l.name, nil)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret[l.key]
+	if !ok {
+		return nil, fmt.Errorf("secret %q has no key %q", l.name, l.key)
+	}
+	return []byte(value), nil
+}
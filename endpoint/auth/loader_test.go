@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileLoader_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`[{"name":"client","hash":"`+HashKey("key")+`"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFileLoader(path).Load()
+	if err != nil {
+		t.Fatalf("Load() = unexpected error: %v\n", err)
+	}
+	want := []Key{{Name: "client", Hash: HashKey("key")}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+
+	if _, err := NewFileLoader(filepath.Join(t.TempDir(), "missing.json")).Load(); err == nil {
+		t.Errorf("Load() = unexpected result, want error, got nil\n")
+	}
+}
+
+func TestSecretLoader_Load(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client  *mockSecretClient
+			options []SecretLoaderOption
+		}
+		want    []Key
+		wantErr bool
+	}{
+		{
+			name: "With defaults",
+			input: struct {
+				client  *mockSecretClient
+				options []SecretLoaderOption
+			}{
+				client: &mockSecretClient{secret: map[string]string{
+					defaultSecretName: `[{"name":"client","hash":"` + HashKey("key") + `"}]`,
+				}},
+			},
+			want: []Key{{Name: "client", Hash: HashKey("key")}},
+		},
+		{
+			name: "With key",
+			input: struct {
+				client  *mockSecretClient
+				options []SecretLoaderOption
+			}{
+				client: &mockSecretClient{secret: map[string]string{
+					"keys": `[{"name":"client","hash":"` + HashKey("key") + `"}]`,
+				}},
+				options: []SecretLoaderOption{
+					func(o *SecretLoaderOptions) {
+						o.Name = "secret"
+						o.Key = "keys"
+					},
+				},
+			},
+			want: []Key{{Name: "client", Hash: HashKey("key")}},
+		},
+		{
+			name: "With missing key",
+			input: struct {
+				client  *mockSecretClient
+				options []SecretLoaderOption
+			}{
+				client: &mockSecretClient{secret: map[string]string{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "With error",
+			input: struct {
+				client  *mockSecretClient
+				options []SecretLoaderOption
+			}{
+				client: &mockSecretClient{err: errors.New("error")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newSecretLoader("secrets", test.input.options...)
+			l.secretClient = test.input.client
+
+			got, gotErr := l.Load()
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Load() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("Load() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+type mockSecretClient struct {
+	secret map[string]string
+	err    error
+}
+
+func (c *mockSecretClient) GetSecret(ctx context.Context, storeName, key string, meta map[string]string) (map[string]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.secret, nil
+}
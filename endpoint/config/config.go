@@ -1,13 +1,26 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/health"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/metrics"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
 	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/tracing"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
 	"github.com/caarlos0/env/v10"
+	"google.golang.org/grpc/codes"
 )
 
 const (
@@ -16,6 +29,60 @@ const (
 	defaultReadTimeout  = time.Second * 15
 	defaultWriteTimeout = time.Second * 15
 	defaultIdleTimeout  = time.Second * 30
+	defaultMaxBatchSize = 100
+)
+
+const (
+	defaultShutdownDelay   = time.Second * 5
+	defaultShutdownTimeout = time.Second * 10
+	defaultHealthTimeout   = time.Second * 2
+)
+
+const (
+	defaultIdempotencyWindow = time.Hour * 24
+)
+
+const (
+	// SchemeAPIKey is the authentication scheme for API keys.
+	SchemeAPIKey = "apikey"
+	// SchemeJWT is the authentication scheme for JWT bearer tokens.
+	SchemeJWT = "jwt"
+	// SchemeHMAC is the authentication scheme for HMAC signed requests.
+	SchemeHMAC = "hmac"
+)
+
+const (
+	defaultKeysSecretName      = "api-keys"
+	defaultKeysReloadInterval  = time.Minute
+	defaultJWTNameClaim        = "sub"
+	defaultJWKSRefreshInterval = time.Hour
+	defaultHMACSecretName      = "hmac-secrets"
+	defaultHMACMaxSkew         = time.Minute * 5
+)
+
+const (
+	defaultMaxBodySize      = 1 << 20
+	defaultMaxBatchBodySize = 16 << 20
+)
+
+const (
+	defaultUsageRetention = time.Hour * 24 * 400
+)
+
+// defaultAccessLogRedactHeaders returns the request headers that are
+// redacted in the access log by default.
+func defaultAccessLogRedactHeaders() map[string]struct{} {
+	return map[string]struct{}{
+		"Authorization": {},
+		"Cookie":        {},
+		"X-API-Key":     {},
+		"X-Signature":   {},
+	}
+}
+
+const (
+	rateLimitBackendMemory = "memory"
+	rateLimitBackendState  = "state"
 )
 
 const (
@@ -23,33 +90,189 @@ const (
 	reporterTypePubsub = "pubsub"
 )
 
+// defaultRoute is the name of the route of reports that match no route.
+const defaultRoute = "default"
+
+const (
+	defaultReporterType        = reporterTypeQueue
+	defaultReporterName        = "reports"
+	defaultReporterTimeout     = time.Second * 10
+	defaultReporterQueue       = "create"
+	defaultReporterTopic       = "create"
+	defaultReporterConcurrency = 10
+	defaultReporterMaxDataSize = 128 << 10
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseBackoff = time.Millisecond * 100
+	defaultRetryMaxBackoff  = time.Second * 2
+	defaultRetryJitter      = 0.2
+)
+
+// defaultRetryCodes returns the gRPC codes of errors that are retried by
+// default.
+func defaultRetryCodes() []codes.Code {
+	return []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+}
+
+const (
+	defaultCircuitBreakerWindowSize       = 20
+	defaultCircuitBreakerMinRequests      = 10
+	defaultCircuitBreakerFailureRate      = 0.5
+	defaultCircuitBreakerCoolDown         = time.Second * 30
+	defaultCircuitBreakerHalfOpenRequests = 1
+)
+
 const (
-	defaultReporterType    = reporterTypeQueue
-	defaultReporterName    = "reports"
-	defaultReporterTimeout = time.Second * 10
-	defaultReporterQueue   = "create"
-	defaultReporterTopic   = "create"
+	defaultFanOutPolicy = string(report.FanOutPrimary)
+)
+
+const (
+	defaultClaimCheckThreshold   = 64 << 10
+	defaultClaimCheckTimeout     = time.Second * 10
+	defaultClaimCheckMaxDataSize = 10 << 20
+	defaultClaimCheckPartSize    = 4 << 20
+)
+
+const (
+	defaultStateTimeout         = time.Second * 10
+	defaultStateStatusRetention = time.Hour * 24 * 7
+)
+
+const (
+	defaultTracingSampleRatio = 1.0
 )
 
 // Configuration contains the configuration for the application.
 type Configuration struct {
 	Server   Server
 	Reporter Reporter
+	State    State
+	Tracing  Tracing
 }
 
 // Server contains the configuration for the server.
 type Server struct {
 	Security     Security
+	Idempotency  Idempotency
+	Validation   Validation
+	RateLimit    RateLimit
+	Usage        Usage
+	Health       Health
+	Metrics      Metrics
+	AccessLog    AccessLog
 	Host         string        `env:"ENDPOINT_HOST"`
 	Port         int           `env:"ENDPOINT_PORT"`
 	ReadTimeout  time.Duration `env:"ENDPOINT_READ_TIMEOUT"`
 	WriteTimeout time.Duration `env:"ENDPOINT_WRITE_TIMEOUT"`
 	IdleTimeout  time.Duration `env:"ENDPOINT_IDLE_TIMEOUT"`
+	MaxBatchSize int           `env:"ENDPOINT_MAX_BATCH_SIZE"`
+	// ShutdownDelay is the time the server reports not ready before it
+	// stops.
+	ShutdownDelay time.Duration `env:"ENDPOINT_SHUTDOWN_DELAY"`
+	// ShutdownTimeout is the time in-flight requests have to complete
+	// when the server stops.
+	ShutdownTimeout time.Duration `env:"ENDPOINT_SHUTDOWN_TIMEOUT"`
 }
 
-// Security contains the configuration for server security.
+// AccessLog contains the configuration for the access log. The values of
+// the request headers and fields to redact are replaced in the log. The
+// default headers are always redacted.
+type AccessLog struct {
+	Enabled       bool                `env:"ENDPOINT_ACCESS_LOG_ENABLED"`
+	RedactHeaders map[string]struct{} `env:"ENDPOINT_ACCESS_LOG_REDACT_HEADERS"`
+	RedactFields  map[string]struct{} `env:"ENDPOINT_ACCESS_LOG_REDACT_FIELDS"`
+}
+
+// Metrics contains the configuration for Prometheus metrics, served
+// on /metrics.
+type Metrics struct {
+	Enabled bool `env:"ENDPOINT_METRICS_ENABLED"`
+}
+
+// Health contains the configuration for readiness checks of the DAPR
+// sidecar.
+type Health struct {
+	Timeout time.Duration `env:"ENDPOINT_HEALTH_TIMEOUT"`
+}
+
+// Security contains the configuration for server security. Schemes are the
+// allowed authentication schemes, "apikey", "jwt" and "hmac". API keys are
+// loaded from a file, a DAPR secret store or, if neither is set, from Keys.
 type Security struct {
-	Keys map[string]struct{} `env:"ENDPOINT_SECURITY_KEYS"`
+	JWT                JWT
+	HMAC               HMAC
+	Schemes            map[string]struct{} `env:"ENDPOINT_SECURITY_SCHEMES"`
+	Keys               map[string]struct{} `env:"ENDPOINT_SECURITY_KEYS"`
+	KeysFile           string              `env:"ENDPOINT_SECURITY_KEYS_FILE"`
+	KeysSecretStore    string              `env:"ENDPOINT_SECURITY_KEYS_SECRET_STORE"`
+	KeysSecretName     string              `env:"ENDPOINT_SECURITY_KEYS_SECRET_NAME"`
+	KeysReloadInterval time.Duration       `env:"ENDPOINT_SECURITY_KEYS_RELOAD_INTERVAL"`
+}
+
+// HasScheme returns true if the provided authentication scheme is allowed.
+func (c Security) HasScheme(scheme string) bool {
+	_, ok := c.Schemes[scheme]
+	return ok
+}
+
+// JWT contains the configuration for JWT bearer tokens. JWKS is the path
+// or URL of the JSON Web Key Set used to verify the tokens.
+type JWT struct {
+	Issuer              string        `env:"ENDPOINT_SECURITY_JWT_ISSUER"`
+	Audience            string        `env:"ENDPOINT_SECURITY_JWT_AUDIENCE"`
+	JWKS                string        `env:"ENDPOINT_SECURITY_JWT_JWKS"`
+	NameClaim           string        `env:"ENDPOINT_SECURITY_JWT_NAME_CLAIM"`
+	JWKSRefreshInterval time.Duration `env:"ENDPOINT_SECURITY_JWT_JWKS_REFRESH_INTERVAL"`
+}
+
+// Idempotency contains the configuration for idempotent report submissions.
+// Idempotency is enabled when a state store is configured.
+type Idempotency struct {
+	Window time.Duration `env:"ENDPOINT_IDEMPOTENCY_WINDOW"`
+}
+
+// Validation contains the configuration for request validation.
+type Validation struct {
+	MaxBodySize      int64 `env:"ENDPOINT_MAX_BODY_SIZE"`
+	MaxBatchBodySize int64 `env:"ENDPOINT_MAX_BATCH_BODY_SIZE"`
+}
+
+// RateLimit contains the configuration for rate limiting of clients. Rate
+// and Burst are the default limit for clients without a limit, and there is
+// no default limit if Rate is 0. Backend is "memory" or "state", to keep the
+// limits in the state store. Requests are rejected if the limiter fails and
+// FailClosed is set, otherwise they are allowed.
+type RateLimit struct {
+	Rate       float64 `env:"ENDPOINT_RATE_LIMIT_RATE"`
+	Burst      int     `env:"ENDPOINT_RATE_LIMIT_BURST"`
+	Backend    string  `env:"ENDPOINT_RATE_LIMIT_BACKEND"`
+	FailClosed bool    `env:"ENDPOINT_RATE_LIMIT_FAIL_CLOSED"`
+}
+
+// Limit returns the default limit.
+func (c RateLimit) Limit() ratelimit.Limit {
+	return ratelimit.Limit{Rate: c.Rate, Burst: c.Burst}
+}
+
+// Usage contains the configuration for usage accounting of clients. Usage
+// is tracked when a state store is configured. The quotas are the default
+// quotas for clients without a quota, and a quota of 0 is no quota.
+type Usage struct {
+	DailyReports   int64         `env:"ENDPOINT_USAGE_DAILY_REPORTS"`
+	DailyBytes     int64         `env:"ENDPOINT_USAGE_DAILY_BYTES"`
+	MonthlyReports int64         `env:"ENDPOINT_USAGE_MONTHLY_REPORTS"`
+	MonthlyBytes   int64         `env:"ENDPOINT_USAGE_MONTHLY_BYTES"`
+	Retention      time.Duration `env:"ENDPOINT_USAGE_RETENTION"`
+}
+
+// Quota returns the default quota.
+func (c Usage) Quota() usage.Quota {
+	return usage.Quota{
+		Daily:   usage.Count{Reports: c.DailyReports, Bytes: c.DailyBytes},
+		Monthly: usage.Count{Reports: c.MonthlyReports, Bytes: c.MonthlyBytes},
+	}
 }
 
 // Reporter contains the configuration for the reporter service.
@@ -59,6 +282,246 @@ type Reporter struct {
 	Timeout time.Duration `env:"ENDPOINT_REPORTER_TIMEOUT"`
 	Queue   string        `env:"ENDPOINT_REPORTER_QUEUE"`
 	Topic   string        `env:"ENDPOINT_REPORTER_TOPIC"`
+	// Concurrency is the maximum number of concurrent binding calls
+	// for batches with the queue reporter.
+	Concurrency int `env:"ENDPOINT_REPORTER_CONCURRENCY"`
+	// QueueMaxDataSize and PubsubMaxDataSize are the maximum sizes in
+	// bytes of the data of a report for the respective reporter type.
+	QueueMaxDataSize  int `env:"ENDPOINT_REPORTER_QUEUE_MAX_DATA_SIZE"`
+	PubsubMaxDataSize int `env:"ENDPOINT_REPORTER_PUBSUB_MAX_DATA_SIZE"`
+	// Fallbacks are the reporters reports are sent with, in order, when
+	// the reporter fails. Set as a JSON array of targets.
+	Fallbacks []Target `env:"ENDPOINT_REPORTER_FALLBACKS"`
+	// Routes are the rules reports are routed on, in order. Reports that
+	// match no route are sent with the reporter and its fallbacks. Set as
+	// a JSON array of routes.
+	Routes         []Route `env:"ENDPOINT_REPORTER_ROUTES"`
+	Retry          Retry
+	CircuitBreaker CircuitBreaker
+	FanOut         FanOut
+	ClaimCheck     ClaimCheck
+}
+
+// Target is a reporter that sends reports to a queue with an output
+// binding or to a topic with a pubsub component.
+type Target struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Queue string `json:"queue,omitempty"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// Destination returns the topic or queue reports are sent to for the
+// type of the target.
+func (t Target) Destination() string {
+	if t.Type == reporterTypePubsub {
+		return t.Topic
+	}
+	return t.Queue
+}
+
+// String returns the type, name and destination of the target, such as
+// "pubsub/reports/create". Used as the name of the reporter that accepted
+// a report.
+func (t Target) String() string {
+	return t.Type + "/" + t.Name + "/" + t.Destination()
+}
+
+// Route is a rule that sends the reports that match it to the target.
+type Route struct {
+	Name   string     `json:"name"`
+	Match  RouteMatch `json:"match"`
+	Target Target     `json:"target"`
+}
+
+// RouteMatch contains the conditions a report must meet to match a route.
+// Conditions that are not set are not checked.
+type RouteMatch struct {
+	Types       []string          `json:"types,omitempty"`
+	Tenants     []string          `json:"tenants,omitempty"`
+	MinPriority *int              `json:"minPriority,omitempty"`
+	MaxPriority *int              `json:"maxPriority,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	MinSize     int               `json:"minSize,omitempty"`
+	MaxSize     int               `json:"maxSize,omitempty"`
+}
+
+// Match returns the conditions as a report.Match.
+func (m RouteMatch) Match() report.Match {
+	return report.Match{
+		Types:       m.Types,
+		Tenants:     m.Tenants,
+		MinPriority: m.MinPriority,
+		MaxPriority: m.MaxPriority,
+		Labels:      m.Labels,
+		MinSize:     m.MinSize,
+		MaxSize:     m.MaxSize,
+	}
+}
+
+// Retry contains the configuration for retries of the reporter. Codes are
+// the gRPC codes of errors that are retried, by name (e.g. UNAVAILABLE).
+// Retries are disabled if MaxAttempts is less than 2.
+type Retry struct {
+	MaxAttempts int           `env:"ENDPOINT_REPORTER_RETRY_MAX_ATTEMPTS"`
+	BaseBackoff time.Duration `env:"ENDPOINT_REPORTER_RETRY_BASE_BACKOFF"`
+	MaxBackoff  time.Duration `env:"ENDPOINT_REPORTER_RETRY_MAX_BACKOFF"`
+	Jitter      float64       `env:"ENDPOINT_REPORTER_RETRY_JITTER"`
+	Codes       []codes.Code  `env:"ENDPOINT_REPORTER_RETRY_CODES"`
+}
+
+// Policy returns the retry policy.
+func (c Retry) Policy() report.RetryPolicy {
+	return report.RetryPolicy{
+		MaxAttempts: c.MaxAttempts,
+		BaseBackoff: c.BaseBackoff,
+		MaxBackoff:  c.MaxBackoff,
+		Jitter:      c.Jitter,
+		Codes:       c.Codes,
+	}
+}
+
+// CircuitBreaker contains the configuration for the circuit breaker of the
+// primary reporter (the reporter of type and name, not the fallbacks, fan-out
+// or route targets). The breaker opens when FailureRate of the last WindowSize runs
+// fail (with at least MinRequests runs), stays open for CoolDown and closes
+// again after HalfOpenRequests successful trial runs.
+type CircuitBreaker struct {
+	Enabled          bool          `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_ENABLED"`
+	WindowSize       int           `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_WINDOW_SIZE"`
+	MinRequests      int           `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_MIN_REQUESTS"`
+	FailureRate      float64       `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_FAILURE_RATE"`
+	CoolDown         time.Duration `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_COOL_DOWN"`
+	HalfOpenRequests int           `env:"ENDPOINT_REPORTER_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS"`
+}
+
+// FanOut contains the configuration for sending every report with
+// additional reporters, besides the reporter and its fallbacks. Policy
+// decides if a report succeeded: all, any or primary (the reporter must
+// succeed, the targets are best-effort). Sequential sends reports with the
+// reporters in order instead of concurrently. Fan-out is disabled if no
+// targets are set.
+type FanOut struct {
+	Targets    []Target `env:"ENDPOINT_REPORTER_FANOUT_TARGETS"`
+	Policy     string   `env:"ENDPOINT_REPORTER_FANOUT_POLICY"`
+	Sequential bool     `env:"ENDPOINT_REPORTER_FANOUT_SEQUENTIAL"`
+}
+
+// ClaimCheck contains the configuration for claim checks. Data of reports
+// above the threshold is stored with the output binding, and only a
+// reference is sent with the report. Claim checks are disabled if no
+// name is set. Uploaded data above the threshold is streamed to the
+// output binding in parts of PartSize.
+type ClaimCheck struct {
+	Name        string        `env:"ENDPOINT_CLAIM_CHECK_NAME"`
+	Threshold   int           `env:"ENDPOINT_CLAIM_CHECK_THRESHOLD"`
+	Timeout     time.Duration `env:"ENDPOINT_CLAIM_CHECK_TIMEOUT"`
+	MaxDataSize int           `env:"ENDPOINT_CLAIM_CHECK_MAX_DATA_SIZE"`
+	PartSize    int           `env:"ENDPOINT_CLAIM_CHECK_PART_SIZE"`
+}
+
+// MaxDataSize returns the maximum size in bytes of the data of a report
+// for the configured reporter type, or for claim checks if enabled. With
+// fallbacks or routes the smallest size of the reporter types is returned,
+// so that every reporter can send the report.
+func (c Reporter) MaxDataSize() int {
+	if len(c.ClaimCheck.Name) > 0 {
+		return c.ClaimCheck.MaxDataSize
+	}
+	size := c.QueueMaxDataSize
+	if c.Type == reporterTypePubsub {
+		size = c.PubsubMaxDataSize
+	}
+	for _, t := range c.Targets()[1:] {
+		if t.Type == reporterTypePubsub {
+			size = min(size, c.PubsubMaxDataSize)
+		} else {
+			size = min(size, c.QueueMaxDataSize)
+		}
+	}
+	return size
+}
+
+// MaxDuration returns the longest time a report can take to be sent.
+// Retries share the timeout, and the fallbacks of a chain share it with
+// the reporter. The timeout of claim checks is added if they are enabled,
+// and with sequential fan-out the time of every target.
+func (c Reporter) MaxDuration() time.Duration {
+	d := c.Timeout
+	if len(c.ClaimCheck.Name) > 0 {
+		d += c.ClaimCheck.Timeout
+	}
+	if c.FanOut.Sequential {
+		d *= time.Duration(1 + len(c.FanOut.Targets))
+	}
+	return d
+}
+
+// Target returns the target of the reporter.
+func (c Reporter) Target() Target {
+	return Target{Type: c.Type, Name: c.Name, Queue: c.Queue, Topic: c.Topic}
+}
+
+// Targets returns the targets of the reporter, of the fallbacks, of the
+// fan-out and of the routes, in order.
+func (c Reporter) Targets() []Target {
+	targets := append([]Target{c.Target()}, c.Fallbacks...)
+	targets = append(targets, c.FanOut.Targets...)
+	for _, route := range c.Routes {
+		targets = append(targets, route.Target)
+	}
+	return targets
+}
+
+// Topics returns the topics or queues the report can be sent to: the
+// target of the first route it matches, or the targets of the reporter,
+// the fallbacks and the fan-out if it matches no route.
+func (c Reporter) Topics(re report.Report) []string {
+	for _, route := range c.Routes {
+		if route.Match.Match().Matches(re) {
+			return []string{route.Target.Destination()}
+		}
+	}
+	targets := append([]Target{c.Target()}, c.Fallbacks...)
+	targets = append(targets, c.FanOut.Targets...)
+	topics := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if !slices.Contains(topics, target.Destination()) {
+			topics = append(topics, target.Destination())
+		}
+	}
+	return topics
+}
+
+// Tracing contains the configuration for OpenTelemetry tracing. Tracing
+// is disabled if no exporter is set.
+type Tracing struct {
+	// Exporter is the exporter of spans: otlp, stdout or file.
+	Exporter string `env:"ENDPOINT_TRACING_EXPORTER"`
+	// Endpoint is the address of the OTLP collector. The OpenTelemetry
+	// environment variables are used if empty.
+	Endpoint    string  `env:"ENDPOINT_TRACING_ENDPOINT"`
+	Insecure    bool    `env:"ENDPOINT_TRACING_INSECURE"`
+	File        string  `env:"ENDPOINT_TRACING_FILE"`
+	SampleRatio float64 `env:"ENDPOINT_TRACING_SAMPLE_RATIO"`
+}
+
+// State contains the configuration for the state store. State is
+// disabled if no name is set.
+type State struct {
+	Name            string        `env:"ENDPOINT_STATE_NAME"`
+	Timeout         time.Duration `env:"ENDPOINT_STATE_TIMEOUT"`
+	StatusRetention time.Duration `env:"ENDPOINT_STATE_STATUS_RETENTION"`
+}
+
+// HMAC contains the configuration for HMAC signed requests. The shared
+// secrets are loaded from a file or, if not set, a DAPR secret store.
+// Nonces are kept in the state store.
+type HMAC struct {
+	SecretsFile string        `env:"ENDPOINT_SECURITY_HMAC_SECRETS_FILE"`
+	SecretStore string        `env:"ENDPOINT_SECURITY_HMAC_SECRET_STORE"`
+	SecretName  string        `env:"ENDPOINT_SECURITY_HMAC_SECRET_NAME"`
+	MaxSkew     time.Duration `env:"ENDPOINT_SECURITY_HMAC_MAX_SKEW"`
 }
 
 // New creates a new *Configuration based on environment variables
@@ -66,55 +529,613 @@ type Reporter struct {
 func New() (*Configuration, error) {
 	c := &Configuration{
 		Server: Server{
-			Host:         defaultHost,
-			Port:         defaultPort,
-			ReadTimeout:  defaultReadTimeout,
-			WriteTimeout: defaultWriteTimeout,
-			IdleTimeout:  defaultIdleTimeout,
+			Host:            defaultHost,
+			Port:            defaultPort,
+			ReadTimeout:     defaultReadTimeout,
+			WriteTimeout:    defaultWriteTimeout,
+			IdleTimeout:     defaultIdleTimeout,
+			MaxBatchSize:    defaultMaxBatchSize,
+			ShutdownDelay:   defaultShutdownDelay,
+			ShutdownTimeout: defaultShutdownTimeout,
+			Security: Security{
+				JWT: JWT{
+					NameClaim:           defaultJWTNameClaim,
+					JWKSRefreshInterval: defaultJWKSRefreshInterval,
+				},
+				HMAC: HMAC{
+					SecretName: defaultHMACSecretName,
+					MaxSkew:    defaultHMACMaxSkew,
+				},
+				Schemes:            map[string]struct{}{SchemeAPIKey: {}},
+				KeysSecretName:     defaultKeysSecretName,
+				KeysReloadInterval: defaultKeysReloadInterval,
+			},
+			Idempotency: Idempotency{
+				Window: defaultIdempotencyWindow,
+			},
+			Validation: Validation{
+				MaxBodySize:      defaultMaxBodySize,
+				MaxBatchBodySize: defaultMaxBatchBodySize,
+			},
+			RateLimit: RateLimit{
+				Backend: rateLimitBackendMemory,
+			},
+			Usage: Usage{
+				Retention: defaultUsageRetention,
+			},
+			Health: Health{
+				Timeout: defaultHealthTimeout,
+			},
+			Metrics: Metrics{
+				Enabled: true,
+			},
+			AccessLog: AccessLog{
+				Enabled:       true,
+				RedactHeaders: defaultAccessLogRedactHeaders(),
+			},
 		},
 		Reporter: Reporter{
-			Type:    defaultReporterType,
-			Name:    defaultReporterName,
-			Timeout: defaultReporterTimeout,
-			Queue:   defaultReporterQueue,
-			Topic:   defaultReporterTopic,
+			Type:              defaultReporterType,
+			Name:              defaultReporterName,
+			Timeout:           defaultReporterTimeout,
+			Queue:             defaultReporterQueue,
+			Topic:             defaultReporterTopic,
+			Concurrency:       defaultReporterConcurrency,
+			QueueMaxDataSize:  defaultReporterMaxDataSize,
+			PubsubMaxDataSize: defaultReporterMaxDataSize,
+			Retry: Retry{
+				MaxAttempts: defaultRetryMaxAttempts,
+				BaseBackoff: defaultRetryBaseBackoff,
+				MaxBackoff:  defaultRetryMaxBackoff,
+				Jitter:      defaultRetryJitter,
+				Codes:       defaultRetryCodes(),
+			},
+			CircuitBreaker: CircuitBreaker{
+				WindowSize:       defaultCircuitBreakerWindowSize,
+				MinRequests:      defaultCircuitBreakerMinRequests,
+				FailureRate:      defaultCircuitBreakerFailureRate,
+				CoolDown:         defaultCircuitBreakerCoolDown,
+				HalfOpenRequests: defaultCircuitBreakerHalfOpenRequests,
+			},
+			FanOut: FanOut{
+				Policy: defaultFanOutPolicy,
+			},
+			ClaimCheck: ClaimCheck{
+				Threshold:   defaultClaimCheckThreshold,
+				Timeout:     defaultClaimCheckTimeout,
+				MaxDataSize: defaultClaimCheckMaxDataSize,
+				PartSize:    defaultClaimCheckPartSize,
+			},
+		},
+		State: State{
+			Timeout:         defaultStateTimeout,
+			StatusRetention: defaultStateStatusRetention,
+		},
+		Tracing: Tracing{
+			SampleRatio: defaultTracingSampleRatio,
 		},
 	}
 
 	if err := parseEnv(c); err != nil {
 		return nil, err
 	}
+	// Headers set in the environment are redacted in addition to the
+	// defaults, so that credentials are never logged.
+	if c.Server.AccessLog.RedactHeaders == nil {
+		c.Server.AccessLog.RedactHeaders = make(map[string]struct{})
+	}
+	maps.Copy(c.Server.AccessLog.RedactHeaders, defaultAccessLogRedactHeaders())
+
+	for i := range c.Reporter.Fallbacks {
+		if err := checkTarget(&c.Reporter.Fallbacks[i]); err != nil {
+			return nil, fmt.Errorf("fallback reporter %d: %w", i, err)
+		}
+	}
+
+	switch report.FanOutPolicy(c.Reporter.FanOut.Policy) {
+	case report.FanOutAll, report.FanOutAny, report.FanOutPrimary:
+	default:
+		return nil, fmt.Errorf("unknown fan-out policy: %q", c.Reporter.FanOut.Policy)
+	}
+	for i := range c.Reporter.FanOut.Targets {
+		if err := checkTarget(&c.Reporter.FanOut.Targets[i]); err != nil {
+			return nil, fmt.Errorf("fan-out reporter %d: %w", i, err)
+		}
+	}
+
+	routes := map[string]struct{}{defaultRoute: {}}
+	for i, route := range c.Reporter.Routes {
+		if len(route.Name) == 0 {
+			return nil, fmt.Errorf("route %d: name is required", i)
+		}
+		if _, ok := routes[route.Name]; ok {
+			return nil, fmt.Errorf("route %d: name %q is not unique", i, route.Name)
+		}
+		routes[route.Name] = struct{}{}
+		if err := checkTarget(&c.Reporter.Routes[i].Target); err != nil {
+			return nil, fmt.Errorf("route %s: %w", route.Name, err)
+		}
+	}
+
+	for scheme := range c.Server.Security.Schemes {
+		if scheme != SchemeAPIKey && scheme != SchemeJWT && scheme != SchemeHMAC {
+			return nil, fmt.Errorf("unknown security scheme: %q", scheme)
+		}
+	}
 
 	return c, nil
 }
 
+// SetupKeys sets up a new *auth.KeySet based on the provided configuration.
+// Keys from a file or a secret store are reloaded at the configured interval,
+// and onError is called with errors from reloading. Keys from the environment
+// are hashed and named after the start of their hash.
+func SetupKeys(c Security, onError func(err error)) (*auth.KeySet, error) {
+	var loader auth.Loader
+	interval := c.KeysReloadInterval
+	if len(c.KeysFile) > 0 {
+		loader = auth.NewFileLoader(c.KeysFile)
+	} else if len(c.KeysSecretStore) > 0 {
+		l, err := auth.NewSecretLoader(c.KeysSecretStore, func(o *auth.SecretLoaderOptions) {
+			o.Name = c.KeysSecretName
+		})
+		if err != nil {
+			return nil, fmt.Errorf("setup keys: %w", err)
+		}
+		loader = l
+	} else {
+		keys := make([]auth.Key, 0, len(c.Keys))
+		for key := range c.Keys {
+			hash := auth.HashKey(key)
+			keys = append(keys, auth.Key{Name: "key-" + hash[:8], Hash: hash})
+		}
+		loader = auth.StaticLoader(keys)
+		interval = -1
+	}
+
+	keys, err := auth.NewKeySet(loader, func(o *auth.KeySetOptions) {
+		o.ReloadInterval = interval
+		if onError != nil {
+			o.OnError = onError
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup keys: %w", err)
+	}
+	return keys, nil
+}
+
+// SetupTokens sets up a new *auth.JWTValidator based on the provided
+// configuration. The keys are refreshed at the configured interval, and
+// onError is called with errors from refreshing.
+func SetupTokens(c JWT, onError func(err error)) (*auth.JWTValidator, error) {
+	keys, err := auth.NewJWKS(c.JWKS, func(o *auth.JWKSOptions) {
+		o.RefreshInterval = c.JWKSRefreshInterval
+		if onError != nil {
+			o.OnError = onError
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup tokens: %w", err)
+	}
+
+	tokens, err := auth.NewJWTValidator(keys, c.Issuer, c.Audience, func(o *auth.JWTValidatorOptions) {
+		o.NameClaim = c.NameClaim
+	})
+	if err != nil {
+		keys.Close()
+		return nil, fmt.Errorf("setup tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// SetupSignatures sets up a new *auth.HMACVerifier based on the provided
+// configuration. The secrets are loaded once, and nonces are kept in the
+// provided store.
+func SetupSignatures(c HMAC, store state.Store) (*auth.HMACVerifier, error) {
+	if store == nil {
+		return nil, errors.New("setup signatures: state store is required")
+	}
+
+	var b []byte
+	var err error
+	if len(c.SecretsFile) > 0 {
+		b, err = os.ReadFile(c.SecretsFile)
+	} else if len(c.SecretStore) > 0 {
+		var l *auth.SecretLoader
+		l, err = auth.NewSecretLoader(c.SecretStore, func(o *auth.SecretLoaderOptions) {
+			o.Name = c.SecretName
+		})
+		if err == nil {
+			b, err = l.Read()
+		}
+	} else {
+		err = errors.New("secrets file or secret store is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("setup signatures: %w", err)
+	}
+
+	secrets, err := auth.ParseSecrets(b)
+	if err != nil {
+		return nil, fmt.Errorf("setup signatures: %w", err)
+	}
+	signatures, err := auth.NewHMACVerifier(secrets, store, func(o *auth.HMACVerifierOptions) {
+		o.MaxSkew = c.MaxSkew
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup signatures: %w", err)
+	}
+	return signatures, nil
+}
+
+// SetupRateLimiter sets up a new ratelimit.Limiter based on the provided
+// configuration. The state backend requires the provided store.
+func SetupRateLimiter(c RateLimit, store state.Store) (ratelimit.Limiter, error) {
+	switch c.Backend {
+	case rateLimitBackendMemory:
+		return ratelimit.NewMemoryLimiter(), nil
+	case rateLimitBackendState:
+		l, err := ratelimit.NewStoreLimiter(store)
+		if err != nil {
+			return nil, fmt.Errorf("setup rate limiter: %w", err)
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("setup rate limiter: unknown backend: %q", c.Backend)
+	}
+}
+
+// SetupUsage sets up a new *usage.Tracker based on the provided
+// configuration and store.
+func SetupUsage(c Usage, store state.Store) (*usage.Tracker, error) {
+	tracker, err := usage.NewTracker(store, func(o *usage.TrackerOptions) {
+		o.Retention = c.Retention
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup usage: %w", err)
+	}
+	return tracker, nil
+}
+
+// Components returns the DAPR components the application uses: the
+// bindings or pubsubs of the reporter and its fallbacks, and the claim
+// check binding and state store if configured.
+func (c Configuration) Components() []health.Component {
+	var components []health.Component
+	for _, t := range c.Reporter.Targets() {
+		kind := health.KindBindings
+		if t.Type == reporterTypePubsub {
+			kind = health.KindPubsub
+		}
+		component := health.Component{Kind: kind, Name: t.Name}
+		if !slices.Contains(components, component) {
+			components = append(components, component)
+		}
+	}
+	if len(c.Reporter.ClaimCheck.Name) > 0 {
+		components = append(components, health.Component{Kind: health.KindBindings, Name: c.Reporter.ClaimCheck.Name})
+	}
+	if len(c.State.Name) > 0 {
+		components = append(components, health.Component{Kind: health.KindState, Name: c.State.Name})
+	}
+	return components
+}
+
+// SetupHealth sets up a new *health.DaprChecker for the provided
+// components.
+func SetupHealth(c Health, components []health.Component) (*health.DaprChecker, error) {
+	checker, err := health.NewDaprChecker(func(o *health.DaprCheckerOptions) {
+		o.Components = components
+		o.Timeout = c.Timeout
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup health: %w", err)
+	}
+	return checker, nil
+}
+
+// SetupTracing sets up a new *tracing.Provider based on the provided
+// configuration. Returns nil if no exporter is configured.
+func SetupTracing(c Tracing) (*tracing.Provider, error) {
+	if len(c.Exporter) == 0 {
+		return nil, nil
+	}
+	p, err := tracing.NewProvider(func(o *tracing.ProviderOptions) {
+		o.Exporter = c.Exporter
+		o.Endpoint = c.Endpoint
+		o.Insecure = c.Insecure
+		o.File = c.File
+		o.SampleRatio = c.SampleRatio
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup tracing: %w", err)
+	}
+	return p, nil
+}
+
+// SetupState sets up a new state.Store based on the provided configuration.
+// Returns nil if no state store name is configured.
+func SetupState(c State) (state.Store, error) {
+	if len(c.Name) == 0 {
+		return nil, nil
+	}
+	s, err := state.NewDaprStore(func(o *state.DaprStoreOptions) {
+		o.Name = c.Name
+		o.Timeout = c.Timeout
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup state: %w", err)
+	}
+	return s, nil
+}
+
+// SetupCircuitBreaker sets up a new *report.CircuitBreaker for the primary
+// reporter based on the provided configuration. Returns nil if the circuit
+// breaker is disabled.
+// If m is not nil the state of the breaker is recorded with it, and
+// onStateChange is called when the state changes.
+func SetupCircuitBreaker(c Reporter, m *metrics.Metrics, onStateChange func(from, to report.CircuitState)) *report.CircuitBreaker {
+	if !c.CircuitBreaker.Enabled {
+		return nil
+	}
+	if m != nil {
+		m.CircuitState(c.Type, c.Name, report.CircuitClosed)
+	}
+	return report.NewCircuitBreaker(func(o *report.CircuitBreakerOptions) {
+		o.WindowSize = c.CircuitBreaker.WindowSize
+		o.MinRequests = c.CircuitBreaker.MinRequests
+		o.FailureRate = c.CircuitBreaker.FailureRate
+		o.CoolDown = c.CircuitBreaker.CoolDown
+		o.HalfOpenRequests = c.CircuitBreaker.HalfOpenRequests
+		o.OnStateChange = func(from, to report.CircuitState) {
+			if m != nil {
+				m.CircuitState(c.Type, c.Name, to)
+			}
+			if onStateChange != nil {
+				onStateChange(from, to)
+			}
+		}
+	})
+}
+
+// ReporterOptions contains the optional dependencies and callbacks of
+// the reporter service. Every field is optional.
+type ReporterOptions struct {
+	// Store is used to track the status of reports.
+	Store state.Store
+	// StatusRetention is the time the status of a report is kept.
+	StatusRetention time.Duration
+	// Metrics records the runs and attempts of the reporters, labelled
+	// with the type and name of every target.
+	Metrics *metrics.Metrics
+	// Tracing traces the runs of the reporters, with a span for every
+	// target.
+	Tracing *tracing.Provider
+	// PrimaryCircuitBreaker wraps the primary reporter only. Fallbacks,
+	// fan-out and route targets are not wrapped.
+	PrimaryCircuitBreaker *report.CircuitBreaker
+	// OnAttempt is called after every attempt of a reporter, with the
+	// target of the reporter.
+	OnAttempt func(target string, a report.Attempt)
+	// OnDelivery is called after a report has been sent with the reporters
+	// of the fan-out, once per reporter.
+	OnDelivery func(id string, d report.Delivery)
+	// OnRoute is called when the route of a report is chosen.
+	OnRoute func(re report.Report)
+}
+
 // SetupReporter sets up a new report.Service based on the provided configuration.
-func SetupReporter(c Reporter) (report.Service, error) {
-	var r report.Reporter
+// With fallbacks the reports are sent with a chain of the reporter and its
+// fallbacks. The primary reporter is wrapped with the circuit breaker if
+// set, and every reporter of the chain with a claim check reporter if claim
+// checks are enabled. With
+// fan-out targets the reports are sent with the chain and the targets. With
+// routes the reports are routed to the targets of the routes, and reports
+// that match no route are sent with the chain (or fan-out).
+func SetupReporter(c Reporter, options ReporterOptions) (report.Service, error) {
+	targets := append([]Target{c.Target()}, c.Fallbacks...)
+	reporters := make([]report.NamedReporter, len(targets))
+	for i, target := range targets {
+		var breaker *report.CircuitBreaker
+		if i == 0 {
+			breaker = options.PrimaryCircuitBreaker
+		}
+		r, err := setupTarget(c, target, breaker, options)
+		if err != nil {
+			return nil, fmt.Errorf("setup service: %w", err)
+		}
+		// Every fallback stores its own copy of the data of claim checks,
+		// since a report that timed out with an earlier reporter of the
+		// chain may have been sent, and the data is removed when a report
+		// has been consumed.
+		var prefix string
+		if i > 0 {
+			prefix = claimCheckPrefix(target)
+		}
+		if r, err = setupClaimCheck(c, r, prefix); err != nil {
+			return nil, fmt.Errorf("setup service: %w", err)
+		}
+		reporters[i] = report.NamedReporter{Name: target.String(), Reporter: r}
+	}
+
 	var err error
-	if c.Type == reporterTypePubsub {
-		r, err = report.NewPubsubReporter(func(o *report.PubsubReporterOptions) {
-			o.Name = c.Name
-			o.Topic = c.Topic
+	r := reporters[0].Reporter
+	if len(reporters) > 1 {
+		// The reporters of the chain share the timeout, so that a report
+		// does not take longer than the timeout with all the fallbacks.
+		chain, err := report.NewChainReporter(reporters, func(o *report.ChainReporterOptions) {
 			o.Timeout = c.Timeout
 		})
 		if err != nil {
 			return nil, fmt.Errorf("setup service: %w", err)
 		}
-	} else if c.Type == reporterTypeQueue {
-		r, err = report.NewQueueReporter(func(o *report.QueueReporterOptions) {
-			o.Name = c.Name
-			o.Queue = c.Queue
+		r = chain
+	}
+
+	if len(c.FanOut.Targets) > 0 {
+		reporters := []report.NamedReporter{{Name: targets[0].String(), Reporter: r}}
+		for _, target := range c.FanOut.Targets {
+			fr, err := setupTarget(c, target, nil, options)
+			if err != nil {
+				return nil, fmt.Errorf("setup service: %w", err)
+			}
+			// Every target stores its own copy of the data of claim checks,
+			// since the data is removed when a report has been consumed.
+			if fr, err = setupClaimCheck(c, fr, claimCheckPrefix(target)); err != nil {
+				return nil, fmt.Errorf("setup service: %w", err)
+			}
+			reporters = append(reporters, report.NamedReporter{Name: target.String(), Reporter: fr})
+		}
+		r, err = report.NewFanOutReporter(reporters, func(o *report.FanOutReporterOptions) {
+			o.Policy = report.FanOutPolicy(c.FanOut.Policy)
+			o.Sequential = c.FanOut.Sequential
 			o.Timeout = c.Timeout
+			o.OnDelivery = options.OnDelivery
 		})
 		if err != nil {
 			return nil, fmt.Errorf("setup service: %w", err)
 		}
-	} else {
-		return nil, fmt.Errorf("setup service: unknown reporter type: %q", c.Type)
 	}
 
-	return report.NewService(r)
+	if len(c.Routes) > 0 {
+		routes := make([]report.Route, len(c.Routes))
+		for i, route := range c.Routes {
+			rr, err := setupTarget(c, route.Target, nil, options)
+			if err != nil {
+				return nil, fmt.Errorf("setup service: %w", err)
+			}
+			if rr, err = setupClaimCheck(c, rr, ""); err != nil {
+				return nil, fmt.Errorf("setup service: %w", err)
+			}
+			routes[i] = report.Route{
+				Name:     route.Name,
+				Match:    route.Match.Match(),
+				Reporter: report.NamedReporter{Name: route.Target.String(), Reporter: rr},
+			}
+		}
+		// Reports that match no route are sent with the chain, which records
+		// the reporter that accepted them itself.
+		r, err = report.NewRoutingReporter(routes, report.Route{Name: defaultRoute, Reporter: report.NamedReporter{Name: targets[0].String(), Reporter: r}}, func(o *report.RoutingReporterOptions) {
+			o.OnRoute = options.OnRoute
+		})
+		if err != nil {
+			return nil, fmt.Errorf("setup service: %w", err)
+		}
+	}
+
+	return report.NewService(r, func(o *report.ServiceOptions) {
+		o.Store = options.Store
+		o.Retention = options.StatusRetention
+	})
+}
+
+// setupClaimCheck wraps the provided reporter with a claim check reporter
+// if claim checks are enabled. The names of the stored data are prefixed
+// with prefix.
+func setupClaimCheck(c Reporter, r report.Reporter, prefix string) (report.Reporter, error) {
+	if len(c.ClaimCheck.Name) == 0 {
+		return r, nil
+	}
+	return report.NewClaimCheckReporter(r, func(o *report.ClaimCheckReporterOptions) {
+		o.Name = c.ClaimCheck.Name
+		o.Prefix = prefix
+		o.Threshold = c.ClaimCheck.Threshold
+		o.Timeout = c.ClaimCheck.Timeout
+	})
+}
+
+// SetupClaimCheckStore sets up a new claim check store for uploaded data
+// with the provided configuration. Returns nil if claim checks are
+// disabled.
+func SetupClaimCheckStore(c Reporter) (*report.ClaimCheckStore, error) {
+	if len(c.ClaimCheck.Name) == 0 {
+		return nil, nil
+	}
+	return report.NewClaimCheckStore(func(o *report.ClaimCheckStoreOptions) {
+		o.Name = c.ClaimCheck.Name
+		o.PartSize = c.ClaimCheck.PartSize
+		o.Timeout = c.ClaimCheck.Timeout
+	})
+}
+
+// claimCheckPrefix returns the prefix of the names of the data of claim
+// checks stored for the provided target.
+func claimCheckPrefix(target Target) string {
+	return strings.ReplaceAll(target.String(), "/", "-") + "."
+}
+
+// setupTarget sets up a new queue or pubsub reporter for the provided
+// target, with the retry policy of the configuration. The reporter is
+// wrapped with the circuit breaker if not nil, and traced and recorded
+// with the type and name of the target.
+func setupTarget(c Reporter, target Target, breaker *report.CircuitBreaker, options ReporterOptions) (report.Reporter, error) {
+	r, err := newTargetReporter(c, target, options)
+	if err != nil {
+		return nil, err
+	}
+	if breaker != nil {
+		r = breaker.Reporter(r)
+	}
+	if options.Tracing != nil {
+		r = options.Tracing.Reporter(r, target.Type, target.Name)
+	}
+	if options.Metrics != nil {
+		r = options.Metrics.Reporter(r, target.Type, target.Name)
+	}
+	return r, nil
+}
+
+// newTargetReporter creates a new queue or pubsub reporter for the
+// provided target.
+func newTargetReporter(c Reporter, target Target, options ReporterOptions) (report.Reporter, error) {
+	retry := c.Retry.Policy()
+	retry.OnAttempt = func(a report.Attempt) {
+		if options.Metrics != nil {
+			options.Metrics.Attempt(target.Type, target.Name, a)
+		}
+		if options.OnAttempt != nil {
+			options.OnAttempt(target.String(), a)
+		}
+	}
+
+	switch target.Type {
+	case reporterTypePubsub:
+		return report.NewPubsubReporter(func(o *report.PubsubReporterOptions) {
+			o.Name = target.Name
+			o.Topic = target.Topic
+			o.Timeout = c.Timeout
+			o.Retry = retry
+		})
+	case reporterTypeQueue:
+		return report.NewQueueReporter(func(o *report.QueueReporterOptions) {
+			o.Name = target.Name
+			o.Queue = target.Queue
+			o.Timeout = c.Timeout
+			o.Concurrency = c.Concurrency
+			o.Retry = retry
+		})
+	default:
+		return nil, fmt.Errorf("unknown reporter type: %q", target.Type)
+	}
+}
+
+// checkTarget checks the type and name of the target, and sets the
+// default queue and topic if not set.
+func checkTarget(t *Target) error {
+	if t.Type != reporterTypeQueue && t.Type != reporterTypePubsub {
+		return fmt.Errorf("unknown reporter type: %q", t.Type)
+	}
+	if len(t.Name) == 0 {
+		return errors.New("name is required")
+	}
+	if len(t.Queue) == 0 {
+		t.Queue = defaultReporterQueue
+	}
+	if len(t.Topic) == 0 {
+		t.Topic = defaultReporterTopic
+	}
+	return nil
 }
 
 // parseEnv parses the provided value using the env package.
@@ -122,6 +1143,9 @@ func parseEnv(v any) error {
 	return env.ParseWithOptions(v, env.Options{
 		FuncMap: map[reflect.Type]env.ParserFunc{
 			reflect.TypeOf(map[string]struct{}{}): parseStructMap,
+			reflect.TypeOf([]codes.Code{}):        parseCodes,
+			reflect.TypeOf([]Target{}):            parseTargets,
+			reflect.TypeOf([]Route{}):             parseRoutes,
 		},
 	})
 }
@@ -135,3 +1159,34 @@ func parseStructMap(v string) (any, error) {
 	}
 	return m, nil
 }
+
+// parseCodes parses the provided comma separated string of gRPC code
+// names into a []codes.Code.
+func parseCodes(v string) (any, error) {
+	parts := strings.Split(strings.ReplaceAll(v, " ", ""), ",")
+	c := make([]codes.Code, len(parts))
+	for i, v := range parts {
+		if err := c[i].UnmarshalJSON([]byte(`"` + strings.ToUpper(v) + `"`)); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// parseTargets parses the provided JSON array into a []Target.
+func parseTargets(v string) (any, error) {
+	var targets []Target
+	if err := json.Unmarshal([]byte(v), &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// parseRoutes parses the provided JSON array into a []Route.
+func parseRoutes(v string) (any, error) {
+	var routes []Route
+	if err := json.Unmarshal([]byte(v), &routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
@@ -3,10 +3,20 @@ package config
 import (
 	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/health"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
 )
 
 func TestNew(t *testing.T) {
@@ -21,56 +31,303 @@ func TestNew(t *testing.T) {
 			input: map[string]string{},
 			want: &Configuration{
 				Server: Server{
-					Host:         defaultHost,
-					Port:         defaultPort,
-					ReadTimeout:  defaultReadTimeout,
-					WriteTimeout: defaultWriteTimeout,
-					IdleTimeout:  defaultIdleTimeout,
+					Host:            defaultHost,
+					Port:            defaultPort,
+					ReadTimeout:     defaultReadTimeout,
+					WriteTimeout:    defaultWriteTimeout,
+					IdleTimeout:     defaultIdleTimeout,
+					MaxBatchSize:    defaultMaxBatchSize,
+					ShutdownDelay:   defaultShutdownDelay,
+					ShutdownTimeout: defaultShutdownTimeout,
+					Security: Security{
+						JWT: JWT{
+							NameClaim:           defaultJWTNameClaim,
+							JWKSRefreshInterval: defaultJWKSRefreshInterval,
+						},
+						HMAC: HMAC{
+							SecretName: defaultHMACSecretName,
+							MaxSkew:    defaultHMACMaxSkew,
+						},
+						Schemes:            map[string]struct{}{SchemeAPIKey: {}},
+						KeysSecretName:     defaultKeysSecretName,
+						KeysReloadInterval: defaultKeysReloadInterval,
+					},
+					Idempotency: Idempotency{
+						Window: defaultIdempotencyWindow,
+					},
+					Validation: Validation{
+						MaxBodySize:      defaultMaxBodySize,
+						MaxBatchBodySize: defaultMaxBatchBodySize,
+					},
+					RateLimit: RateLimit{
+						Backend: rateLimitBackendMemory,
+					},
+					Usage: Usage{
+						Retention: defaultUsageRetention,
+					},
+					Health: Health{
+						Timeout: defaultHealthTimeout,
+					},
+					Metrics: Metrics{
+						Enabled: true,
+					},
+					AccessLog: AccessLog{
+						Enabled:       true,
+						RedactHeaders: defaultAccessLogRedactHeaders(),
+					},
 				},
 				Reporter: Reporter{
-					Type:    defaultReporterType,
-					Name:    defaultReporterName,
-					Timeout: defaultReporterTimeout,
-					Queue:   defaultReporterQueue,
-					Topic:   defaultReporterTopic,
+					Type:              defaultReporterType,
+					Name:              defaultReporterName,
+					Timeout:           defaultReporterTimeout,
+					Queue:             defaultReporterQueue,
+					Topic:             defaultReporterTopic,
+					Concurrency:       defaultReporterConcurrency,
+					QueueMaxDataSize:  defaultReporterMaxDataSize,
+					PubsubMaxDataSize: defaultReporterMaxDataSize,
+					Retry: Retry{
+						MaxAttempts: defaultRetryMaxAttempts,
+						BaseBackoff: defaultRetryBaseBackoff,
+						MaxBackoff:  defaultRetryMaxBackoff,
+						Jitter:      defaultRetryJitter,
+						Codes:       defaultRetryCodes(),
+					},
+					CircuitBreaker: CircuitBreaker{
+						WindowSize:       defaultCircuitBreakerWindowSize,
+						MinRequests:      defaultCircuitBreakerMinRequests,
+						FailureRate:      defaultCircuitBreakerFailureRate,
+						CoolDown:         defaultCircuitBreakerCoolDown,
+						HalfOpenRequests: defaultCircuitBreakerHalfOpenRequests,
+					},
+					FanOut: FanOut{
+						Policy: defaultFanOutPolicy,
+					},
+					ClaimCheck: ClaimCheck{
+						Threshold:   defaultClaimCheckThreshold,
+						Timeout:     defaultClaimCheckTimeout,
+						MaxDataSize: defaultClaimCheckMaxDataSize,
+						PartSize:    defaultClaimCheckPartSize,
+					},
+				},
+				State: State{
+					Timeout:         defaultStateTimeout,
+					StatusRetention: defaultStateStatusRetention,
+				},
+				Tracing: Tracing{
+					SampleRatio: defaultTracingSampleRatio,
 				},
 			},
 		},
 		{
 			name: "With environment variables",
 			input: map[string]string{
-				"ENDPOINT_HOST":             "localhost",
-				"ENDPOINT_PORT":             "3001",
-				"ENDPOINT_READ_TIMEOUT":     "10s",
-				"ENDPOINT_WRITE_TIMEOUT":    "10s",
-				"ENDPOINT_IDLE_TIMEOUT":     "10s",
-				"ENDPOINT_REPORTER_TYPE":    "pubsub-test",
-				"ENDPOINT_REPORTER_NAME":    "reports-test",
-				"ENDPOINT_REPORTER_TIMEOUT": "5s",
-				"ENDPOINT_REPORTER_QUEUE":   "create-test",
-				"ENDPOINT_REPORTER_TOPIC":   "create-test",
-				"ENDPOINT_SECURITY_KEYS":    "key1,key2",
+				"ENDPOINT_HOST":                                        "localhost",
+				"ENDPOINT_PORT":                                        "3001",
+				"ENDPOINT_READ_TIMEOUT":                                "10s",
+				"ENDPOINT_WRITE_TIMEOUT":                               "10s",
+				"ENDPOINT_IDLE_TIMEOUT":                                "10s",
+				"ENDPOINT_IDEMPOTENCY_WINDOW":                          "1h",
+				"ENDPOINT_MAX_BATCH_SIZE":                              "50",
+				"ENDPOINT_SHUTDOWN_DELAY":                              "10s",
+				"ENDPOINT_SHUTDOWN_TIMEOUT":                            "20s",
+				"ENDPOINT_HEALTH_TIMEOUT":                              "1s",
+				"ENDPOINT_METRICS_ENABLED":                             "false",
+				"ENDPOINT_MAX_BODY_SIZE":                               "2048",
+				"ENDPOINT_MAX_BATCH_BODY_SIZE":                         "8192",
+				"ENDPOINT_RATE_LIMIT_RATE":                             "2.5",
+				"ENDPOINT_RATE_LIMIT_BURST":                            "10",
+				"ENDPOINT_RATE_LIMIT_BACKEND":                          "state",
+				"ENDPOINT_RATE_LIMIT_FAIL_CLOSED":                      "true",
+				"ENDPOINT_USAGE_DAILY_REPORTS":                         "1000",
+				"ENDPOINT_USAGE_DAILY_BYTES":                           "1048576",
+				"ENDPOINT_USAGE_MONTHLY_REPORTS":                       "20000",
+				"ENDPOINT_USAGE_MONTHLY_BYTES":                         "10485760",
+				"ENDPOINT_USAGE_RETENTION":                             "720h",
+				"ENDPOINT_REPORTER_QUEUE_MAX_DATA_SIZE":                "512",
+				"ENDPOINT_REPORTER_PUBSUB_MAX_DATA_SIZE":               "1024",
+				"ENDPOINT_CLAIM_CHECK_NAME":                            "reports-claims-test",
+				"ENDPOINT_CLAIM_CHECK_THRESHOLD":                       "256",
+				"ENDPOINT_CLAIM_CHECK_TIMEOUT":                         "5s",
+				"ENDPOINT_CLAIM_CHECK_MAX_DATA_SIZE":                   "4096",
+				"ENDPOINT_CLAIM_CHECK_PART_SIZE":                       "1024",
+				"ENDPOINT_REPORTER_CONCURRENCY":                        "5",
+				"ENDPOINT_REPORTER_TYPE":                               "pubsub-test",
+				"ENDPOINT_REPORTER_NAME":                               "reports-test",
+				"ENDPOINT_REPORTER_TIMEOUT":                            "5s",
+				"ENDPOINT_REPORTER_QUEUE":                              "create-test",
+				"ENDPOINT_REPORTER_TOPIC":                              "create-test",
+				"ENDPOINT_REPORTER_RETRY_MAX_ATTEMPTS":                 "5",
+				"ENDPOINT_REPORTER_RETRY_BASE_BACKOFF":                 "50ms",
+				"ENDPOINT_REPORTER_RETRY_MAX_BACKOFF":                  "1s",
+				"ENDPOINT_REPORTER_RETRY_JITTER":                       "0.5",
+				"ENDPOINT_REPORTER_RETRY_CODES":                        "UNAVAILABLE,deadline_exceeded",
+				"ENDPOINT_REPORTER_FALLBACKS":                          `[{"type":"queue","name":"reports-queue"},{"type":"pubsub","name":"reports-west","topic":"create-west"}]`,
+				"ENDPOINT_REPORTER_ROUTES":                             `[{"name":"urgent","match":{"types":["alert"],"minPriority":8,"labels":{"region":"eu"}},"target":{"type":"queue","name":"reports-urgent"}}]`,
+				"ENDPOINT_REPORTER_FANOUT_TARGETS":                     `[{"type":"pubsub","name":"reports-analytics","topic":"analytics"}]`,
+				"ENDPOINT_REPORTER_FANOUT_POLICY":                      "all",
+				"ENDPOINT_REPORTER_FANOUT_SEQUENTIAL":                  "true",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_ENABLED":            "true",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_WINDOW_SIZE":        "50",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_MIN_REQUESTS":       "20",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_FAILURE_RATE":       "0.25",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_COOL_DOWN":          "1m",
+				"ENDPOINT_REPORTER_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS": "3",
+				"ENDPOINT_SECURITY_KEYS":                               "key1,key2",
+				"ENDPOINT_SECURITY_KEYS_FILE":                          "/etc/endpoint/keys.json",
+				"ENDPOINT_SECURITY_KEYS_SECRET_STORE":                  "secrets",
+				"ENDPOINT_SECURITY_KEYS_SECRET_NAME":                   "api-keys-test",
+				"ENDPOINT_SECURITY_KEYS_RELOAD_INTERVAL":               "30s",
+				"ENDPOINT_SECURITY_SCHEMES":                            "apikey,jwt,hmac",
+				"ENDPOINT_SECURITY_HMAC_SECRETS_FILE":                  "/etc/endpoint/secrets.json",
+				"ENDPOINT_SECURITY_HMAC_SECRET_STORE":                  "secrets",
+				"ENDPOINT_SECURITY_HMAC_SECRET_NAME":                   "hmac-secrets-test",
+				"ENDPOINT_SECURITY_HMAC_MAX_SKEW":                      "1m",
+				"ENDPOINT_SECURITY_JWT_ISSUER":                         "https://issuer.example.com",
+				"ENDPOINT_SECURITY_JWT_AUDIENCE":                       "endpoint",
+				"ENDPOINT_SECURITY_JWT_JWKS":                           "/etc/endpoint/jwks.json",
+				"ENDPOINT_SECURITY_JWT_NAME_CLAIM":                     "azp",
+				"ENDPOINT_SECURITY_JWT_JWKS_REFRESH_INTERVAL":          "30m",
+				"ENDPOINT_STATE_NAME":                                  "reports-state-test",
+				"ENDPOINT_ACCESS_LOG_ENABLED":                          "false",
+				"ENDPOINT_ACCESS_LOG_REDACT_HEADERS":                   "Authorization,X-Custom",
+				"ENDPOINT_ACCESS_LOG_REDACT_FIELDS":                    "token",
+				"ENDPOINT_STATE_TIMEOUT":                               "5s",
+				"ENDPOINT_STATE_STATUS_RETENTION":                      "24h",
+				"ENDPOINT_TRACING_EXPORTER":                            "otlp",
+				"ENDPOINT_TRACING_ENDPOINT":                            "localhost:4317",
+				"ENDPOINT_TRACING_INSECURE":                            "true",
+				"ENDPOINT_TRACING_SAMPLE_RATIO":                        "0.5",
 			},
 			want: &Configuration{
 				Server: Server{
-					Host:         "localhost",
-					Port:         3001,
-					ReadTimeout:  time.Second * 10,
-					WriteTimeout: time.Second * 10,
-					IdleTimeout:  time.Second * 10,
+					Host:            "localhost",
+					Port:            3001,
+					ReadTimeout:     time.Second * 10,
+					WriteTimeout:    time.Second * 10,
+					IdleTimeout:     time.Second * 10,
+					MaxBatchSize:    50,
+					ShutdownDelay:   time.Second * 10,
+					ShutdownTimeout: time.Second * 20,
 					Security: Security{
+						JWT: JWT{
+							Issuer:              "https://issuer.example.com",
+							Audience:            "endpoint",
+							JWKS:                "/etc/endpoint/jwks.json",
+							NameClaim:           "azp",
+							JWKSRefreshInterval: time.Minute * 30,
+						},
+						HMAC: HMAC{
+							SecretsFile: "/etc/endpoint/secrets.json",
+							SecretStore: "secrets",
+							SecretName:  "hmac-secrets-test",
+							MaxSkew:     time.Minute,
+						},
+						Schemes: map[string]struct{}{
+							SchemeAPIKey: {},
+							SchemeJWT:    {},
+							SchemeHMAC:   {},
+						},
 						Keys: map[string]struct{}{
 							"key1": {},
 							"key2": {},
 						},
+						KeysFile:           "/etc/endpoint/keys.json",
+						KeysSecretStore:    "secrets",
+						KeysSecretName:     "api-keys-test",
+						KeysReloadInterval: time.Second * 30,
+					},
+					Idempotency: Idempotency{
+						Window: time.Hour,
+					},
+					Validation: Validation{
+						MaxBodySize:      2048,
+						MaxBatchBodySize: 8192,
+					},
+					RateLimit: RateLimit{
+						Rate:       2.5,
+						Burst:      10,
+						Backend:    "state",
+						FailClosed: true,
+					},
+					Usage: Usage{
+						DailyReports:   1000,
+						DailyBytes:     1048576,
+						MonthlyReports: 20000,
+						MonthlyBytes:   10485760,
+						Retention:      time.Hour * 24 * 30,
+					},
+					Health: Health{
+						Timeout: time.Second,
+					},
+					Metrics: Metrics{
+						Enabled: false,
+					},
+					AccessLog: AccessLog{
+						Enabled:       false,
+						RedactHeaders: map[string]struct{}{"Authorization": {}, "Cookie": {}, "X-API-Key": {}, "X-Custom": {}, "X-Signature": {}},
+						RedactFields:  map[string]struct{}{"token": {}},
 					},
 				},
 				Reporter: Reporter{
-					Type:    "pubsub-test",
-					Name:    "reports-test",
-					Timeout: time.Second * 5,
-					Queue:   "create-test",
-					Topic:   "create-test",
+					Type:              "pubsub-test",
+					Name:              "reports-test",
+					Timeout:           time.Second * 5,
+					Queue:             "create-test",
+					Topic:             "create-test",
+					Concurrency:       5,
+					QueueMaxDataSize:  512,
+					PubsubMaxDataSize: 1024,
+					Fallbacks: []Target{
+						{Type: reporterTypeQueue, Name: "reports-queue", Queue: defaultReporterQueue, Topic: defaultReporterTopic},
+						{Type: reporterTypePubsub, Name: "reports-west", Queue: defaultReporterQueue, Topic: "create-west"},
+					},
+					Routes: []Route{
+						{
+							Name:   "urgent",
+							Match:  RouteMatch{Types: []string{"alert"}, MinPriority: toPtr(8), Labels: map[string]string{"region": "eu"}},
+							Target: Target{Type: reporterTypeQueue, Name: "reports-urgent", Queue: defaultReporterQueue, Topic: defaultReporterTopic},
+						},
+					},
+					Retry: Retry{
+						MaxAttempts: 5,
+						BaseBackoff: time.Millisecond * 50,
+						MaxBackoff:  time.Second,
+						Jitter:      0.5,
+						Codes:       []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+					},
+					CircuitBreaker: CircuitBreaker{
+						Enabled:          true,
+						WindowSize:       50,
+						MinRequests:      20,
+						FailureRate:      0.25,
+						CoolDown:         time.Minute,
+						HalfOpenRequests: 3,
+					},
+					FanOut: FanOut{
+						Targets: []Target{
+							{Type: reporterTypePubsub, Name: "reports-analytics", Queue: defaultReporterQueue, Topic: "analytics"},
+						},
+						Policy:     "all",
+						Sequential: true,
+					},
+					ClaimCheck: ClaimCheck{
+						Name:        "reports-claims-test",
+						Threshold:   256,
+						Timeout:     time.Second * 5,
+						MaxDataSize: 4096,
+						PartSize:    1024,
+					},
+				},
+				State: State{
+					Name:            "reports-state-test",
+					Timeout:         time.Second * 5,
+					StatusRetention: time.Hour * 24,
+				},
+				Tracing: Tracing{
+					Exporter:    "otlp",
+					Endpoint:    "localhost:4317",
+					Insecure:    true,
+					SampleRatio: 0.5,
 				},
 			},
 		},
@@ -82,6 +339,78 @@ func TestNew(t *testing.T) {
 			want:    nil,
 			wantErr: errors.New("error"),
 		},
+		{
+			name: "With unknown retry code",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_RETRY_CODES": "UNAVAILABLE,UNKNOWN_CODE",
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With unknown fallback reporter type",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_FALLBACKS": `[{"type":"http","name":"reports"}]`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With invalid fallbacks",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_FALLBACKS": `{"type":"queue"}`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With unknown fan-out policy",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_FANOUT_POLICY": "some",
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With fan-out target without name",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_FANOUT_TARGETS": `[{"type":"pubsub"}]`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With route without name",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_ROUTES": `[{"target":{"type":"queue","name":"reports"}}]`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With default route name",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_ROUTES": `[{"name":"default","target":{"type":"queue","name":"reports"}}]`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With unknown route reporter type",
+			input: map[string]string{
+				"ENDPOINT_REPORTER_ROUTES": `[{"name":"urgent","target":{"type":"http","name":"reports"}}]`,
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
+		{
+			name: "With unknown security scheme",
+			input: map[string]string{
+				"ENDPOINT_SECURITY_SCHEMES": "apikey,basic",
+			},
+			want:    nil,
+			wantErr: errors.New("error"),
+		},
 	}
 
 	for _, test := range tests {
@@ -103,6 +432,438 @@ func TestNew(t *testing.T) {
 
 }
 
+func TestSetupKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`[{"name":"client","hash":"`+auth.HashKey("file-key")+`"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name  string
+		input Security
+		want  map[string]string
+	}{
+		{
+			name: "From environment",
+			input: Security{
+				Keys: map[string]struct{}{"key": {}},
+			},
+			want: map[string]string{"key": "key-" + auth.HashKey("key")[:8]},
+		},
+		{
+			name: "From file",
+			input: Security{
+				Keys:     map[string]struct{}{"key": {}},
+				KeysFile: path,
+			},
+			want: map[string]string{"file-key": "client", "key": ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys, err := SetupKeys(test.input, nil)
+			if err != nil {
+				t.Fatalf("SetupKeys() = unexpected error: %v\n", err)
+			}
+			defer keys.Close()
+
+			for key, want := range test.want {
+				got, _ := keys.Authenticate(key)
+				if got.Name != want {
+					t.Errorf("SetupKeys() = unexpected identity for key %q, want: %q, got: %q\n", key, want, got.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   JWT
+		wantErr bool
+	}{
+		{
+			name:  "Valid",
+			input: JWT{Issuer: "issuer", Audience: "audience", JWKS: path},
+		},
+		{
+			name:    "Missing JWKS",
+			input:   JWT{Issuer: "issuer", Audience: "audience"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing audience",
+			input:   JWT{Issuer: "issuer", JWKS: path},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := SetupTokens(test.input, nil)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("SetupTokens() = unexpected result, want error, got nil\n")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetupTokens() = unexpected error: %v\n", err)
+			}
+			tokens.Close()
+		})
+	}
+}
+
+func TestSetupSignatures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := os.WriteFile(path, []byte(`[{"name":"client","secret":"`+strings.Repeat("s", 32)+`"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			c     HMAC
+			store state.Store
+		}
+		wantErr bool
+	}{
+		{
+			name: "From file",
+			input: struct {
+				c     HMAC
+				store state.Store
+			}{
+				c:     HMAC{SecretsFile: path},
+				store: &state.DaprStore{},
+			},
+		},
+		{
+			name: "Without state store",
+			input: struct {
+				c     HMAC
+				store state.Store
+			}{
+				c: HMAC{SecretsFile: path},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Without secrets",
+			input: struct {
+				c     HMAC
+				store state.Store
+			}{
+				store: &state.DaprStore{},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := SetupSignatures(test.input.c, test.input.store)
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("SetupSignatures() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestSetupRateLimiter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			c     RateLimit
+			store state.Store
+		}
+		want    ratelimit.Limiter
+		wantErr bool
+	}{
+		{
+			name: "Memory",
+			input: struct {
+				c     RateLimit
+				store state.Store
+			}{
+				c: RateLimit{Backend: rateLimitBackendMemory},
+			},
+			want: &ratelimit.MemoryLimiter{},
+		},
+		{
+			name: "State",
+			input: struct {
+				c     RateLimit
+				store state.Store
+			}{
+				c:     RateLimit{Backend: rateLimitBackendState},
+				store: &state.DaprStore{},
+			},
+			want: &ratelimit.StoreLimiter{},
+		},
+		{
+			name: "State without store",
+			input: struct {
+				c     RateLimit
+				store state.Store
+			}{
+				c: RateLimit{Backend: rateLimitBackendState},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown backend",
+			input: struct {
+				c     RateLimit
+				store state.Store
+			}{
+				c: RateLimit{Backend: "redis"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := SetupRateLimiter(test.input.c, test.input.store)
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("SetupRateLimiter() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(test.want) {
+				t.Errorf("SetupRateLimiter() = unexpected type, want: %T, got: %T\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSetupUsage(t *testing.T) {
+	if _, err := SetupUsage(Usage{}, &state.DaprStore{}); err != nil {
+		t.Errorf("SetupUsage() = unexpected error: %v\n", err)
+	}
+	if _, err := SetupUsage(Usage{}, nil); err == nil {
+		t.Errorf("SetupUsage() = expected error without store\n")
+	}
+}
+
+func TestUsage_Quota(t *testing.T) {
+	got := Usage{DailyReports: 1, DailyBytes: 2, MonthlyReports: 3, MonthlyBytes: 4}.Quota()
+	want := usage.Quota{
+		Daily:   usage.Count{Reports: 1, Bytes: 2},
+		Monthly: usage.Count{Reports: 3, Bytes: 4},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Quota() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestConfiguration_Components(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Configuration
+		want  []health.Component
+	}{
+		{
+			name: "Queue",
+			input: Configuration{
+				Reporter: Reporter{Type: reporterTypeQueue, Name: "reports"},
+			},
+			want: []health.Component{
+				{Kind: health.KindBindings, Name: "reports"},
+			},
+		},
+		{
+			name: "Pubsub with claim checks and state",
+			input: Configuration{
+				Reporter: Reporter{Type: reporterTypePubsub, Name: "reports", ClaimCheck: ClaimCheck{Name: "reports-claims"}},
+				State:    State{Name: "reports-state"},
+			},
+			want: []health.Component{
+				{Kind: health.KindPubsub, Name: "reports"},
+				{Kind: health.KindBindings, Name: "reports-claims"},
+				{Kind: health.KindState, Name: "reports-state"},
+			},
+		},
+		{
+			name: "With fallbacks",
+			input: Configuration{
+				Reporter: Reporter{Type: reporterTypePubsub, Name: "reports", Fallbacks: []Target{
+					{Type: reporterTypeQueue, Name: "reports-queue"},
+					{Type: reporterTypePubsub, Name: "reports"},
+				}},
+			},
+			want: []health.Component{
+				{Kind: health.KindPubsub, Name: "reports"},
+				{Kind: health.KindBindings, Name: "reports-queue"},
+			},
+		},
+		{
+			name: "With fan-out",
+			input: Configuration{
+				Reporter: Reporter{Type: reporterTypeQueue, Name: "reports", FanOut: FanOut{Targets: []Target{
+					{Type: reporterTypePubsub, Name: "reports"},
+				}}},
+			},
+			want: []health.Component{
+				{Kind: health.KindBindings, Name: "reports"},
+				{Kind: health.KindPubsub, Name: "reports"},
+			},
+		},
+		{
+			name: "With routes",
+			input: Configuration{
+				Reporter: Reporter{Type: reporterTypeQueue, Name: "reports", Routes: []Route{
+					{Name: "urgent", Target: Target{Type: reporterTypePubsub, Name: "reports-urgent"}},
+				}},
+			},
+			want: []health.Component{
+				{Kind: health.KindBindings, Name: "reports"},
+				{Kind: health.KindPubsub, Name: "reports-urgent"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.Components()
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Components() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReporter_MaxDataSize(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Reporter
+		want  int
+	}{
+		{
+			name:  "Queue",
+			input: Reporter{Type: reporterTypeQueue, QueueMaxDataSize: 512, PubsubMaxDataSize: 1024},
+			want:  512,
+		},
+		{
+			name:  "Pubsub",
+			input: Reporter{Type: reporterTypePubsub, QueueMaxDataSize: 512, PubsubMaxDataSize: 1024},
+			want:  1024,
+		},
+		{
+			name:  "Pubsub with queue fallback",
+			input: Reporter{Type: reporterTypePubsub, QueueMaxDataSize: 512, PubsubMaxDataSize: 1024, Fallbacks: []Target{{Type: reporterTypeQueue}}},
+			want:  512,
+		},
+		{
+			name:  "Pubsub with queue route",
+			input: Reporter{Type: reporterTypePubsub, QueueMaxDataSize: 512, PubsubMaxDataSize: 1024, Routes: []Route{{Target: Target{Type: reporterTypeQueue}}}},
+			want:  512,
+		},
+		{
+			name:  "With claim check",
+			input: Reporter{Type: reporterTypeQueue, QueueMaxDataSize: 512, ClaimCheck: ClaimCheck{Name: "claims", MaxDataSize: 4096}},
+			want:  4096,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.input.MaxDataSize(); got != test.want {
+				t.Errorf("MaxDataSize() = unexpected result, want: %d, got: %d\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReporter_MaxDuration(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Reporter
+		want  time.Duration
+	}{
+		{
+			name:  "Reporter",
+			input: Reporter{Timeout: time.Second * 10, Fallbacks: []Target{{Type: reporterTypeQueue}}},
+			want:  time.Second * 10,
+		},
+		{
+			name:  "With claim check",
+			input: Reporter{Timeout: time.Second * 10, ClaimCheck: ClaimCheck{Name: "claims", Timeout: time.Second * 5}},
+			want:  time.Second * 15,
+		},
+		{
+			name:  "With sequential fan-out",
+			input: Reporter{Timeout: time.Second * 10, FanOut: FanOut{Sequential: true, Targets: []Target{{Type: reporterTypeQueue}, {Type: reporterTypePubsub}}}},
+			want:  time.Second * 30,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.input.MaxDuration(); got != test.want {
+				t.Errorf("MaxDuration() = unexpected result, want: %s, got: %s\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReporter_Topics(t *testing.T) {
+	c := Reporter{
+		Type:      reporterTypeQueue,
+		Name:      "reports",
+		Queue:     "create",
+		Topic:     "topic",
+		Fallbacks: []Target{{Type: reporterTypePubsub, Name: "reports", Topic: "create"}},
+		FanOut: FanOut{
+			Targets: []Target{{Type: reporterTypePubsub, Name: "analytics", Topic: "analytics"}},
+		},
+		Routes: []Route{
+			{Name: "alerts", Match: RouteMatch{Types: []string{"alert"}}, Target: Target{Type: reporterTypeQueue, Name: "reports", Queue: "alerts"}},
+		},
+	}
+
+	var tests = []struct {
+		name  string
+		input report.Report
+		want  []string
+	}{
+		{
+			name:  "Matching route",
+			input: report.Report{Type: "alert"},
+			want:  []string{"alerts"},
+		},
+		{
+			name:  "No matching route",
+			input: report.Report{Type: "other"},
+			want:  []string{"create", "analytics"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, c.Topics(test.input)); diff != "" {
+				t.Errorf("Topics() = unexpected result, (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTarget_String(t *testing.T) {
+	if got := (Target{Type: reporterTypePubsub, Name: "reports", Queue: "queue", Topic: "topic"}).String(); got != "pubsub/reports/topic" {
+		t.Errorf("String() = unexpected result, want: %q, got: %q\n", "pubsub/reports/topic", got)
+	}
+	if got := (Target{Type: reporterTypeQueue, Name: "reports", Queue: "queue", Topic: "topic"}).String(); got != "queue/reports/queue" {
+		t.Errorf("String() = unexpected result, want: %q, got: %q\n", "queue/reports/queue", got)
+	}
+}
+
 func setEnvVars(vars map[string]string) {
 	os.Clearenv()
 	for k, v := range vars {
@@ -115,3 +876,16 @@ func unsetEnvVars(vars map[string]string) {
 		os.Unsetenv(v)
 	}
 }
+
+func TestRouteMatch_Match(t *testing.T) {
+	input := RouteMatch{Types: []string{"alert"}, Tenants: []string{"client"}, MinPriority: toPtr(1), MaxPriority: toPtr(5), Labels: map[string]string{"region": "eu"}, MinSize: 1, MaxSize: 1024}
+	want := report.Match{Types: []string{"alert"}, Tenants: []string{"client"}, MinPriority: toPtr(1), MaxPriority: toPtr(5), Labels: map[string]string{"region": "eu"}, MinSize: 1, MaxSize: 1024}
+
+	if diff := cmp.Diff(want, input.Match()); diff != "" {
+		t.Errorf("Match() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewDaprChecker(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []DaprCheckerOption
+		want  *DaprChecker
+	}{
+		{
+			name:  "Empty",
+			input: nil,
+			want: &DaprChecker{
+				timeout: defaultCheckerTimeout,
+			},
+		},
+		{
+			name: "With options",
+			input: []DaprCheckerOption{
+				func(o *DaprCheckerOptions) {
+					o.Components = []Component{{Kind: KindBindings, Name: "reports"}}
+					o.Timeout = time.Second * 5
+				},
+			},
+			want: &DaprChecker{
+				components: []Component{{Kind: KindBindings, Name: "reports"}},
+				timeout:    time.Second * 5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newDaprChecker(test.input...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(DaprChecker{})); diff != "" {
+				t.Errorf("newDaprChecker() = unexpected, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDaprChecker_Check(t *testing.T) {
+	metadata := &dapr.GetMetadataResponse{
+		RegisteredComponents: []*dapr.MetadataRegisteredComponents{
+			{Name: "reports", Type: "bindings.azure.servicebusqueues"},
+			{Name: "reports-state", Type: "state.azure.tablestorage"},
+		},
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			client     *mockClient
+			components []Component
+		}
+		wantErr error
+	}{
+		{
+			name: "Registered components",
+			input: struct {
+				client     *mockClient
+				components []Component
+			}{
+				client:     &mockClient{metadata: metadata},
+				components: []Component{{Kind: KindBindings, Name: "reports"}, {Kind: KindState, Name: "reports-state"}},
+			},
+		},
+		{
+			name: "Component of other kind",
+			input: struct {
+				client     *mockClient
+				components []Component
+			}{
+				client:     &mockClient{metadata: metadata},
+				components: []Component{{Kind: KindPubsub, Name: "reports"}},
+			},
+			wantErr: ErrComponentNotFound,
+		},
+		{
+			name: "Missing component",
+			input: struct {
+				client     *mockClient
+				components []Component
+			}{
+				client:     &mockClient{metadata: metadata},
+				components: []Component{{Kind: KindBindings, Name: "reports-output"}},
+			},
+			wantErr: ErrComponentNotFound,
+		},
+		{
+			name: "Unreachable sidecar",
+			input: struct {
+				client     *mockClient
+				components []Component
+			}{
+				client: &mockClient{err: errors.New("error")},
+			},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &DaprChecker{
+				client:     test.input.client,
+				components: test.input.components,
+				timeout:    time.Second,
+			}
+
+			gotErr := c.Check(context.Background())
+			if (test.wantErr == nil) != (gotErr == nil) {
+				t.Fatalf("Check() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if errors.Is(test.wantErr, ErrComponentNotFound) && !errors.Is(gotErr, ErrComponentNotFound) {
+				t.Errorf("Check() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+type mockClient struct {
+	metadata *dapr.GetMetadataResponse
+	err      error
+}
+
+func (c *mockClient) GetMetadata(ctx context.Context) (*dapr.GetMetadataResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.metadata, nil
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/RedeployAB/container-apps-dapr/endpoint/config"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/metrics"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
 	"github.com/RedeployAB/container-apps-dapr/endpoint/server"
 )
 
@@ -18,27 +24,193 @@ func main() {
 		os.Exit(1)
 	}
 
-	reporter, err := config.SetupReporter(cfg.Reporter)
+	store, err := config.SetupState(cfg.State)
+	if err != nil {
+		log.Error("Error setting up state store.", "error", err)
+		os.Exit(1)
+	}
+
+	provider, err := config.SetupTracing(cfg.Tracing)
+	if err != nil {
+		log.Error("Error setting up tracing.", "error", err)
+		os.Exit(1)
+	}
+	var serverTracing server.Tracing
+	if provider != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+			if err := provider.Shutdown(ctx); err != nil {
+				log.Error("Error shutting down tracing.", "error", err)
+			}
+		}()
+		serverTracing = provider
+	}
+
+	var m *metrics.Metrics
+	var serverMetrics server.Metrics
+	if cfg.Server.Metrics.Enabled {
+		m = metrics.New()
+		serverMetrics = m
+	}
+
+	breaker := config.SetupCircuitBreaker(cfg.Reporter, m, func(from, to report.CircuitState) {
+		log.Info("Circuit breaker state changed.", "reporter", cfg.Reporter.Target().String(), "from", from, "to", to)
+	})
+	var serverCircuit server.CircuitBreaker
+	if breaker != nil {
+		serverCircuit = breaker
+	}
+
+	reporter, err := config.SetupReporter(cfg.Reporter, config.ReporterOptions{
+		Store:                 store,
+		StatusRetention:       cfg.State.StatusRetention,
+		Metrics:               m,
+		Tracing:               provider,
+		PrimaryCircuitBreaker: breaker,
+		OnAttempt: func(target string, a report.Attempt) {
+			if a.Err == nil {
+				log.Info("Report attempt succeeded.", "reporter", target, "ids", a.IDs, "attempt", a.Number)
+				return
+			}
+			log.Error("Report attempt failed.", "reporter", target, "ids", a.IDs, "attempt", a.Number, "retry", a.Retry, "backoff", a.Backoff, "error", a.Err)
+		},
+		OnDelivery: func(id string, d report.Delivery) {
+			if len(d.Error) == 0 {
+				log.Info("Report delivered.", "id", id, "reporter", d.Reporter)
+				return
+			}
+			log.Error("Report delivery failed.", "id", id, "reporter", d.Reporter, "error", d.Error)
+		},
+		OnRoute: func(re report.Report) {
+			log.Info("Report routed.", "id", re.ID, "route", re.Route, "type", re.Type, "tenant", re.Tenant, "priority", re.Priority, "request_id", re.RequestID)
+		},
+	})
 	if err != nil {
 		log.Error("Error setting up reporter.", "error", err)
 		os.Exit(1)
 	}
 
+	claimChecks, err := config.SetupClaimCheckStore(cfg.Reporter)
+	if err != nil {
+		log.Error("Error setting up claim check store.", "error", err)
+		os.Exit(1)
+	}
+	var uploads server.Uploads
+	if claimChecks != nil {
+		uploads = server.Uploads{Store: claimChecks, Threshold: cfg.Reporter.ClaimCheck.Threshold}
+	}
+
+	var security server.Security
+	if cfg.Server.Security.HasScheme(config.SchemeAPIKey) {
+		keys, err := config.SetupKeys(cfg.Server.Security, func(err error) {
+			log.Error("Error reloading API keys.", "error", err)
+		})
+		if err != nil {
+			log.Error("Error setting up API keys.", "error", err)
+			os.Exit(1)
+		}
+		defer keys.Close()
+		security.Keys = keys
+	}
+	if cfg.Server.Security.HasScheme(config.SchemeJWT) {
+		tokens, err := config.SetupTokens(cfg.Server.Security.JWT, func(err error) {
+			log.Error("Error refreshing JWKS.", "error", err)
+		})
+		if err != nil {
+			log.Error("Error setting up bearer tokens.", "error", err)
+			os.Exit(1)
+		}
+		defer tokens.Close()
+		security.Tokens = tokens
+	}
+	if cfg.Server.Security.HasScheme(config.SchemeHMAC) {
+		signatures, err := config.SetupSignatures(cfg.Server.Security.HMAC, store)
+		if err != nil {
+			log.Error("Error setting up request signatures.", "error", err)
+			os.Exit(1)
+		}
+		security.Signatures = signatures
+	}
+
+	limiter, err := config.SetupRateLimiter(cfg.Server.RateLimit, store)
+	if err != nil {
+		log.Error("Error setting up rate limiter.", "error", err)
+		os.Exit(1)
+	}
+
+	rateLimit := server.RateLimit{
+		Limiter:    limiter,
+		Limit:      cfg.Server.RateLimit.Limit(),
+		FailClosed: cfg.Server.RateLimit.FailClosed,
+	}
+	if m != nil {
+		rateLimit.OnError = m.RateLimitError
+	}
+
+	usage := server.Usage{Quota: cfg.Server.Usage.Quota()}
+	if store != nil {
+		tracker, err := config.SetupUsage(cfg.Server.Usage, store)
+		if err != nil {
+			log.Error("Error setting up usage tracking.", "error", err)
+			os.Exit(1)
+		}
+		usage.Tracker = tracker
+	}
+
+	checker, err := config.SetupHealth(cfg.Server.Health, cfg.Components())
+	if err != nil {
+		log.Error("Error setting up health checks.", "error", err)
+		os.Exit(1)
+	}
+
 	srv, err := server.New(http.NewServeMux(), server.Options{
-		Reporter:     reporter,
-		Logger:       log,
-		Host:         cfg.Server.Host,
-		Port:         cfg.Server.Port,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
-		Security: server.Security{
-			Keys: cfg.Server.Security.Keys,
+		Reporter:        reporter,
+		Logger:          log,
+		Host:            cfg.Server.Host,
+		Port:            cfg.Server.Port,
+		ReadTimeout:     cfg.Server.ReadTimeout,
+		WriteTimeout:    cfg.Server.WriteTimeout,
+		IdleTimeout:     cfg.Server.IdleTimeout,
+		MaxBatchSize:    cfg.Server.MaxBatchSize,
+		ShutdownDelay:   cfg.Server.ShutdownDelay,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		Health:          checker,
+		Circuit:         serverCircuit,
+		Metrics:         serverMetrics,
+		Tracing:         serverTracing,
+		Topics:          cfg.Reporter.Topics,
+		Security:        security,
+		Idempotency: server.Idempotency{
+			Store:  store,
+			Window: cfg.Server.Idempotency.Window,
+			// A key is held while its report can still be sent.
+			PendingTTL: cfg.Reporter.MaxDuration(),
+		},
+		RateLimit: rateLimit,
+		Usage:     usage,
+		AccessLog: server.AccessLog{
+			Enabled:       cfg.Server.AccessLog.Enabled,
+			RedactHeaders: cfg.Server.AccessLog.RedactHeaders,
+			RedactFields:  cfg.Server.AccessLog.RedactFields,
 		},
+		Validation: server.Validation{
+			MaxBodySize:      cfg.Server.Validation.MaxBodySize,
+			MaxBatchBodySize: cfg.Server.Validation.MaxBatchBodySize,
+			MaxDataSize:      cfg.Reporter.MaxDataSize(),
+		},
+		Uploads: uploads,
 	})
 	if err != nil {
 		log.Error("Error creating server.", "error", err)
+		os.Exit(1)
 	}
 
-	srv.Start()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		log.Error("Server error.", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Instrument wraps the handler of the provided route and records the
+// number, latency and body size of its requests. The route is the
+// pattern the handler is registered with, not the request path, to keep
+// the number of label values bounded.
+func (m Metrics) Instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+
+		next.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.status)
+		m.requests.WithLabelValues(route, r.Method, status).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+		if body.n > 0 {
+			m.requestSize.WithLabelValues(route).Observe(float64(body.n))
+		}
+	})
+}
+
+// statusRecorder is a http.ResponseWriter that records the status code
+// of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and writes it.
+func (w *statusRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data of the response.
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// countingReader is an io.ReadCloser that counts the bytes read.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read reads from the wrapped reader and counts the bytes read.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_Instrument(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			body    string
+			handler http.HandlerFunc
+		}
+		wantStatus string
+		wantSize   int
+	}{
+		{
+			name: "With status",
+			input: struct {
+				body    string
+				handler http.HandlerFunc
+			}{
+				body: `{"id":"123"}`,
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					io.ReadAll(r.Body)
+					w.WriteHeader(http.StatusAccepted)
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+			},
+			wantStatus: "202",
+			wantSize:   1,
+		},
+		{
+			name: "Without status",
+			input: struct {
+				body    string
+				handler http.HandlerFunc
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("ok"))
+				},
+			},
+			wantStatus: "200",
+			wantSize:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New()
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(test.input.body))
+			w := httptest.NewRecorder()
+
+			m.Instrument("/reports", test.input.handler).ServeHTTP(w, req)
+
+			if got := testutil.ToFloat64(m.requests.WithLabelValues("/reports", http.MethodPost, test.wantStatus)); got != 1 {
+				t.Errorf("Instrument() = unexpected request count, want: 1, got: %v\n", got)
+			}
+			if got := testutil.CollectAndCount(m.requestSize); got != test.wantSize {
+				t.Errorf("Instrument() = unexpected request sizes, want: %d, got: %d\n", test.wantSize, got)
+			}
+		})
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.requests.WithLabelValues("/reports", http.MethodPost, "202").Inc()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+
+	m.Handler().ServeHTTP(w, req)
+
+	body, _ := io.ReadAll(w.Result().Body)
+	if want := `endpoint_http_requests_total{method="POST",route="/reports",status="202"} 1`; !strings.Contains(string(body), want) {
+		t.Errorf("Handler() = unexpected result, want body to contain %s\n", want)
+	}
+}
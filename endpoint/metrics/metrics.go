@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "endpoint"
+)
+
+var (
+	// sizeBuckets are the buckets for sizes in bytes, from 256 B to 4 MiB.
+	sizeBuckets = prometheus.ExponentialBuckets(256, 4, 8)
+)
+
+// Metrics contains the Prometheus collectors of the endpoint and the
+// registry they are registered with.
+type Metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	requestSize      *prometheus.HistogramVec
+	reporterDuration *prometheus.HistogramVec
+	reporterErrors   *prometheus.CounterVec
+	reporterAttempts *prometheus.CounterVec
+	circuitState     *prometheus.GaugeVec
+	reportSize       *prometheus.HistogramVec
+	rateLimitErrors  prometheus.Counter
+}
+
+// New creates a new *Metrics with the collectors registered with a new
+// registry, together with the Go and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_size_bytes",
+			Help:      "Size of the bodies of HTTP requests by route.",
+			Buckets:   sizeBuckets,
+		}, []string{"route"}),
+		reporterDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "reporter_run_duration_seconds",
+			Help:      "Latency of reporter runs by reporter type, component and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type", "component", "operation"}),
+		reporterErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reporter_errors_total",
+			Help:      "Number of reports that failed to run by reporter type, component and reason.",
+		}, []string{"type", "component", "reason"}),
+		reporterAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reporter_attempts_total",
+			Help:      "Number of attempts to send reports by reporter type, component and outcome.",
+		}, []string{"type", "component", "outcome"}),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "reporter_circuit_state",
+			Help:      "State of the circuit breaker of the reporter by reporter type and component, 1 for the current state.",
+		}, []string{"type", "component", "state"}),
+		reportSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "report_data_size_bytes",
+			Help:      "Size of the data of reports by reporter type and component.",
+			Buckets:   sizeBuckets,
+		}, []string{"type", "component"}),
+		rateLimitErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_errors_total",
+			Help:      "Number of requests for which the rate limiter failed.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.requestSize,
+		m.reporterDuration,
+		m.reporterErrors,
+		m.reporterAttempts,
+		m.circuitState,
+		m.reportSize,
+		m.rateLimitErrors,
+	)
+	return m
+}
+
+// RateLimitError counts a request for which the rate limiter failed.
+func (m Metrics) RateLimitError(err error) {
+	m.rateLimitErrors.Inc()
+}
+
+// Handler returns a handler that serves the metrics in the Prometheus
+// exposition format.
+func (m Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
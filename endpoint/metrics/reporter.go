@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+// Operations of a reporter.
+const (
+	operationRun   = "run"
+	operationBatch = "batch"
+)
+
+// reporter is a report.Reporter that records the latency, errors and data
+// sizes of the wrapped reporter.
+type reporter struct {
+	r         report.Reporter
+	m         Metrics
+	typ       string
+	component string
+}
+
+// Reporter wraps the provided reporter and records its latency, errors
+// and data sizes with the reporter type and component name as labels.
+// Batches are run with the wrapped reporter if it is a
+// report.BatchReporter, otherwise the reports are run one by one.
+func (m Metrics) Reporter(r report.Reporter, typ, component string) report.Reporter {
+	return &reporter{r: r, m: m, typ: typ, component: component}
+}
+
+// Run the report with the wrapped reporter.
+func (r reporter) Run(ctx context.Context, re report.Report) error {
+	r.m.reportSize.WithLabelValues(r.typ, r.component).Observe(float64(len(re.Data)))
+
+	start := time.Now()
+	err := r.r.Run(ctx, re)
+	r.m.reporterDuration.WithLabelValues(r.typ, r.component, operationRun).Observe(time.Since(start).Seconds())
+	r.observeError(err)
+	return err
+}
+
+// RunBatch runs the reports with the wrapped reporter.
+func (r reporter) RunBatch(ctx context.Context, reports []report.Report) []error {
+	br, ok := r.r.(report.BatchReporter)
+	if !ok {
+		errs := make([]error, len(reports))
+		for i, re := range reports {
+			errs[i] = r.Run(ctx, re)
+		}
+		return errs
+	}
+
+	for _, re := range reports {
+		r.m.reportSize.WithLabelValues(r.typ, r.component).Observe(float64(len(re.Data)))
+	}
+	start := time.Now()
+	errs := br.RunBatch(ctx, reports)
+	r.m.reporterDuration.WithLabelValues(r.typ, r.component, operationBatch).Observe(time.Since(start).Seconds())
+	for _, err := range errs {
+		r.observeError(err)
+	}
+	return errs
+}
+
+// observeError counts the error, if any, by reason.
+func (r reporter) observeError(err error) {
+	if err == nil {
+		return
+	}
+	r.m.reporterErrors.WithLabelValues(r.typ, r.component, reason(err)).Inc()
+}
+
+// Attempt counts an attempt of a reporter with the reporter type and
+// component name as labels. The outcome is "success", "retry" or "error".
+func (m Metrics) Attempt(typ, component string, a report.Attempt) {
+	outcome := "success"
+	if a.Err != nil {
+		outcome = "error"
+		if a.Retry {
+			outcome = "retry"
+		}
+	}
+	m.reporterAttempts.WithLabelValues(typ, component, outcome).Inc()
+}
+
+// CircuitState sets the state of the circuit breaker of a reporter with
+// the reporter type and component name as labels.
+func (m Metrics) CircuitState(typ, component string, state report.CircuitState) {
+	for _, s := range []report.CircuitState{report.CircuitClosed, report.CircuitOpen, report.CircuitHalfOpen} {
+		var v float64
+		if s == state {
+			v = 1
+		}
+		m.circuitState.WithLabelValues(typ, component, string(s)).Set(v)
+	}
+}
+
+// reason returns the reason of an error from a reporter.
+func reason(err error) string {
+	switch {
+	case errors.Is(err, report.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, report.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, report.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "error"
+	}
+}
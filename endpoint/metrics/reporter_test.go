@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_Reporter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			reporter report.Reporter
+			reports  []report.Report
+		}
+		wantOperation string
+		wantErrors    map[string]float64
+	}{
+		{
+			name: "Reporter",
+			input: struct {
+				reporter report.Reporter
+				reports  []report.Report
+			}{
+				reporter: &mockReporter{errs: map[string]error{"456": fmt.Errorf("%w: error", report.ErrTimeout)}},
+				reports:  []report.Report{report.NewReport("123", []byte("data")), report.NewReport("456", []byte("data"))},
+			},
+			wantOperation: operationRun,
+			wantErrors:    map[string]float64{"timeout": 1, "unavailable": 0, "error": 0},
+		},
+		{
+			name: "Batch reporter",
+			input: struct {
+				reporter report.Reporter
+				reports  []report.Report
+			}{
+				reporter: &mockBatchReporter{mockReporter{errs: map[string]error{
+					"123": errors.New("error"),
+					"456": fmt.Errorf("%w: error", report.ErrUnavailable),
+				}}},
+				reports: []report.Report{report.NewReport("123", []byte("data")), report.NewReport("456", []byte("data"))},
+			},
+			wantOperation: operationBatch,
+			wantErrors:    map[string]float64{"timeout": 0, "unavailable": 1, "error": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New()
+			r := m.Reporter(test.input.reporter, "queue", "reports")
+
+			errs := r.(report.BatchReporter).RunBatch(context.Background(), test.input.reports)
+			if len(errs) != len(test.input.reports) {
+				t.Fatalf("RunBatch() = unexpected result, want %d errors, got: %d\n", len(test.input.reports), len(errs))
+			}
+
+			got := map[string]float64{}
+			for reason := range test.wantErrors {
+				got[reason] = testutil.ToFloat64(m.reporterErrors.WithLabelValues("queue", "reports", reason))
+			}
+			if diff := cmp.Diff(test.wantErrors, got); diff != "" {
+				t.Errorf("RunBatch() = unexpected errors, (-want +got):\n%s\n", diff)
+			}
+			if got := testutil.CollectAndCount(m.reporterDuration); got != 1 {
+				t.Errorf("RunBatch() = unexpected durations, want: 1, got: %d\n", got)
+			}
+			if got := testutil.CollectAndCount(m.reporterDuration.MustCurryWith(map[string]string{"operation": test.wantOperation})); got != 1 {
+				t.Errorf("RunBatch() = unexpected operation, want: %s\n", test.wantOperation)
+			}
+		})
+	}
+}
+
+func TestMetrics_Attempt(t *testing.T) {
+	m := New()
+	attempts := []report.Attempt{
+		{IDs: []string{"123"}, Number: 1, Err: errors.New("error"), Retry: true},
+		{IDs: []string{"123"}, Number: 2},
+		{IDs: []string{"456"}, Number: 1, Err: errors.New("error")},
+		{IDs: []string{"789"}, Number: 1},
+	}
+	for _, a := range attempts {
+		m.Attempt("queue", "reports", a)
+	}
+
+	want := map[string]float64{"success": 2, "retry": 1, "error": 1}
+	got := map[string]float64{}
+	for outcome := range want {
+		got[outcome] = testutil.ToFloat64(m.reporterAttempts.WithLabelValues("queue", "reports", outcome))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Attempt() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestMetrics_CircuitState(t *testing.T) {
+	m := New()
+	m.CircuitState("queue", "reports", report.CircuitClosed)
+	m.CircuitState("queue", "reports", report.CircuitOpen)
+
+	want := map[string]float64{"closed": 0, "open": 1, "half-open": 0}
+	got := map[string]float64{}
+	for state := range want {
+		got[state] = testutil.ToFloat64(m.circuitState.WithLabelValues("queue", "reports", state))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CircuitState() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+}
+
+type mockReporter struct {
+	errs map[string]error
+}
+
+func (r mockReporter) Run(ctx context.Context, re report.Report) error {
+	return r.errs[re.ID]
+}
+
+type mockBatchReporter struct {
+	mockReporter
+}
+
+func (r mockBatchReporter) RunBatch(ctx context.Context, reports []report.Report) []error {
+	errs := make([]error, len(reports))
+	for i, re := range reports {
+		errs[i] = r.errs[re.ID]
+	}
+	return errs
+}
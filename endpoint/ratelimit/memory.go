@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// sweepInterval is the minimum time between removals of full buckets.
+	sweepInterval = time.Minute
+)
+
+// MemoryLimiter is a Limiter that keeps buckets in memory. Limits only hold
+// for a single replica.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]entry
+	swept   time.Time
+	now     func() time.Time
+}
+
+// entry is a bucket and the time it is full again.
+type entry struct {
+	bucket
+	full time.Time
+}
+
+// NewMemoryLimiter creates a new *MemoryLimiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the provided key.
+func (l *MemoryLimiter) Allow(key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, result := take(l.buckets[key].bucket, limit, now)
+	l.buckets[key] = entry{bucket: b, full: now.Add(result.Reset)}
+	l.sweep(now)
+	return result, nil
+}
+
+// sweep removes buckets that are full again, since they are the same as
+// new buckets, at most once every sweepInterval.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+	for key, e := range l.buckets {
+		if now.After(e.full) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		got, err := l.Allow("client-a", limit)
+		if err != nil {
+			t.Fatalf("Allow() = unexpected error: %v\n", err)
+		}
+		if got.Allowed != want {
+			t.Errorf("Allow() = unexpected result for request %d, want: %t, got: %t\n", i, want, got.Allowed)
+		}
+	}
+
+	if got, _ := l.Allow("client-b", limit); !got.Allowed {
+		t.Errorf("Allow() = unexpected result for other key, want: true, got: false\n")
+	}
+
+	now = now.Add(time.Minute * 2)
+	l.Allow("client-a", limit)
+	if _, ok := l.buckets["client-b"]; ok {
+		t.Errorf("Allow() = unexpected result, want full bucket to be removed\n")
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is the limit of a token bucket. Rate is the number of tokens added
+// per second and Burst is the size of the bucket, that defaults to the rate.
+// A limit with a rate of 0 is no limit.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// IsZero returns true if the limit is no limit.
+func (l Limit) IsZero() bool {
+	return l.Rate <= 0
+}
+
+// burst returns the size of the bucket, at least 1.
+func (l Limit) burst() int {
+	if l.Burst < 1 {
+		return max(1, int(math.Ceil(l.Rate)))
+	}
+	return l.Burst
+}
+
+// refill returns the time it takes to fill an empty bucket.
+func (l Limit) refill() time.Duration {
+	return seconds(float64(l.burst()) / l.Rate)
+}
+
+// Result is the result of taking a token from a bucket.
+type Result struct {
+	// Allowed is true if a token was taken.
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit int
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// Reset is the time until the bucket is full.
+	Reset time.Duration
+	// RetryAfter is the time until a token is available, if not allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter is the interface that wraps around method Allow.
+type Limiter interface {
+	Allow(key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of a token bucket.
+type bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// take refills the bucket up to now and takes a token if one is available.
+// A bucket with a zero time is full.
+func take(b bucket, limit Limit, now time.Time) (bucket, Result) {
+	burst := float64(limit.burst())
+	if b.Updated.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*limit.Rate)
+	}
+	b.Updated = now
+
+	result := Result{Limit: limit.burst()}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((burst - b.Tokens) / limit.Rate)
+	return b, result
+}
+
+// seconds returns the provided number of seconds as a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
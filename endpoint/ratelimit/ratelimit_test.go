@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 4}
+
+	var tests = []struct {
+		name       string
+		input      bucket
+		wantBucket bucket
+		want       Result
+	}{
+		{
+			name:       "New bucket",
+			input:      bucket{},
+			wantBucket: bucket{Tokens: 3, Updated: now},
+			want:       Result{Allowed: true, Limit: 4, Remaining: 3, Reset: time.Millisecond * 500},
+		},
+		{
+			name:       "Empty bucket",
+			input:      bucket{Tokens: 0, Updated: now},
+			wantBucket: bucket{Tokens: 0, Updated: now},
+			want:       Result{Allowed: false, Limit: 4, Remaining: 0, Reset: time.Second * 2, RetryAfter: time.Millisecond * 500},
+		},
+		{
+			name:       "Refilled bucket",
+			input:      bucket{Tokens: 0, Updated: now.Add(-time.Second)},
+			wantBucket: bucket{Tokens: 1, Updated: now},
+			want:       Result{Allowed: true, Limit: 4, Remaining: 1, Reset: time.Millisecond * 1500},
+		},
+		{
+			name:       "Refill up to burst",
+			input:      bucket{Tokens: 0, Updated: now.Add(-time.Hour)},
+			wantBucket: bucket{Tokens: 3, Updated: now},
+			want:       Result{Allowed: true, Limit: 4, Remaining: 3, Reset: time.Millisecond * 500},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotBucket, got := take(test.input, limit, now)
+
+			if diff := cmp.Diff(test.wantBucket, gotBucket); diff != "" {
+				t.Errorf("take() = unexpected bucket, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("take() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+const (
+	keyPrefix = "ratelimit:"
+)
+
+// StoreLimiter is a Limiter that keeps buckets in a state store, so that
+// limits hold across replicas. Buckets are updated with optimistic
+// concurrency, so that concurrent requests on different replicas cannot
+// take the same token.
+type StoreLimiter struct {
+	store state.Store
+	now   func() time.Time
+}
+
+// NewStoreLimiter creates a new *StoreLimiter with the provided store.
+func NewStoreLimiter(store state.Store) (*StoreLimiter, error) {
+	if store == nil {
+		return nil, errors.New("store is nil")
+	}
+	return &StoreLimiter{
+		store: store,
+		now:   time.Now,
+	}, nil
+}
+
+// Allow takes a token from the bucket of the provided key. The bucket
+// expires from the store when it is full again. The bucket is kept for at
+// least a second since the store may not support shorter times to live,
+// and since the time to live is set before the bucket is updated, it is
+// kept for the time it takes to refill the burst. A bucket that could not
+// be updated because of concurrent requests is under contention, and the
+// request is denied until a token is added, instead of returning an
+// error, so that contention is not treated as the store being unavailable.
+func (l StoreLimiter) Allow(key string, limit Limit) (Result, error) {
+	var result Result
+	ttl := max(limit.refill(), time.Second)
+	if err := l.store.Update(keyPrefix+key, ttl, func(value []byte) ([]byte, error) {
+		var b bucket
+		if value != nil {
+			if err := json.Unmarshal(value, &b); err != nil {
+				return nil, err
+			}
+		}
+		b, result = take(b, limit, l.now())
+		return json.Marshal(b)
+	}); err != nil {
+		if errors.Is(err, state.ErrConflict) {
+			retryAfter := seconds(1 / limit.Rate)
+			return Result{Limit: limit.burst(), Reset: retryAfter, RetryAfter: retryAfter}, nil
+		}
+		return Result{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+func TestStoreLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &mockStore{}
+	l, err := NewStoreLimiter(store)
+	if err != nil {
+		t.Fatalf("NewStoreLimiter() = unexpected error: %v\n", err)
+	}
+	l.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		got, err := l.Allow("client", limit)
+		if err != nil {
+			t.Fatalf("Allow() = unexpected error: %v\n", err)
+		}
+		if got.Allowed != want {
+			t.Errorf("Allow() = unexpected result for request %d, want: %t, got: %t\n", i, want, got.Allowed)
+		}
+	}
+	if _, ok := store.data[keyPrefix+"client"]; !ok {
+		t.Errorf("Allow() = unexpected result, want bucket in store\n")
+	}
+	if store.ttl != time.Second*2 {
+		t.Errorf("Allow() = unexpected ttl, want: %v, got: %v\n", time.Second*2, store.ttl)
+	}
+
+	store.err = state.ErrConflict
+	got, err := l.Allow("client", limit)
+	if err != nil {
+		t.Fatalf("Allow() = unexpected error: %v\n", err)
+	}
+	if want := (Result{Limit: 2, Reset: time.Second, RetryAfter: time.Second}); got != want {
+		t.Errorf("Allow() = unexpected result on conflict, want: %+v, got: %+v\n", want, got)
+	}
+
+	store.err = errors.New("error")
+	if _, err := l.Allow("client", limit); err == nil {
+		t.Errorf("Allow() = unexpected result, want error, got nil\n")
+	}
+}
+
+func TestStoreLimiter_Allow_Concurrent(t *testing.T) {
+	l, _ := NewStoreLimiter(&mockStore{})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 5}
+
+	n := 20
+	allowed := make(chan bool, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := l.Allow("client", limit)
+			if err != nil {
+				t.Errorf("Allow() = unexpected error: %v\n", err)
+			}
+			allowed <- result.Allowed
+		}()
+	}
+	wg.Wait()
+	close(allowed)
+
+	var got int
+	for ok := range allowed {
+		if ok {
+			got++
+		}
+	}
+	if got != limit.Burst {
+		t.Errorf("Allow() = unexpected number of allowed requests, want: %d, got: %d\n", limit.Burst, got)
+	}
+}
+
+type mockStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttl  time.Duration
+	err  error
+}
+
+func (s *mockStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	b, ok := s.data[key]
+	if !ok {
+		return nil, state.ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *mockStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Create(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok && s.err == nil {
+		return state.ErrExists
+	}
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	value, err := fn(s.data[key])
+	if err != nil {
+		return err
+	}
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mockStore) set(key string, value []byte, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = value
+	s.ttl = ttl
+	return nil
+}
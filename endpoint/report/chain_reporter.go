@@ -0,0 +1,142 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ChainReporter is a reporter that runs reports with the first of an
+// ordered chain of reporters, and falls back to the next reporter in the
+// chain when a reporter fails. The name of the reporter that accepted a
+// report is recorded (see WithReceipts).
+type ChainReporter struct {
+	reporters []NamedReporter
+	timeout   time.Duration
+}
+
+// ChainReporterOptions contains settings for a ChainReporter.
+type ChainReporterOptions struct {
+	// Timeout is the time a report (or batch) may take with all the
+	// reporters of the chain. Every reporter gets the time that remains
+	// when it is run. Disabled if 0.
+	Timeout time.Duration
+}
+
+// ChainReporterOption is a function that sets *ChainReporterOptions.
+type ChainReporterOption func(o *ChainReporterOptions)
+
+// NewChainReporter creates a new *ChainReporter with the provided
+// reporters, in order, and options.
+func NewChainReporter(reporters []NamedReporter, options ...ChainReporterOption) (*ChainReporter, error) {
+	if len(reporters) == 0 {
+		return nil, errors.New("no reporters")
+	}
+	for _, r := range reporters {
+		if r.Reporter == nil {
+			return nil, errors.New("reporter " + r.Name + " is nil")
+		}
+	}
+	opts := ChainReporterOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &ChainReporter{reporters: reporters, timeout: opts.Timeout}, nil
+}
+
+// withTimeout returns a context with the timeout of the chain, if set.
+func (r ChainReporter) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+// Run a report routine with the reporters of the chain until one of them
+// succeeds. The chain stops if ctx is done. Returns the error of the last
+// reporter that was run if none succeed, joined with the first error that
+// does not tell if the report was sent (see NotSent), if any.
+func (r ChainReporter) Run(ctx context.Context, report Report) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var err, unsure error
+	for _, nr := range r.reporters {
+		if err = nr.Reporter.Run(ctx, report); err == nil {
+			recordAccepted(ctx, report.ID, nr.Name)
+			return nil
+		}
+		if unsure == nil && !NotSent(err) {
+			unsure = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return joinUnsure(err, unsure)
+}
+
+// RunBatch runs a report routine for every report. The reports are run
+// with the first reporter of the chain, and the reports that fail are run
+// with the next reporter, and so on. Reporters that are BatchReporters
+// run their reports as a batch. Returns one error per report, in the same
+// order as the reports. Errors are returned as with Run.
+func (r ChainReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	errs := make([]error, len(reports))
+	unsure := make([]error, len(reports))
+	pending := make([]int, len(reports))
+	for i := range reports {
+		pending[i] = i
+	}
+
+	for _, nr := range r.reporters {
+		batch := make([]Report, len(pending))
+		for j, i := range pending {
+			batch[j] = reports[i]
+		}
+
+		var batchErrs []error
+		if br, ok := nr.Reporter.(BatchReporter); ok {
+			batchErrs = br.RunBatch(ctx, batch)
+		} else {
+			batchErrs = make([]error, len(batch))
+			for j, report := range batch {
+				batchErrs[j] = nr.Reporter.Run(ctx, report)
+			}
+		}
+
+		failed := pending[:0]
+		for j, i := range pending {
+			errs[i] = batchErrs[j]
+			if errs[i] != nil {
+				if unsure[i] == nil && !NotSent(errs[i]) {
+					unsure[i] = errs[i]
+				}
+				failed = append(failed, i)
+				continue
+			}
+			recordAccepted(ctx, reports[i].ID, nr.Name)
+		}
+		pending = failed
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+	}
+	for i := range errs {
+		errs[i] = joinUnsure(errs[i], unsure[i])
+	}
+	return errs
+}
+
+// joinUnsure joins err with unsure, an earlier error of the chain that does
+// not tell if the report was sent, so that the report is not treated as
+// not sent by callers.
+func joinUnsure(err, unsure error) error {
+	if err == nil || unsure == nil || err == unsure {
+		return err
+	}
+	return errors.Join(err, unsure)
+}
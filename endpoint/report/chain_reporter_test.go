@@ -0,0 +1,172 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewChainReporter(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []NamedReporter
+		wantErr bool
+	}{
+		{
+			name:  "With reporters",
+			input: []NamedReporter{{Name: "primary", Reporter: mockReporter{}}, {Name: "fallback", Reporter: mockReporter{}}},
+		},
+		{
+			name:    "Without reporters",
+			input:   nil,
+			wantErr: true,
+		},
+		{
+			name:    "With nil reporter",
+			input:   []NamedReporter{{Name: "primary"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := NewChainReporter(test.input)
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("NewChainReporter() = unexpected error, want error: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestChainReporter_Run(t *testing.T) {
+	var tests = []struct {
+		name         string
+		input        []NamedReporter
+		wantAccepted string
+		wantErr      error
+	}{
+		{
+			name: "Primary",
+			input: []NamedReporter{
+				{Name: "primary", Reporter: mockReporter{}},
+				{Name: "fallback", Reporter: mockReporter{}},
+			},
+			wantAccepted: "primary",
+		},
+		{
+			name: "Fallback",
+			input: []NamedReporter{
+				{Name: "primary", Reporter: mockReporter{err: ErrUnavailable}},
+				{Name: "fallback", Reporter: mockReporter{}},
+			},
+			wantAccepted: "fallback",
+		},
+		{
+			name: "All fail",
+			input: []NamedReporter{
+				{Name: "primary", Reporter: mockReporter{err: ErrUnavailable}},
+				{Name: "fallback", Reporter: mockReporter{err: ErrTimeout}},
+			},
+			wantErr: ErrTimeout,
+		},
+		{
+			name: "All fail after timeout",
+			input: []NamedReporter{
+				{Name: "primary", Reporter: mockReporter{err: ErrTimeout}},
+				{Name: "fallback", Reporter: mockReporter{err: ErrUnavailable}},
+			},
+			wantErr: ErrTimeout,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, _ := NewChainReporter(test.input)
+			ctx := WithReceipts(context.Background())
+
+			gotErr := r.Run(ctx, Report{ID: "123"})
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Run() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if got := AcceptedBy(ctx, "123"); got != test.wantAccepted {
+				t.Errorf("Run() = unexpected accepted by, want: %s, got: %s\n", test.wantAccepted, got)
+			}
+		})
+	}
+}
+
+func TestChainReporter_Timeout(t *testing.T) {
+	fallback := &mockRecordingReporter{}
+	r, _ := NewChainReporter([]NamedReporter{
+		{Name: "primary", Reporter: mockBlockingReporter{}},
+		{Name: "fallback", Reporter: fallback},
+	}, func(o *ChainReporterOptions) {
+		o.Timeout = time.Millisecond * 10
+	})
+
+	gotErr := r.Run(context.Background(), Report{ID: "123"})
+
+	if !errors.Is(gotErr, ErrTimeout) {
+		t.Errorf("Run() = unexpected error, want: %v, got: %v\n", ErrTimeout, gotErr)
+	}
+	if len(fallback.reports) != 0 {
+		t.Errorf("Run() = unexpected reports with fallback, want: 0, got: %d\n", len(fallback.reports))
+	}
+}
+
+func TestChainReporter_RunBatch(t *testing.T) {
+	last := &mockRecordingReporter{}
+	r, _ := NewChainReporter([]NamedReporter{
+		{Name: "primary", Reporter: mockBatchReporter{errs: map[string]error{"456": ErrUnavailable, "789": ErrUnavailable}}},
+		{Name: "secondary", Reporter: mockBatchReporter{errs: map[string]error{"789": ErrTimeout}}},
+		{Name: "last", Reporter: last},
+	})
+	ctx := WithReceipts(context.Background())
+	reports := []Report{{ID: "123"}, {ID: "456"}, {ID: "789"}}
+
+	errs := r.RunBatch(ctx, reports)
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("RunBatch() = unexpected error for report %d: %v\n", i, err)
+		}
+	}
+
+	got := map[string]string{}
+	for _, re := range reports {
+		got[re.ID] = AcceptedBy(ctx, re.ID)
+	}
+	want := map[string]string{"123": "primary", "456": "secondary", "789": "last"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RunBatch() = unexpected accepted by, (-want +got):\n%s\n", diff)
+	}
+	if len(last.reports) != 1 || last.reports[0].ID != "789" {
+		t.Errorf("RunBatch() = unexpected reports for last reporter, got: %v\n", last.reports)
+	}
+}
+
+func TestAcceptedBy(t *testing.T) {
+	ctx := context.Background()
+	recordAccepted(ctx, "123", "primary")
+	if got := AcceptedBy(ctx, "123"); got != "" {
+		t.Errorf("AcceptedBy() = unexpected result without receipts, got: %s\n", got)
+	}
+
+	ctx = WithReceipts(ctx)
+	recordAccepted(ctx, "123", "inner")
+	recordAccepted(WithReceipts(ctx), "123", "outer")
+	if got := AcceptedBy(ctx, "123"); got != "inner" {
+		t.Errorf("AcceptedBy() = unexpected result, want: inner, got: %s\n", got)
+	}
+}
+
+type mockBlockingReporter struct{}
+
+func (r mockBlockingReporter) Run(ctx context.Context, report Report) error {
+	<-ctx.Done()
+	return ErrTimeout
+}
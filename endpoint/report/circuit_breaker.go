@@ -0,0 +1,290 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitWindowSize       = 20
+	defaultCircuitMinRequests      = 10
+	defaultCircuitFailureRate      = 0.5
+	defaultCircuitCoolDown         = time.Second * 30
+	defaultCircuitHalfOpenRequests = 1
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState string
+
+// States of a circuit breaker.
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+var (
+	// ErrCircuitOpen is returned when a circuit breaker is open and
+	// rejects reports without running them.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
+
+// CircuitOpenError is returned by a reporter wrapped with a circuit
+// breaker while the breaker is open. RetryAfter is the time until the
+// breaker lets reports through again, or 0 if unknown. The error is
+// both ErrCircuitOpen and ErrUnavailable.
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+// Error returns the error message.
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+// Is returns true if target is ErrCircuitOpen or ErrUnavailable.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen || target == ErrUnavailable
+}
+
+// CircuitBreaker stops running reports with the reporters it wraps when
+// too many of them fail. The breaker opens when the rate of failures
+// among the last runs reaches the failure rate, and rejects reports until
+// the cool-down has passed. It then lets a number of trial runs through
+// (half-open), and closes if they all succeed or opens again if one
+// fails. Only unavailable and timeout errors count as failures.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            CircuitState
+	generation       uint64
+	results          []bool
+	next             int
+	count            int
+	failures         int
+	openedAt         time.Time
+	trials           int
+	successes        int
+	minRequests      int
+	failureRate      float64
+	coolDown         time.Duration
+	halfOpenRequests int
+	onStateChange    func(from, to CircuitState)
+	now              func() time.Time
+}
+
+// CircuitBreakerOptions contains settings for a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// WindowSize is the number of most recent runs the failure rate is
+	// calculated from.
+	WindowSize int
+	// MinRequests is the minimum number of runs in the window before the
+	// breaker can open.
+	MinRequests int
+	// FailureRate is the rate (0 to 1) of failed runs that opens the
+	// breaker.
+	FailureRate float64
+	// CoolDown is the time the breaker stays open.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of trial runs that must succeed
+	// while half-open for the breaker to close.
+	HalfOpenRequests int
+	// OnStateChange is called with the breaker locked when the state
+	// changes. It must not call the breaker. Optional.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreakerOption is a function that sets *CircuitBreakerOptions.
+type CircuitBreakerOption func(o *CircuitBreakerOptions)
+
+// NewCircuitBreaker creates a new closed *CircuitBreaker with the
+// provided options.
+func NewCircuitBreaker(options ...CircuitBreakerOption) *CircuitBreaker {
+	opts := CircuitBreakerOptions{
+		WindowSize:       defaultCircuitWindowSize,
+		MinRequests:      defaultCircuitMinRequests,
+		FailureRate:      defaultCircuitFailureRate,
+		CoolDown:         defaultCircuitCoolDown,
+		HalfOpenRequests: defaultCircuitHalfOpenRequests,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.WindowSize < 1 {
+		opts.WindowSize = defaultCircuitWindowSize
+	}
+	if opts.HalfOpenRequests < 1 {
+		opts.HalfOpenRequests = defaultCircuitHalfOpenRequests
+	}
+
+	return &CircuitBreaker{
+		state:            CircuitClosed,
+		results:          make([]bool, opts.WindowSize),
+		minRequests:      opts.MinRequests,
+		failureRate:      opts.FailureRate,
+		coolDown:         opts.CoolDown,
+		halfOpenRequests: opts.HalfOpenRequests,
+		onStateChange:    opts.OnStateChange,
+		now:              time.Now,
+	}
+}
+
+// State returns the state of the breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.coolOff()
+	return b.state
+}
+
+// Reporter wraps the provided reporter with the breaker. Batches are run
+// with the wrapped reporter if it is a BatchReporter, and count as one
+// run that fails if any of the reports fail.
+func (b *CircuitBreaker) Reporter(r Reporter) Reporter {
+	return &circuitBreakerReporter{r: r, b: b}
+}
+
+// allow returns the generation of the breaker if a run is allowed, or a
+// *CircuitOpenError if not.
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.coolOff()
+
+	switch b.state {
+	case CircuitOpen:
+		return 0, &CircuitOpenError{RetryAfter: b.coolDown - b.now().Sub(b.openedAt)}
+	case CircuitHalfOpen:
+		if b.trials >= b.halfOpenRequests {
+			return 0, &CircuitOpenError{}
+		}
+		b.trials++
+	}
+	return b.generation, nil
+}
+
+// done records the result of a run allowed in the provided generation.
+// Results from earlier generations are ignored.
+func (b *CircuitBreaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case CircuitClosed:
+		if b.count == len(b.results) && b.results[b.next] {
+			b.failures--
+		}
+		b.results[b.next] = failed
+		b.next = (b.next + 1) % len(b.results)
+		b.count = min(b.count+1, len(b.results))
+		if failed {
+			b.failures++
+		}
+		if b.count >= b.minRequests && float64(b.failures)/float64(b.count) >= b.failureRate {
+			b.transition(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			b.transition(CircuitOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.halfOpenRequests {
+			b.transition(CircuitClosed)
+		}
+	}
+}
+
+// cancel releases a run allowed in the provided generation without a
+// result, such as when the caller gave up on it.
+func (b *CircuitBreaker) cancel(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation == b.generation && b.state == CircuitHalfOpen {
+		b.trials--
+	}
+}
+
+// coolOff moves the breaker from open to half-open when the cool-down has
+// passed.
+func (b *CircuitBreaker) coolOff() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.coolDown {
+		b.transition(CircuitHalfOpen)
+	}
+}
+
+// transition moves the breaker to the provided state and resets the
+// results.
+func (b *CircuitBreaker) transition(to CircuitState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	clear(b.results)
+	b.next, b.count, b.failures = 0, 0, 0
+	b.trials, b.successes = 0, 0
+	if to == CircuitOpen {
+		b.openedAt = b.now()
+	}
+	if b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
+
+// circuitBreakerReporter is a Reporter that runs reports with the wrapped
+// reporter through a circuit breaker.
+type circuitBreakerReporter struct {
+	r Reporter
+	b *CircuitBreaker
+}
+
+// Run the report with the wrapped reporter if the breaker allows it.
+func (r circuitBreakerReporter) Run(ctx context.Context, report Report) error {
+	generation, err := r.b.allow()
+	if err != nil {
+		return err
+	}
+	err = r.r.Run(ctx, report)
+	r.record(ctx, generation, err)
+	return err
+}
+
+// RunBatch runs the reports with the wrapped reporter if the breaker
+// allows it.
+func (r circuitBreakerReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	br, ok := r.r.(BatchReporter)
+	if !ok {
+		errs := make([]error, len(reports))
+		for i, report := range reports {
+			errs[i] = r.Run(ctx, report)
+		}
+		return errs
+	}
+
+	errs := make([]error, len(reports))
+	generation, err := r.b.allow()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	errs = br.RunBatch(ctx, reports)
+	r.record(ctx, generation, errors.Join(errs...))
+	return errs
+}
+
+// record records the result of a run with the breaker. A run whose
+// context was cancelled by the caller is not counted, since the failure
+// does not tell anything about the wrapped reporter. A run that timed out
+// counts as a failure, also when the deadline was set by the caller.
+func (r circuitBreakerReporter) record(ctx context.Context, generation uint64, err error) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		r.b.cancel(generation)
+		return
+	}
+	r.b.done(generation, errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded))
+}
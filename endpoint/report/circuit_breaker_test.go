@@ -0,0 +1,202 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errOther := errors.New("error")
+
+	type step struct {
+		advance   time.Duration
+		err       error
+		wantErr   error
+		wantState CircuitState
+	}
+
+	var tests = []struct {
+		name  string
+		input []step
+		want  []CircuitState
+	}{
+		{
+			name: "Stays closed below minimum requests",
+			input: []step{
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			},
+			want: nil,
+		},
+		{
+			name: "Stays closed below failure rate",
+			input: []step{
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{wantState: CircuitClosed},
+				{wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			},
+			want: nil,
+		},
+		{
+			name: "Other errors are not failures",
+			input: []step{
+				{err: errOther, wantErr: errOther, wantState: CircuitClosed},
+				{err: errOther, wantErr: errOther, wantState: CircuitClosed},
+				{err: errOther, wantErr: errOther, wantState: CircuitClosed},
+			},
+			want: nil,
+		},
+		{
+			name: "Opens and fails fast",
+			input: []step{
+				{wantState: CircuitClosed},
+				{err: ErrTimeout, wantErr: ErrTimeout, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+				{wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+				{advance: time.Second * 5, wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+			},
+			want: []CircuitState{CircuitOpen},
+		},
+		{
+			name: "Closes after cool-down and successful trial",
+			input: []step{
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+				{advance: time.Second * 10, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			},
+			want: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed},
+		},
+		{
+			name: "Opens again after failed trial",
+			input: []step{
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+				{err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+				{advance: time.Second * 10, err: ErrUnavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+				{wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+			},
+			want: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Now()
+			var got []CircuitState
+			b := NewCircuitBreaker(func(o *CircuitBreakerOptions) {
+				o.WindowSize = 4
+				o.MinRequests = 3
+				o.FailureRate = 0.6
+				o.CoolDown = time.Second * 10
+				o.OnStateChange = func(from, to CircuitState) {
+					got = append(got, to)
+				}
+			})
+			b.now = func() time.Time { return now }
+
+			reporter := &mockReporter{}
+			r := b.Reporter(reporter)
+			for i, step := range test.input {
+				now = now.Add(step.advance)
+				reporter.err = step.err
+
+				gotErr := r.Run(context.Background(), Report{ID: "123"})
+				if !errors.Is(gotErr, step.wantErr) {
+					t.Errorf("Run() = unexpected error at step %d, want: %v, got: %v\n", i, step.wantErr, gotErr)
+				}
+				if gotState := b.State(); gotState != step.wantState {
+					t.Errorf("State() = unexpected result at step %d, want: %s, got: %s\n", i, step.wantState, gotState)
+				}
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("OnStateChange = unexpected transitions, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_RetryAfter(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(func(o *CircuitBreakerOptions) {
+		o.MinRequests = 1
+		o.CoolDown = time.Second * 30
+	})
+	b.now = func() time.Time { return now }
+	r := b.Reporter(mockReporter{err: ErrUnavailable})
+
+	r.Run(context.Background(), Report{ID: "123"})
+	now = now.Add(time.Second * 10)
+	err := r.Run(context.Background(), Report{ID: "123"})
+
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Run() = unexpected error, want: %v, got: %v\n", ErrCircuitOpen, err)
+	}
+	if openErr.RetryAfter != time.Second*20 {
+		t.Errorf("Run() = unexpected retry after, want: %v, got: %v\n", time.Second*20, openErr.RetryAfter)
+	}
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Run() = unexpected error, want error to be %v\n", ErrUnavailable)
+	}
+}
+
+func TestCircuitBreaker_RunBatch(t *testing.T) {
+	b := NewCircuitBreaker(func(o *CircuitBreakerOptions) {
+		o.MinRequests = 1
+	})
+	r := b.Reporter(mockBatchReporter{errs: map[string]error{"456": ErrUnavailable}}).(BatchReporter)
+	reports := []Report{{ID: "123"}, {ID: "456"}}
+
+	errs := r.RunBatch(context.Background(), reports)
+	if errs[0] != nil || !errors.Is(errs[1], ErrUnavailable) {
+		t.Errorf("RunBatch() = unexpected errors, got: %v\n", errs)
+	}
+	if got := b.State(); got != CircuitOpen {
+		t.Errorf("State() = unexpected result, want: %s, got: %s\n", CircuitOpen, got)
+	}
+
+	errs = r.RunBatch(context.Background(), reports)
+	for i, err := range errs {
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("RunBatch() = unexpected error for report %d, want: %v, got: %v\n", i, ErrCircuitOpen, err)
+		}
+	}
+}
+
+func TestCircuitBreaker_Cancelled(t *testing.T) {
+	b := NewCircuitBreaker(func(o *CircuitBreakerOptions) {
+		o.MinRequests = 1
+	})
+	r := b.Reporter(mockReporter{err: ErrTimeout})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx, Report{ID: "123"})
+
+	if got := b.State(); got != CircuitClosed {
+		t.Errorf("State() = unexpected result, want: %s, got: %s\n", CircuitClosed, got)
+	}
+}
+
+func TestCircuitBreaker_DeadlineExceeded(t *testing.T) {
+	b := NewCircuitBreaker(func(o *CircuitBreakerOptions) {
+		o.MinRequests = 1
+	})
+	r := b.Reporter(mockReporter{err: ErrTimeout})
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	r.Run(ctx, Report{ID: "123"})
+
+	if got := b.State(); got != CircuitOpen {
+		t.Errorf("State() = unexpected result, want: %s, got: %s\n", CircuitOpen, got)
+	}
+}
@@ -0,0 +1,197 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	defaultClaimCheckName      = "reports-claims"
+	defaultClaimCheckThreshold = 64 << 10
+	defaultClaimCheckTimeout   = time.Second * 10
+)
+
+// ClaimCheckReporter is a reporter that stores the data of reports larger
+// than a threshold with a DAPR output binding, and runs the report with a
+// claim check in place of the data with the wrapped reporter. Smaller
+// reports are run as they are. Reports with data that was stored before
+// they are run (streamed uploads) are run as they are, or with a copy of
+// the data if a prefix is set.
+type ClaimCheckReporter struct {
+	client
+	r         Reporter
+	name      string
+	prefix    string
+	threshold int
+	timeout   time.Duration
+}
+
+// ClaimCheckReporterOptions contains settings for a ClaimCheckReporter.
+type ClaimCheckReporterOptions struct {
+	// Name is the name of the output binding used to store data.
+	Name string
+	// Prefix is prepended to the names of the stored data. Used to store
+	// a copy of the data per reporter when reports are sent with several
+	// reporters, since the worker removes the data it has resolved.
+	Prefix string
+	// Threshold is the size in bytes above which data is stored with
+	// the output binding.
+	Threshold int
+	Timeout   time.Duration
+}
+
+// ClaimCheckReporterOption is a function that sets *ClaimCheckReporterOptions.
+type ClaimCheckReporterOption func(o *ClaimCheckReporterOptions)
+
+// NewClaimCheckReporter creates a new *ClaimCheckReporter wrapping the
+// provided reporter with the provided options.
+func NewClaimCheckReporter(r Reporter, options ...ClaimCheckReporterOption) (*ClaimCheckReporter, error) {
+	if r == nil {
+		return nil, errors.New("reporter is nil")
+	}
+
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClaimCheckReporter(r, options...)
+	c.client = client
+
+	return c, nil
+}
+
+// newClaimCheckReporter creates a new *ClaimCheckReporter wrapping the
+// provided reporter with the provided options.
+func newClaimCheckReporter(r Reporter, options ...ClaimCheckReporterOption) *ClaimCheckReporter {
+	opts := ClaimCheckReporterOptions{
+		Name:      defaultClaimCheckName,
+		Threshold: defaultClaimCheckThreshold,
+		Timeout:   defaultClaimCheckTimeout,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &ClaimCheckReporter{
+		r:         r,
+		name:      opts.Name,
+		prefix:    opts.Prefix,
+		threshold: opts.Threshold,
+		timeout:   opts.Timeout,
+	}
+}
+
+// Run a report routine. The data of a report above the threshold is stored
+// before the report is run. The data stored by the reporter is removed if
+// the report was not sent (see NotSent). The data is kept if it is unknown
+// if the report was sent, since the worker may still resolve it, and is
+// expected to expire with the lifecycle rules of the storage otherwise.
+func (r ClaimCheckReporter) Run(ctx context.Context, report Report) error {
+	report, stored, err := r.check(ctx, report)
+	if err != nil {
+		return err
+	}
+	if err := r.r.Run(ctx, report); err != nil {
+		if stored && NotSent(err) {
+			r.release(ctx, report)
+		}
+		return err
+	}
+	return nil
+}
+
+// RunBatch runs a report routine for every report. The data of reports
+// above the threshold is stored before the reports are run. If the wrapped
+// reporter is a BatchReporter the reports are run as a batch, otherwise they
+// are run one by one. Stored data is removed as with Run. Returns one error
+// per report, in the same order as the reports.
+func (r ClaimCheckReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	errs := make([]error, len(reports))
+	pending := make([]int, 0, len(reports))
+	batch := make([]Report, 0, len(reports))
+	stored := make([]bool, 0, len(reports))
+	for i, report := range reports {
+		report, ok, err := r.check(ctx, report)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		pending = append(pending, i)
+		batch = append(batch, report)
+		stored = append(stored, ok)
+	}
+	if len(batch) == 0 {
+		return errs
+	}
+
+	var batchErrs []error
+	if br, ok := r.r.(BatchReporter); ok {
+		batchErrs = br.RunBatch(ctx, batch)
+	} else {
+		batchErrs = make([]error, len(batch))
+		for j, report := range batch {
+			batchErrs[j] = r.r.Run(ctx, report)
+		}
+	}
+
+	for j, i := range pending {
+		if stored[j] && NotSent(batchErrs[j]) {
+			r.release(ctx, batch[j])
+		}
+		errs[i] = batchErrs[j]
+	}
+	return errs
+}
+
+// check stores the data of the report if it is above the threshold and
+// returns the report with a claim check in place of the data. The data of
+// a report that already has a claim check is copied if a prefix is set.
+// Returns true if data was stored by the reporter.
+func (r ClaimCheckReporter) check(ctx context.Context, report Report) (Report, bool, error) {
+	if len(report.ClaimCheck) > 0 {
+		if len(r.prefix) == 0 {
+			return report, false, nil
+		}
+		name := r.prefix + report.ClaimCheck
+		if err := r.store().Copy(ctx, report.ClaimCheck, name, report.ClaimCheckParts); err != nil {
+			return Report{}, false, err
+		}
+		report.ClaimCheck = name
+		return report, true, nil
+	}
+	if len(report.Data) <= r.threshold {
+		return report, false, nil
+	}
+
+	name := r.prefix + claimCheckName(report.ID)
+	if err := r.store().create(ctx, name, report.Data); err != nil {
+		return Report{}, false, err
+	}
+	report.Size = len(report.Data)
+	report.Data = nil
+	report.ClaimCheck = name
+	return report, true, nil
+}
+
+// release removes the stored data of a report with a claim check.
+func (r ClaimCheckReporter) release(ctx context.Context, report Report) {
+	if len(report.ClaimCheck) == 0 {
+		return
+	}
+	r.store().Delete(ctx, report.ClaimCheck, report.ClaimCheckParts)
+}
+
+// store returns a ClaimCheckStore with the binding of the reporter.
+func (r ClaimCheckReporter) store() ClaimCheckStore {
+	return ClaimCheckStore{client: r.client, name: r.name, timeout: r.timeout}
+}
+
+// claimCheckName returns the name of the stored data of a report.
+func claimCheckName(id string) string {
+	return id + ".data"
+}
@@ -0,0 +1,318 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewClaimCheckReporter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []ClaimCheckReporterOption
+		want  *ClaimCheckReporter
+	}{
+		{
+			name:  "Empty",
+			input: nil,
+			want: &ClaimCheckReporter{
+				r:         mockReporter{},
+				name:      defaultClaimCheckName,
+				threshold: defaultClaimCheckThreshold,
+				timeout:   defaultClaimCheckTimeout,
+			},
+		},
+		{
+			name: "With options",
+			input: []ClaimCheckReporterOption{
+				func(o *ClaimCheckReporterOptions) {
+					o.Name = "name"
+					o.Prefix = "copy."
+					o.Threshold = 1024
+					o.Timeout = time.Second * 5
+				},
+			},
+			want: &ClaimCheckReporter{
+				r:         mockReporter{},
+				name:      "name",
+				prefix:    "copy.",
+				threshold: 1024,
+				timeout:   time.Second * 5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newClaimCheckReporter(mockReporter{}, test.input...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(ClaimCheckReporter{}, mockReporter{})); diff != "" {
+				t.Errorf("newClaimCheckReporter() = unexpected, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClaimCheckReporter_Run(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			report    Report
+			bindErr   error
+			reportErr error
+		}
+		wantReports    []Report
+		wantOperations []string
+		wantErr        bool
+	}{
+		{
+			name: "Below threshold",
+			input: struct {
+				report    Report
+				bindErr   error
+				reportErr error
+			}{
+				report: Report{ID: "id", Data: []byte("data")},
+			},
+			wantReports: []Report{{ID: "id", Data: []byte("data")}},
+		},
+		{
+			name: "Above threshold",
+			input: struct {
+				report    Report
+				bindErr   error
+				reportErr error
+			}{
+				report: Report{ID: "id", Data: []byte("large data")},
+			},
+			wantReports:    []Report{{ID: "id", ClaimCheck: "id.data", Size: 10}},
+			wantOperations: []string{"create id.data"},
+		},
+		{
+			name: "With binding error",
+			input: struct {
+				report    Report
+				bindErr   error
+				reportErr error
+			}{
+				report:  Report{ID: "id", Data: []byte("large data")},
+				bindErr: errors.New("error"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "With reporter error",
+			input: struct {
+				report    Report
+				bindErr   error
+				reportErr error
+			}{
+				report:    Report{ID: "id", Data: []byte("large data")},
+				reportErr: errors.New("error"),
+			},
+			wantReports:    []Report{{ID: "id", ClaimCheck: "id.data", Size: 10}},
+			wantOperations: []string{"create id.data", "delete id.data"},
+			wantErr:        true,
+		},
+		{
+			name: "With reporter timeout",
+			input: struct {
+				report    Report
+				bindErr   error
+				reportErr error
+			}{
+				report:    Report{ID: "id", Data: []byte("large data")},
+				reportErr: ErrTimeout,
+			},
+			wantReports:    []Report{{ID: "id", ClaimCheck: "id.data", Size: 10}},
+			wantOperations: []string{"create id.data"},
+			wantErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mockBindingClient{err: test.input.bindErr}
+			reporter := &mockRecordingReporter{err: test.input.reportErr}
+			r := &ClaimCheckReporter{
+				client:    client,
+				r:         reporter,
+				name:      defaultClaimCheckName,
+				threshold: 4,
+				timeout:   defaultClaimCheckTimeout,
+			}
+
+			gotErr := r.Run(context.Background(), test.input.report)
+
+			if diff := cmp.Diff(test.wantReports, reporter.reports); diff != "" {
+				t.Errorf("ClaimCheckReporter.Run() = unexpected reports, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantOperations, client.operations); diff != "" {
+				t.Errorf("ClaimCheckReporter.Run() = unexpected operations, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("ClaimCheckReporter.Run() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestClaimCheckReporter_Prefix(t *testing.T) {
+	client := &mockBindingClient{}
+	reporter := &mockRecordingReporter{}
+	r := &ClaimCheckReporter{
+		client:    client,
+		r:         reporter,
+		name:      defaultClaimCheckName,
+		prefix:    "copy.",
+		threshold: 4,
+		timeout:   defaultClaimCheckTimeout,
+	}
+
+	if err := r.Run(context.Background(), Report{ID: "id", Data: []byte("large data")}); err != nil {
+		t.Fatalf("ClaimCheckReporter.Run() = unexpected error: %v\n", err)
+	}
+	if diff := cmp.Diff([]Report{{ID: "id", ClaimCheck: "copy.id.data", Size: 10}}, reporter.reports); diff != "" {
+		t.Errorf("ClaimCheckReporter.Run() = unexpected reports, (-want +got):\n%s\n", diff)
+	}
+	if diff := cmp.Diff([]string{"create copy.id.data"}, client.operations); diff != "" {
+		t.Errorf("ClaimCheckReporter.Run() = unexpected operations, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestClaimCheckReporter_Stored(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			prefix    string
+			reportErr error
+		}
+		wantReports    []Report
+		wantOperations []string
+	}{
+		{
+			name: "Without prefix",
+			input: struct {
+				prefix    string
+				reportErr error
+			}{
+				reportErr: errors.New("error"),
+			},
+			wantReports: []Report{{ID: "id", ClaimCheck: "upload.data", ClaimCheckParts: 2, Size: 10}},
+		},
+		{
+			name: "With prefix",
+			input: struct {
+				prefix    string
+				reportErr error
+			}{
+				prefix:    "copy.",
+				reportErr: errors.New("error"),
+			},
+			wantReports: []Report{{ID: "id", ClaimCheck: "copy.upload.data", ClaimCheckParts: 2, Size: 10}},
+			wantOperations: []string{
+				"get upload.data.0", "create copy.upload.data.0",
+				"get upload.data.1", "create copy.upload.data.1",
+				"delete copy.upload.data.0", "delete copy.upload.data.1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mockBindingClient{}
+			reporter := &mockRecordingReporter{err: test.input.reportErr}
+			r := &ClaimCheckReporter{
+				client:    client,
+				r:         reporter,
+				name:      defaultClaimCheckName,
+				prefix:    test.input.prefix,
+				threshold: 4,
+				timeout:   defaultClaimCheckTimeout,
+			}
+
+			_ = r.Run(context.Background(), Report{ID: "id", ClaimCheck: "upload.data", ClaimCheckParts: 2, Size: 10})
+
+			if diff := cmp.Diff(test.wantReports, reporter.reports); diff != "" {
+				t.Errorf("ClaimCheckReporter.Run() = unexpected reports, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantOperations, client.operations); diff != "" {
+				t.Errorf("ClaimCheckReporter.Run() = unexpected operations, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClaimCheckReporter_RunBatch(t *testing.T) {
+	client := &mockBindingClient{}
+	r := &ClaimCheckReporter{
+		client: client,
+		r: mockBatchReporter{errs: map[string]error{
+			"3": errors.New("error"),
+		}},
+		name:      defaultClaimCheckName,
+		threshold: 4,
+		timeout:   defaultClaimCheckTimeout,
+	}
+
+	reports := []Report{
+		{ID: "1", Data: []byte("data")},
+		{ID: "2", Data: []byte("large data")},
+		{ID: "3", Data: []byte("large data")},
+	}
+	gotErrs := r.RunBatch(context.Background(), reports)
+
+	wantErrs := []bool{false, false, true}
+	for i, err := range gotErrs {
+		if wantErrs[i] != (err != nil) {
+			t.Errorf("ClaimCheckReporter.RunBatch() = unexpected error for report %d: %v\n", i, err)
+		}
+	}
+	wantOperations := []string{"create 2.data", "create 3.data", "delete 3.data"}
+	if diff := cmp.Diff(wantOperations, client.operations); diff != "" {
+		t.Errorf("ClaimCheckReporter.RunBatch() = unexpected operations, (-want +got):\n%s\n", diff)
+	}
+}
+
+type mockBindingClient struct {
+	mockClient
+	err        error
+	operations []string
+	blobs      map[string][]byte
+}
+
+func (c *mockBindingClient) InvokeBinding(ctx context.Context, in *dapr.InvokeBindingRequest) (*dapr.BindingEvent, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.operations = append(c.operations, in.Operation+" "+in.Metadata["blobName"])
+	return &dapr.BindingEvent{Data: c.blobs[in.Metadata["blobName"]]}, nil
+}
+
+func (c *mockBindingClient) InvokeOutputBinding(ctx context.Context, in *dapr.InvokeBindingRequest) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.operations = append(c.operations, in.Operation+" "+in.Metadata["blobName"])
+	if in.Operation == "create" {
+		if c.blobs == nil {
+			c.blobs = map[string][]byte{}
+		}
+		c.blobs[in.Metadata["blobName"]] = append([]byte(nil), in.Data...)
+	}
+	return nil
+}
+
+type mockRecordingReporter struct {
+	err     error
+	reports []Report
+}
+
+func (r *mockRecordingReporter) Run(ctx context.Context, report Report) error {
+	r.reports = append(r.reports, report)
+	return r.err
+}
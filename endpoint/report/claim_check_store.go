@@ -0,0 +1,197 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	defaultClaimCheckPartSize = 4 << 20
+)
+
+// ClaimCheckStore stores the data of claim checks with a DAPR output
+// binding. Data that is streamed is stored in parts, so that it is never
+// held in memory as a whole.
+type ClaimCheckStore struct {
+	client
+	name     string
+	partSize int
+	timeout  time.Duration
+}
+
+// ClaimCheckStoreOptions contains settings for a ClaimCheckStore.
+type ClaimCheckStoreOptions struct {
+	// Name is the name of the output binding used to store data.
+	Name string
+	// PartSize is the size in bytes of the parts streamed data is stored
+	// in.
+	PartSize int
+	// Timeout is the timeout of every binding call.
+	Timeout time.Duration
+}
+
+// ClaimCheckStoreOption is a function that sets *ClaimCheckStoreOptions.
+type ClaimCheckStoreOption func(o *ClaimCheckStoreOptions)
+
+// NewClaimCheckStore creates a new *ClaimCheckStore with the provided
+// options.
+func NewClaimCheckStore(options ...ClaimCheckStoreOption) (*ClaimCheckStore, error) {
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	s := newClaimCheckStore(options...)
+	s.client = client
+
+	return s, nil
+}
+
+// newClaimCheckStore creates a new *ClaimCheckStore with the provided
+// options.
+func newClaimCheckStore(options ...ClaimCheckStoreOption) *ClaimCheckStore {
+	opts := ClaimCheckStoreOptions{
+		Name:     defaultClaimCheckName,
+		PartSize: defaultClaimCheckPartSize,
+		Timeout:  defaultClaimCheckTimeout,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = defaultClaimCheckPartSize
+	}
+
+	return &ClaimCheckStore{
+		name:     opts.Name,
+		partSize: opts.PartSize,
+		timeout:  opts.Timeout,
+	}
+}
+
+// Store reads r until EOF and stores the data in parts of at most the
+// part size, with the provided name and the index of the part. Only one
+// part is held in memory at a time. Returns the number of parts and the
+// size of the data. Parts stored before an error are removed.
+func (s ClaimCheckStore) Store(ctx context.Context, name string, r io.Reader) (int, int, error) {
+	buf := make([]byte, s.partSize)
+	var parts, size int
+	for {
+		n, err := io.ReadFull(r, buf)
+		eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !eof {
+			s.Delete(ctx, name, parts)
+			return 0, 0, err
+		}
+		if n > 0 {
+			if err := s.create(ctx, partName(name, parts), buf[:n]); err != nil {
+				s.Delete(ctx, name, parts)
+				return 0, 0, err
+			}
+			parts++
+			size += n
+		}
+		if eof {
+			return parts, size, nil
+		}
+	}
+}
+
+// Copy copies the stored data with the provided name and number of parts
+// to a new name, one part at a time. Data stored as a whole has 0 parts.
+// Parts copied before an error are removed.
+func (s ClaimCheckStore) Copy(ctx context.Context, from, to string, parts int) error {
+	for i := range max(parts, 1) {
+		src, dst := from, to
+		if parts > 0 {
+			src, dst = partName(from, i), partName(to, i)
+		}
+		data, err := s.get(ctx, src)
+		if err != nil {
+			s.Delete(ctx, to, i)
+			return err
+		}
+		if err := s.create(ctx, dst, data); err != nil {
+			s.Delete(ctx, to, i)
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the stored data with the provided name and number of
+// parts. Data stored as a whole has 0 parts. Errors are ignored, the data
+// is expected to expire with the lifecycle rules of the storage. The data
+// is removed even if the context is cancelled.
+func (s ClaimCheckStore) Delete(ctx context.Context, name string, parts int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+	defer cancel()
+
+	names := []string{name}
+	if parts > 0 {
+		names = make([]string, parts)
+		for i := range parts {
+			names[i] = partName(name, i)
+		}
+	}
+	for _, name := range names {
+		_ = s.InvokeOutputBinding(ctx, &dapr.InvokeBindingRequest{
+			Name:      s.name,
+			Operation: "delete",
+			Metadata:  blobMetadata(name),
+		})
+	}
+}
+
+// create stores data with the provided name.
+func (s ClaimCheckStore) create(ctx context.Context, name string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.InvokeOutputBinding(ctx, &dapr.InvokeBindingRequest{
+		Name:      s.name,
+		Operation: "create",
+		Data:      data,
+		Metadata:  blobMetadata(name),
+	}); err != nil {
+		return fmt.Errorf("error storing claim check: %w", classifyError(err))
+	}
+	return nil
+}
+
+// get returns the data stored with the provided name.
+func (s ClaimCheckStore) get(ctx context.Context, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	out, err := s.InvokeBinding(ctx, &dapr.InvokeBindingRequest{
+		Name:      s.name,
+		Operation: "get",
+		Metadata:  blobMetadata(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting claim check: %w", classifyError(err))
+	}
+	return out.Data, nil
+}
+
+// partName returns the name of a part of stored data.
+func partName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
+}
+
+// blobMetadata returns the metadata of a binding call for the data with
+// the provided name.
+func blobMetadata(name string) map[string]string {
+	return map[string]string{
+		"key":      name,
+		"blobName": name,
+	}
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewClaimCheckStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []ClaimCheckStoreOption
+		want  *ClaimCheckStore
+	}{
+		{
+			name:  "Empty",
+			input: nil,
+			want: &ClaimCheckStore{
+				name:     defaultClaimCheckName,
+				partSize: defaultClaimCheckPartSize,
+				timeout:  defaultClaimCheckTimeout,
+			},
+		},
+		{
+			name: "With options",
+			input: []ClaimCheckStoreOption{
+				func(o *ClaimCheckStoreOptions) {
+					o.Name = "name"
+					o.PartSize = 1024
+					o.Timeout = time.Second * 5
+				},
+			},
+			want: &ClaimCheckStore{
+				name:     "name",
+				partSize: 1024,
+				timeout:  time.Second * 5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newClaimCheckStore(test.input...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(ClaimCheckStore{})); diff != "" {
+				t.Errorf("newClaimCheckStore() = unexpected, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClaimCheckStore_Store(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			r       io.Reader
+			bindErr error
+		}
+		wantParts      int
+		wantSize       int
+		wantBlobs      map[string][]byte
+		wantOperations []string
+		wantErr        bool
+	}{
+		{
+			name: "Data in parts",
+			input: struct {
+				r       io.Reader
+				bindErr error
+			}{
+				r: strings.NewReader("large data"),
+			},
+			wantParts: 3,
+			wantSize:  10,
+			wantBlobs: map[string][]byte{
+				"id.data.0": []byte("larg"),
+				"id.data.1": []byte("e da"),
+				"id.data.2": []byte("ta"),
+			},
+			wantOperations: []string{"create id.data.0", "create id.data.1", "create id.data.2"},
+		},
+		{
+			name: "With read error",
+			input: struct {
+				r       io.Reader
+				bindErr error
+			}{
+				r: io.MultiReader(strings.NewReader("large"), iotest.ErrReader(errors.New("error"))),
+			},
+			wantBlobs:      map[string][]byte{"id.data.0": []byte("larg")},
+			wantOperations: []string{"create id.data.0", "delete id.data.0"},
+			wantErr:        true,
+		},
+		{
+			name: "With binding error",
+			input: struct {
+				r       io.Reader
+				bindErr error
+			}{
+				r:       strings.NewReader("large data"),
+				bindErr: errors.New("error"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mockBindingClient{err: test.input.bindErr}
+			s := ClaimCheckStore{client: client, name: defaultClaimCheckName, partSize: 4, timeout: defaultClaimCheckTimeout}
+
+			gotParts, gotSize, gotErr := s.Store(context.Background(), "id.data", test.input.r)
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("Store() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+			if gotParts != test.wantParts || gotSize != test.wantSize {
+				t.Errorf("Store() = unexpected result, want: %d, %d, got: %d, %d\n", test.wantParts, test.wantSize, gotParts, gotSize)
+			}
+			if diff := cmp.Diff(test.wantBlobs, client.blobs); diff != "" {
+				t.Errorf("Store() = unexpected blobs, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantOperations, client.operations); diff != "" {
+				t.Errorf("Store() = unexpected operations, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClaimCheckStore_Copy(t *testing.T) {
+	var tests = []struct {
+		name           string
+		input          int
+		wantOperations []string
+	}{
+		{
+			name:           "Whole data",
+			input:          0,
+			wantOperations: []string{"get id.data", "create copy.id.data"},
+		},
+		{
+			name:           "Data in parts",
+			input:          2,
+			wantOperations: []string{"get id.data.0", "create copy.id.data.0", "get id.data.1", "create copy.id.data.1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mockBindingClient{}
+			s := ClaimCheckStore{client: client, name: defaultClaimCheckName, partSize: 4, timeout: defaultClaimCheckTimeout}
+
+			if err := s.Copy(context.Background(), "id.data", "copy.id.data", test.input); err != nil {
+				t.Fatalf("Copy() = unexpected error: %v\n", err)
+			}
+			if diff := cmp.Diff(test.wantOperations, client.operations); diff != "" {
+				t.Errorf("Copy() = unexpected operations, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClaimCheckStore_Delete(t *testing.T) {
+	client := &mockBindingClient{}
+	s := ClaimCheckStore{client: client, name: defaultClaimCheckName, partSize: 4, timeout: defaultClaimCheckTimeout}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Delete(ctx, "id.data", 2)
+
+	if diff := cmp.Diff([]string{"delete id.data.0", "delete id.data.1"}, client.operations); diff != "" {
+		t.Errorf("Delete() = unexpected operations, (-want +got):\n%s\n", diff)
+	}
+}
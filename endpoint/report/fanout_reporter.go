@@ -0,0 +1,258 @@
+package report
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFanOutTimeout = time.Second * 30
+)
+
+// FanOutPolicy decides if a report that is sent with several reporters
+// succeeded.
+type FanOutPolicy string
+
+const (
+	// FanOutAll requires every reporter to accept the report.
+	FanOutAll FanOutPolicy = "all"
+	// FanOutAny requires at least one reporter to accept the report.
+	FanOutAny FanOutPolicy = "any"
+	// FanOutPrimary requires the first reporter, the primary, to accept
+	// the report. The other reporters are best-effort, and finish in the
+	// background when reports are sent concurrently.
+	FanOutPrimary FanOutPolicy = "primary"
+)
+
+// errNotSent is the error of a delivery that was not attempted, since the
+// report had already failed.
+var errNotSent = errors.New("not sent")
+
+// FanOutReporter is a reporter that sends every report with several
+// reporters, concurrently or in order. The policy decides if a report
+// succeeded. The names of the reporters that accepted a report are recorded
+// as the reporter that accepted it, comma separated, and the result of
+// every reporter as the deliveries of the report (see WithReceipts).
+type FanOutReporter struct {
+	reporters  []NamedReporter
+	policy     FanOutPolicy
+	sequential bool
+	timeout    time.Duration
+	onDelivery func(id string, d Delivery)
+}
+
+// FanOutReporterOptions contains settings for a FanOutReporter.
+type FanOutReporterOptions struct {
+	// Policy decides if a report succeeded. Defaults to FanOutAll.
+	Policy FanOutPolicy
+	// Sequential sends reports with the reporters in order instead of
+	// concurrently, and does not send a report with the remaining
+	// reporters once it has failed under the policy.
+	Sequential bool
+	// Timeout is the time the best-effort reporters have to send reports
+	// in the background with FanOutPrimary. Defaults to 30 seconds.
+	Timeout time.Duration
+	// OnDelivery is called with the delivery of every report with every
+	// reporter. Optional.
+	OnDelivery func(id string, d Delivery)
+}
+
+// FanOutReporterOption is a function that sets *FanOutReporterOptions.
+type FanOutReporterOption func(o *FanOutReporterOptions)
+
+// NewFanOutReporter creates a new *FanOutReporter with the provided
+// reporters, the first being the primary, and options.
+func NewFanOutReporter(reporters []NamedReporter, options ...FanOutReporterOption) (*FanOutReporter, error) {
+	if len(reporters) == 0 {
+		return nil, errors.New("no reporters")
+	}
+	for _, r := range reporters {
+		if r.Reporter == nil {
+			return nil, errors.New("reporter " + r.Name + " is nil")
+		}
+	}
+
+	opts := FanOutReporterOptions{
+		Policy:  FanOutAll,
+		Timeout: defaultFanOutTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	switch opts.Policy {
+	case FanOutAll, FanOutAny, FanOutPrimary:
+	default:
+		return nil, errors.New("unknown fan-out policy: " + string(opts.Policy))
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultFanOutTimeout
+	}
+
+	return &FanOutReporter{
+		reporters:  reporters,
+		policy:     opts.Policy,
+		sequential: opts.Sequential,
+		timeout:    opts.Timeout,
+		onDelivery: opts.OnDelivery,
+	}, nil
+}
+
+// Run a report routine with every reporter. Returns the error of the
+// first reporter that failed if the report failed under the policy.
+func (r FanOutReporter) Run(ctx context.Context, report Report) error {
+	return r.RunBatch(ctx, []Report{report})[0]
+}
+
+// RunBatch runs a report routine for every report with every reporter.
+// Reporters that are BatchReporters run the reports as a batch. Returns
+// one error per report, in the same order as the reports. With
+// FanOutPrimary and concurrent reporters, RunBatch returns when the
+// primary is done, and only the delivery of the primary is recorded.
+func (r FanOutReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	reporters := r.reporters
+	if !r.sequential && r.policy == FanOutPrimary {
+		r.sendBackground(ctx, reporters[1:], reports)
+		reporters = reporters[:1]
+	}
+
+	results := make([][]error, len(reporters))
+	accepted := make([][]string, len(reporters))
+	if r.sequential {
+		failed := make([]bool, len(reports))
+		for d, nr := range r.reporters {
+			results[d], accepted[d] = send(ctx, nr, reports, failed)
+			if r.policy == FanOutAny || (r.policy == FanOutPrimary && d > 0) {
+				continue
+			}
+			for i, err := range results[d] {
+				if err != nil {
+					failed[i] = true
+				}
+			}
+		}
+	} else {
+		var wg sync.WaitGroup
+		for d, nr := range reporters {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[d], accepted[d] = send(ctx, nr, reports, nil)
+			}()
+		}
+		wg.Wait()
+	}
+
+	errs := make([]error, len(reports))
+	for i, report := range reports {
+		deliveries := make([]Delivery, len(reporters))
+		var names []string
+		var err error
+		for d, nr := range reporters {
+			if results[d][i] != nil {
+				deliveries[d] = Delivery{Reporter: nr.Name, Error: results[d][i].Error()}
+				if err == nil {
+					err = results[d][i]
+				}
+			} else {
+				deliveries[d] = Delivery{Reporter: accepted[d][i]}
+				names = append(names, accepted[d][i])
+			}
+			if r.onDelivery != nil {
+				r.onDelivery(report.ID, deliveries[d])
+			}
+		}
+
+		switch r.policy {
+		case FanOutAny:
+			if len(names) > 0 {
+				err = nil
+			}
+		case FanOutPrimary:
+			err = results[0][i]
+		}
+		errs[i] = err
+
+		recordDeliveries(ctx, report.ID, deliveries)
+		if err == nil {
+			recordAccepted(ctx, report.ID, strings.Join(names, ","))
+		}
+	}
+	return errs
+}
+
+// sendBackground sends the reports with the provided best-effort
+// reporters concurrently, without waiting for them. The reports are sent
+// with a context that is not cancelled with ctx, but expires after the
+// timeout of the reporter. The deliveries are passed to OnDelivery.
+func (r FanOutReporter) sendBackground(ctx context.Context, reporters []NamedReporter, reports []Report) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	var wg sync.WaitGroup
+	for _, nr := range reporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs, accepted := send(ctx, nr, reports, nil)
+			if r.onDelivery == nil {
+				return
+			}
+			for i, report := range reports {
+				d := Delivery{Reporter: accepted[i]}
+				if errs[i] != nil {
+					d = Delivery{Reporter: nr.Name, Error: errs[i].Error()}
+				}
+				r.onDelivery(report.ID, d)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+}
+
+// send runs the reports that are not skipped with the reporter, and returns
+// one error and the name of the reporter that accepted it per report. The
+// reports are run with new receipts, so that the name is that of the
+// reporter that accepted the report if the reporter is made of several
+// reporters, such as a ChainReporter.
+func send(ctx context.Context, nr NamedReporter, reports []Report, skip []bool) ([]error, []string) {
+	errs := make([]error, len(reports))
+	accepted := make([]string, len(reports))
+	indexes := make([]int, 0, len(reports))
+	batch := make([]Report, 0, len(reports))
+	for i, report := range reports {
+		if skip != nil && skip[i] {
+			errs[i] = errNotSent
+			continue
+		}
+		indexes = append(indexes, i)
+		batch = append(batch, report)
+	}
+	if len(batch) == 0 {
+		return errs, accepted
+	}
+
+	ctx = newReceipts(ctx)
+	var batchErrs []error
+	if br, ok := nr.Reporter.(BatchReporter); ok && len(batch) > 1 {
+		batchErrs = br.RunBatch(ctx, batch)
+	} else {
+		batchErrs = make([]error, len(batch))
+		for j, report := range batch {
+			batchErrs[j] = nr.Reporter.Run(ctx, report)
+		}
+	}
+
+	for j, i := range indexes {
+		errs[i] = batchErrs[j]
+		if errs[i] == nil {
+			accepted[i] = cmp.Or(AcceptedBy(ctx, reports[i].ID), nr.Name)
+		}
+	}
+	return errs, accepted
+}
@@ -0,0 +1,310 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewFanOutReporter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			reporters []NamedReporter
+			policy    FanOutPolicy
+		}
+		wantErr bool
+	}{
+		{
+			name: "With reporters",
+			input: struct {
+				reporters []NamedReporter
+				policy    FanOutPolicy
+			}{
+				reporters: []NamedReporter{{Name: "queue", Reporter: mockReporter{}}, {Name: "pubsub", Reporter: mockReporter{}}},
+				policy:    FanOutPrimary,
+			},
+		},
+		{
+			name: "Without reporters",
+			input: struct {
+				reporters []NamedReporter
+				policy    FanOutPolicy
+			}{
+				policy: FanOutAll,
+			},
+			wantErr: true,
+		},
+		{
+			name: "With nil reporter",
+			input: struct {
+				reporters []NamedReporter
+				policy    FanOutPolicy
+			}{
+				reporters: []NamedReporter{{Name: "queue"}},
+				policy:    FanOutAll,
+			},
+			wantErr: true,
+		},
+		{
+			name: "With unknown policy",
+			input: struct {
+				reporters []NamedReporter
+				policy    FanOutPolicy
+			}{
+				reporters: []NamedReporter{{Name: "queue", Reporter: mockReporter{}}},
+				policy:    "some",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := NewFanOutReporter(test.input.reporters, func(o *FanOutReporterOptions) {
+				o.Policy = test.input.policy
+			})
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("NewFanOutReporter() = unexpected error, want error: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestFanOutReporter_Run(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			policy     FanOutPolicy
+			sequential bool
+			errs       []error
+		}
+		wantErr        error
+		wantAccepted   string
+		wantDeliveries []Delivery
+		wantSent       []int
+	}{
+		{
+			name: "All",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy: FanOutAll,
+				errs:   []error{nil, nil, nil},
+			},
+			wantAccepted:   "primary,secondary,analytics",
+			wantDeliveries: []Delivery{{Reporter: "primary"}, {Reporter: "secondary"}, {Reporter: "analytics"}},
+			wantSent:       []int{1, 1, 1},
+		},
+		{
+			name: "All with failure",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy: FanOutAll,
+				errs:   []error{nil, ErrUnavailable, nil},
+			},
+			wantErr:        ErrUnavailable,
+			wantDeliveries: []Delivery{{Reporter: "primary"}, {Reporter: "secondary", Error: ErrUnavailable.Error()}, {Reporter: "analytics"}},
+			wantSent:       []int{1, 1, 1},
+		},
+		{
+			name: "All with failure in order",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy:     FanOutAll,
+				sequential: true,
+				errs:       []error{nil, ErrUnavailable, nil},
+			},
+			wantErr:        ErrUnavailable,
+			wantDeliveries: []Delivery{{Reporter: "primary"}, {Reporter: "secondary", Error: ErrUnavailable.Error()}, {Reporter: "analytics", Error: errNotSent.Error()}},
+			wantSent:       []int{1, 1, 0},
+		},
+		{
+			name: "Any with failures",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy:     FanOutAny,
+				sequential: true,
+				errs:       []error{ErrUnavailable, ErrTimeout, nil},
+			},
+			wantAccepted:   "analytics",
+			wantDeliveries: []Delivery{{Reporter: "primary", Error: ErrUnavailable.Error()}, {Reporter: "secondary", Error: ErrTimeout.Error()}, {Reporter: "analytics"}},
+			wantSent:       []int{1, 1, 1},
+		},
+		{
+			name: "Any with every failure",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy: FanOutAny,
+				errs:   []error{ErrUnavailable, ErrTimeout, ErrTimeout},
+			},
+			wantErr:        ErrUnavailable,
+			wantDeliveries: []Delivery{{Reporter: "primary", Error: ErrUnavailable.Error()}, {Reporter: "secondary", Error: ErrTimeout.Error()}, {Reporter: "analytics", Error: ErrTimeout.Error()}},
+			wantSent:       []int{1, 1, 1},
+		},
+		{
+			name: "Primary with best-effort failure",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy:     FanOutPrimary,
+				sequential: true,
+				errs:       []error{nil, ErrUnavailable, nil},
+			},
+			wantAccepted:   "primary,analytics",
+			wantDeliveries: []Delivery{{Reporter: "primary"}, {Reporter: "secondary", Error: ErrUnavailable.Error()}, {Reporter: "analytics"}},
+			wantSent:       []int{1, 1, 1},
+		},
+		{
+			name: "Primary with failure in order",
+			input: struct {
+				policy     FanOutPolicy
+				sequential bool
+				errs       []error
+			}{
+				policy:     FanOutPrimary,
+				sequential: true,
+				errs:       []error{ErrUnavailable, nil, nil},
+			},
+			wantErr:        ErrUnavailable,
+			wantDeliveries: []Delivery{{Reporter: "primary", Error: ErrUnavailable.Error()}, {Reporter: "secondary", Error: errNotSent.Error()}, {Reporter: "analytics", Error: errNotSent.Error()}},
+			wantSent:       []int{1, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := []string{"primary", "secondary", "analytics"}
+			recorders := make([]*mockRecordingReporter, len(names))
+			reporters := make([]NamedReporter, len(names))
+			for i, name := range names {
+				recorders[i] = &mockRecordingReporter{err: test.input.errs[i]}
+				reporters[i] = NamedReporter{Name: name, Reporter: recorders[i]}
+			}
+			var gotDeliveries []Delivery
+			r, _ := NewFanOutReporter(reporters, func(o *FanOutReporterOptions) {
+				o.Policy = test.input.policy
+				o.Sequential = test.input.sequential
+				o.OnDelivery = func(id string, d Delivery) {
+					gotDeliveries = append(gotDeliveries, d)
+				}
+			})
+			ctx := WithReceipts(context.Background())
+
+			gotErr := r.Run(ctx, Report{ID: "123"})
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Run() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if got := AcceptedBy(ctx, "123"); got != test.wantAccepted {
+				t.Errorf("Run() = unexpected accepted by, want: %s, got: %s\n", test.wantAccepted, got)
+			}
+			if diff := cmp.Diff(test.wantDeliveries, Deliveries(ctx, "123")); diff != "" {
+				t.Errorf("Run() = unexpected deliveries, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantDeliveries, gotDeliveries); diff != "" {
+				t.Errorf("OnDelivery = unexpected deliveries, (-want +got):\n%s\n", diff)
+			}
+
+			gotSent := make([]int, len(recorders))
+			for i, re := range recorders {
+				gotSent[i] = len(re.reports)
+			}
+			if diff := cmp.Diff(test.wantSent, gotSent); diff != "" {
+				t.Errorf("Run() = unexpected number of sent reports, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestFanOutReporter_RunBatch(t *testing.T) {
+	chain, _ := NewChainReporter([]NamedReporter{
+		{Name: "primary", Reporter: mockBatchReporter{errs: map[string]error{"456": ErrUnavailable}}},
+		{Name: "fallback", Reporter: mockReporter{}},
+	})
+	r, _ := NewFanOutReporter([]NamedReporter{
+		{Name: "primary", Reporter: chain},
+		{Name: "analytics", Reporter: mockBatchReporter{errs: map[string]error{"123": ErrTimeout, "789": ErrTimeout}}},
+	}, func(o *FanOutReporterOptions) {
+		o.Policy = FanOutPrimary
+		o.Sequential = true
+	})
+	ctx := WithReceipts(context.Background())
+	reports := []Report{{ID: "123"}, {ID: "456"}, {ID: "789"}}
+
+	errs := r.RunBatch(ctx, reports)
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("RunBatch() = unexpected error for report %d: %v\n", i, err)
+		}
+	}
+
+	got := map[string]string{}
+	for _, re := range reports {
+		got[re.ID] = AcceptedBy(ctx, re.ID)
+	}
+	want := map[string]string{"123": "primary", "456": "fallback,analytics", "789": "primary"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RunBatch() = unexpected accepted by, (-want +got):\n%s\n", diff)
+	}
+
+	wantDeliveries := []Delivery{{Reporter: "primary"}, {Reporter: "analytics", Error: ErrTimeout.Error()}}
+	if diff := cmp.Diff(wantDeliveries, Deliveries(ctx, "789")); diff != "" {
+		t.Errorf("RunBatch() = unexpected deliveries, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestFanOutReporter_Background(t *testing.T) {
+	deliveries := make(chan Delivery, 2)
+	r, _ := NewFanOutReporter([]NamedReporter{
+		{Name: "primary", Reporter: mockReporter{}},
+		{Name: "analytics", Reporter: mockBlockingReporter{}},
+	}, func(o *FanOutReporterOptions) {
+		o.Policy = FanOutPrimary
+		o.Timeout = time.Millisecond * 10
+		o.OnDelivery = func(id string, d Delivery) {
+			deliveries <- d
+		}
+	})
+	ctx, cancel := context.WithCancel(WithReceipts(context.Background()))
+
+	gotErr := r.Run(ctx, Report{ID: "123"})
+	cancel()
+
+	if gotErr != nil {
+		t.Errorf("Run() = unexpected error: %v\n", gotErr)
+	}
+	if got := AcceptedBy(ctx, "123"); got != "primary" {
+		t.Errorf("Run() = unexpected accepted by, want: primary, got: %s\n", got)
+	}
+	if diff := cmp.Diff([]Delivery{{Reporter: "primary"}}, Deliveries(ctx, "123")); diff != "" {
+		t.Errorf("Run() = unexpected deliveries, (-want +got):\n%s\n", diff)
+	}
+
+	got := []Delivery{<-deliveries, <-deliveries}
+	want := []Delivery{{Reporter: "primary"}, {Reporter: "analytics", Error: ErrTimeout.Error()}}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b Delivery) bool { return a.Reporter > b.Reporter })); diff != "" {
+		t.Errorf("OnDelivery = unexpected deliveries, (-want +got):\n%s\n", diff)
+	}
+}
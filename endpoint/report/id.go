@@ -0,0 +1,54 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxIDLength is the maximum length of a report ID.
+	maxIDLength = 128
+)
+
+var (
+	// ErrInvalidID is returned when a report ID is not valid.
+	ErrInvalidID = errors.New("invalid report ID")
+)
+
+// idPattern contains the allowed characters of a report ID. The ID
+// is used as a blob name by the worker and must be safe to use as a
+// path segment.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// NewID returns a new sortable unique report ID (UUIDv7).
+func NewID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// ValidateID checks that the provided report ID is safe to use. An ID
+// must start with a letter or digit, only contain letters, digits, '.',
+// '_' and '-', not contain '..' and be at most 128 characters. The
+// returned error wraps ErrInvalidID.
+func ValidateID(id string) error {
+	if len(id) == 0 {
+		return fmt.Errorf("%w: must not be empty", ErrInvalidID)
+	}
+	if len(id) > maxIDLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrInvalidID, maxIDLength)
+	}
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%w: must start with a letter or digit and only contain letters, digits, '.', '_' and '-'", ErrInvalidID)
+	}
+	if strings.Contains(id, "..") {
+		return fmt.Errorf("%w: must not contain '..'", ErrInvalidID)
+	}
+	return nil
+}
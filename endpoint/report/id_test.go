@@ -0,0 +1,83 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewID(t *testing.T) {
+	first, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID() = unexpected error: %v\n", err)
+	}
+	second, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID() = unexpected error: %v\n", err)
+	}
+
+	if first == second {
+		t.Errorf("NewID() = unexpected result, want unique IDs, got: %s and %s\n", first, second)
+	}
+
+	if err := ValidateID(first); err != nil {
+		t.Errorf("NewID() = unexpected result, want valid ID, got: %v\n", err)
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:  "Valid",
+			input: "report-1_2.json",
+		},
+		{
+			name:  "UUID",
+			input: "018c0c8e-4a8a-7c2f-9b1e-0d6a2e2f3c4d",
+		},
+		{
+			name:    "Empty",
+			input:   "",
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "Too long",
+			input:   strings.Repeat("a", maxIDLength+1),
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "With slash",
+			input:   "reports/1",
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "With dot dot",
+			input:   "a..b",
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "Leading dot",
+			input:   ".report",
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "With space",
+			input:   "report 1",
+			wantErr: ErrInvalidID,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := ValidateID(test.input)
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("ValidateID(%q) = unexpected result, want: %v, got: %v\n", test.input, test.wantErr, gotErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package report
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	dapr "github.com/dapr/go-sdk/client"
@@ -17,6 +18,7 @@ type PubsubReporter struct {
 	name    string
 	topic   string
 	timeout time.Duration
+	retry   RetryPolicy
 }
 
 // PubsubReporterOptions contains settings for a PubsubReporter.
@@ -24,6 +26,9 @@ type PubsubReporterOptions struct {
 	Name    string
 	Topic   string
 	Timeout time.Duration
+	// Retry is the retry policy of publish calls. Retries are disabled
+	// by default.
+	Retry RetryPolicy
 }
 
 // PubsubReporterOption is a function that sets *PubsubReporterOptions.
@@ -60,13 +65,87 @@ func newPubsubReporter(options ...PubsubReporterOption) *PubsubReporter {
 		name:    opts.Name,
 		topic:   opts.Topic,
 		timeout: opts.Timeout,
+		retry:   opts.Retry,
 	}
 }
 
-// Run a report routine.
-func (r PubsubReporter) Run(report Report) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+// Run a report routine. Failed publish calls are retried according to the
+// retry policy.
+func (r PubsubReporter) Run(ctx context.Context, report Report) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	return r.PublishEvent(ctx, r.name, r.topic, report.JSON())
+	var options []dapr.PublishEventOption
+	if metadata := publishMetadata(report); metadata != nil {
+		options = append(options, dapr.PublishEventWithMetadata(metadata))
+	}
+	return classifyError(r.retry.do(ctx, []string{report.ID}, func(ctx context.Context) error {
+		return r.PublishEvent(ctx, r.name, r.topic, report.JSON(), options...)
+	}))
+}
+
+// RunBatch runs a report routine for every report with the bulk publish
+// API. Failed events are retried according to the retry policy. Returns
+// one error per report, in the same order as the reports.
+func (r PubsubReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	events := make([]any, len(reports))
+	ids := make([]string, len(reports))
+	for i, report := range reports {
+		events[i] = dapr.PublishEventsEvent{
+			EntryID:     strconv.Itoa(i),
+			Data:        report.JSON(),
+			ContentType: "text/plain",
+			Metadata:    publishMetadata(report),
+		}
+		ids[i] = report.ID
+	}
+
+	// pending are the events that have not been published. Only the
+	// failed events of an attempt are retried.
+	pending := events
+	err := r.retry.do(ctx, ids, func(ctx context.Context) error {
+		res := r.PublishEvents(ctx, r.name, r.topic, pending)
+		if res.Error != nil && len(res.FailedEvents) > 0 {
+			pending = res.FailedEvents
+		}
+		return res.Error
+	})
+
+	errs := make([]error, len(reports))
+	if err == nil {
+		return errs
+	}
+	err = classifyError(err)
+	for _, event := range pending {
+		e, ok := event.(dapr.PublishEventsEvent)
+		if !ok {
+			continue
+		}
+		i, convErr := strconv.Atoi(e.EntryID)
+		if convErr != nil || i < 0 || i >= len(errs) {
+			continue
+		}
+		errs[i] = err
+	}
+	return errs
+}
+
+// publishMetadata returns the trace context of the report as metadata that
+// sets the trace context of the cloud event, together with the deadline of
+// the report. Returns nil if the report has neither.
+func publishMetadata(report Report) map[string]string {
+	if len(report.TraceContext) == 0 && report.Deadline == nil {
+		return nil
+	}
+	metadata := make(map[string]string, len(report.TraceContext)+1)
+	for k, v := range report.TraceContext {
+		metadata["cloudevent."+k] = v
+	}
+	if report.Deadline != nil {
+		metadata[deadlineMetadataKey] = report.Deadline.Format(time.RFC3339Nano)
+	}
+	return metadata
 }
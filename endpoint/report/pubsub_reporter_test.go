@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -117,7 +118,7 @@ func TestPubsubReporter_Run(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gotErr := test.input.reporter.Run(test.input.report)
+			gotErr := test.input.reporter.Run(context.Background(), test.input.report)
 
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("PubsubReporter.Run() = unexpected, want error, got nil\n")
@@ -125,3 +126,92 @@ func TestPubsubReporter_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestPubsubReporter_RunBatch(t *testing.T) {
+	var tests = []struct {
+		name     string
+		input    *mockClient
+		wantErrs []bool
+	}{
+		{
+			name:     "Success",
+			input:    &mockClient{},
+			wantErrs: []bool{false, false, false},
+		},
+		{
+			name:     "With failed events",
+			input:    &mockClient{failed: map[string]bool{"1": true}},
+			wantErrs: []bool{false, true, false},
+		},
+		{
+			name:     "With error",
+			input:    &mockClient{err: errors.New("error")},
+			wantErrs: []bool{true, true, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &PubsubReporter{
+				client:  test.input,
+				name:    defaultReporterName,
+				topic:   defaultReporterTopic,
+				timeout: defaultReporterTimeout,
+			}
+
+			gotErrs := r.RunBatch(context.Background(), []Report{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+
+			if len(gotErrs) != len(test.wantErrs) {
+				t.Fatalf("PubsubReporter.RunBatch() = unexpected, want %d errors, got %d\n", len(test.wantErrs), len(gotErrs))
+			}
+			for i, err := range gotErrs {
+				if test.wantErrs[i] != (err != nil) {
+					t.Errorf("PubsubReporter.RunBatch() = unexpected error for report %d: %v\n", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishMetadata(t *testing.T) {
+	deadline := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var tests = []struct {
+		name  string
+		input Report
+		want  map[string]string
+	}{
+		{
+			name:  "Without trace context or deadline",
+			input: Report{ID: "1"},
+			want:  nil,
+		},
+		{
+			name: "With trace context",
+			input: Report{ID: "1", TraceContext: map[string]string{
+				"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+				"tracestate":  "key=value",
+			}},
+			want: map[string]string{
+				"cloudevent.traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+				"cloudevent.tracestate":  "key=value",
+			},
+		},
+		{
+			name:  "With deadline",
+			input: Report{ID: "1", Deadline: &deadline},
+			want: map[string]string{
+				"deadline": "2024-01-02T03:04:05Z",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := publishMetadata(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("publishMetadata() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
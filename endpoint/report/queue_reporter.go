@@ -2,6 +2,7 @@ package report
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	dapr "github.com/dapr/go-sdk/client"
@@ -14,9 +15,11 @@ const (
 // QueueReporter is a reporter that uses queue binding with DAPR to run reports.
 type QueueReporter struct {
 	client
-	name    string
-	queue   string
-	timeout time.Duration
+	name        string
+	queue       string
+	timeout     time.Duration
+	concurrency int
+	retry       RetryPolicy
 }
 
 // QueueReporterOptions contains settings for a QueueReporter.
@@ -24,6 +27,12 @@ type QueueReporterOptions struct {
 	Name    string
 	Queue   string
 	Timeout time.Duration
+	// Concurrency is the maximum number of concurrent binding calls
+	// when running a batch of reports.
+	Concurrency int
+	// Retry is the retry policy of binding calls. Retries are disabled
+	// by default.
+	Retry RetryPolicy
 }
 
 // QueueReporterOption is a function that sets *QueueReporterOptions.
@@ -47,33 +56,72 @@ func NewQueueReporter(options ...QueueReporterOption) (*QueueReporter, error) {
 // options.
 func newQueueReporter(options ...QueueReporterOption) *QueueReporter {
 	opts := QueueReporterOptions{
-		Name:    defaultReporterName,
-		Queue:   defaultReporterQueue,
-		Timeout: defaultReporterTimeout,
+		Name:        defaultReporterName,
+		Queue:       defaultReporterQueue,
+		Timeout:     defaultReporterTimeout,
+		Concurrency: defaultReporterConcurrency,
 	}
 
 	for _, option := range options {
 		option(&opts)
 	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
 
 	return &QueueReporter{
-		name:    opts.Name,
-		queue:   opts.Queue,
-		timeout: opts.Timeout,
+		name:        opts.Name,
+		queue:       opts.Queue,
+		timeout:     opts.Timeout,
+		concurrency: opts.Concurrency,
+		retry:       opts.Retry,
 	}
 }
 
-// Run a report routine.
-func (r QueueReporter) Run(report Report) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+// Run a report routine. Failed binding calls are retried according to the
+// retry policy.
+func (r QueueReporter) Run(ctx context.Context, report Report) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	return r.InvokeOutputBinding(ctx, &dapr.InvokeBindingRequest{
+	metadata := map[string]string{
+		"queueName": r.queue,
+	}
+	for k, v := range report.TraceContext {
+		metadata[k] = v
+	}
+	if report.Deadline != nil {
+		metadata[deadlineMetadataKey] = report.Deadline.Format(time.RFC3339Nano)
+	}
+
+	req := &dapr.InvokeBindingRequest{
 		Name:      r.name,
 		Operation: "create",
 		Data:      report.JSON(),
-		Metadata: map[string]string{
-			"queueName": r.queue,
-		},
-	})
+		Metadata:  metadata,
+	}
+	return classifyError(r.retry.do(ctx, []string{report.ID}, func(ctx context.Context) error {
+		return r.InvokeOutputBinding(ctx, req)
+	}))
+}
+
+// RunBatch runs a report routine for every report with concurrent binding
+// calls. Returns one error per report, in the same order as the reports.
+func (r QueueReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	errs := make([]error, len(reports))
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, report := range reports {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, report Report) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = r.Run(ctx, report)
+		}(i, report)
+	}
+	wg.Wait()
+	return errs
 }
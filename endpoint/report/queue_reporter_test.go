@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -18,9 +19,10 @@ func TestNewQueueReporter(t *testing.T) {
 			name:  "Empty",
 			input: nil,
 			want: &QueueReporter{
-				name:    defaultReporterName,
-				queue:   defaultReporterQueue,
-				timeout: defaultReporterTimeout,
+				name:        defaultReporterName,
+				queue:       defaultReporterQueue,
+				timeout:     defaultReporterTimeout,
+				concurrency: defaultReporterConcurrency,
 			},
 		},
 		{
@@ -30,12 +32,14 @@ func TestNewQueueReporter(t *testing.T) {
 					o.Name = "name"
 					o.Queue = "queue"
 					o.Timeout = time.Second * 5
+					o.Concurrency = 5
 				},
 			},
 			want: &QueueReporter{
-				name:    "name",
-				queue:   "queue",
-				timeout: time.Second * 5,
+				name:        "name",
+				queue:       "queue",
+				timeout:     time.Second * 5,
+				concurrency: 5,
 			},
 		},
 	}
@@ -117,7 +121,7 @@ func TestQueueReporter_Run(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			gotErr := test.input.reporter.Run(test.input.report)
+			gotErr := test.input.reporter.Run(context.Background(), test.input.report)
 
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("QueueReporter.Run() = unexpected, want error, got nil\n")
@@ -125,3 +129,45 @@ func TestQueueReporter_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueReporter_RunBatch(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   *mockClient
+		wantErr bool
+	}{
+		{
+			name:  "Success",
+			input: &mockClient{},
+		},
+		{
+			name:    "With error",
+			input:   &mockClient{err: errors.New("error")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &QueueReporter{
+				client:      test.input,
+				name:        defaultReporterName,
+				queue:       defaultReporterQueue,
+				timeout:     defaultReporterTimeout,
+				concurrency: 2,
+			}
+
+			reports := []Report{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+			gotErrs := r.RunBatch(context.Background(), reports)
+
+			if len(gotErrs) != len(reports) {
+				t.Fatalf("QueueReporter.RunBatch() = unexpected, want %d errors, got %d\n", len(reports), len(gotErrs))
+			}
+			for i, err := range gotErrs {
+				if test.wantErr != (err != nil) {
+					t.Errorf("QueueReporter.RunBatch() = unexpected error for report %d: %v\n", i, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"context"
+	"sync"
+)
+
+// NamedReporter is a reporter with a name. The name is recorded as the
+// reporter that accepted a report when reports are run with
+// reporters that choose between several reporters, such as a
+// ChainReporter, or send reports with several reporters, such as a
+// FanOutReporter.
+type NamedReporter struct {
+	Name     string
+	Reporter Reporter
+}
+
+// receiptsKey is the context key for the *receipts of a run.
+type receiptsKey struct{}
+
+// Delivery is the result of sending a report with one of the reporters
+// of a FanOutReporter. Error is empty if the reporter accepted the report.
+type Delivery struct {
+	Reporter string `json:"reporter"`
+	Error    string `json:"error,omitempty"`
+}
+
+// receipts contains the names of the reporters that accepted reports,
+// and the deliveries of reports, by report ID.
+type receipts struct {
+	mu         sync.Mutex
+	accepted   map[string]string
+	deliveries map[string][]Delivery
+}
+
+// WithReceipts returns a copy of ctx in which the reporter that accepts
+// a report is recorded. The recorded reporter is returned by AcceptedBy,
+// and the deliveries by Deliveries. Returns ctx if it already records
+// receipts.
+func WithReceipts(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(receiptsKey{}).(*receipts); ok {
+		return ctx
+	}
+	return newReceipts(ctx)
+}
+
+// newReceipts returns a copy of ctx with new receipts, replacing the
+// receipts of ctx, if any.
+func newReceipts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, receiptsKey{}, &receipts{accepted: map[string]string{}, deliveries: map[string][]Delivery{}})
+}
+
+// AcceptedBy returns the name of the reporter that accepted the report
+// with the provided ID, or an empty string if ctx does not record receipts
+// or no named reporter accepted the report.
+func AcceptedBy(ctx context.Context, id string) string {
+	rc, ok := ctx.Value(receiptsKey{}).(*receipts)
+	if !ok {
+		return ""
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.accepted[id]
+}
+
+// Deliveries returns the deliveries of the report with the provided ID,
+// or nil if ctx does not record receipts or the report was not sent with
+// a FanOutReporter.
+func Deliveries(ctx context.Context, id string) []Delivery {
+	rc, ok := ctx.Value(receiptsKey{}).(*receipts)
+	if !ok {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.deliveries[id]
+}
+
+// recordAccepted records that the named reporter accepted the report with
+// the provided ID, if ctx records receipts. The first recorded reporter
+// of a report is kept, so that the innermost reporter, the one that sent
+// the report, is recorded when reporters are nested.
+func recordAccepted(ctx context.Context, id, name string) {
+	rc, ok := ctx.Value(receiptsKey{}).(*receipts)
+	if !ok {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if _, ok := rc.accepted[id]; !ok {
+		rc.accepted[id] = name
+	}
+}
+
+// recordDeliveries records the deliveries of the report with the provided
+// ID, if ctx records receipts.
+func recordDeliveries(ctx context.Context, id string, deliveries []Delivery) {
+	rc, ok := ctx.Value(receiptsKey{}).(*receipts)
+	if !ok {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if _, ok := rc.deliveries[id]; !ok {
+		rc.deliveries[id] = deliveries
+	}
+}
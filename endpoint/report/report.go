@@ -2,12 +2,38 @@ package report
 
 import (
 	"encoding/json"
+	"time"
 )
 
-// Report represents a report with an ID and data.
+const (
+	// deadlineMetadataKey is the key of the deadline of a report in the
+	// metadata of messages.
+	deadlineMetadataKey = "deadline"
+)
+
+// Report represents a report with an ID and data. A report with a
+// claim check has its data stored separately, and ClaimCheck is the
+// name of the stored data. Data that was streamed into storage is stored
+// in ClaimCheckParts parts, and Size is the size of the stored data. Type,
+// Tenant, Priority and Labels are attributes reports can be routed on, and
+// Route is the name of the route the report was sent with. RequestID is the ID of the request the report
+// was received with, Deadline is the time after which the report should
+// no longer be processed, and TraceContext carries the W3C trace context
+// (traceparent and tracestate) of the report to the worker.
 type Report struct {
-	ID   string
-	Data []byte
+	ID              string
+	Data            []byte
+	ClaimCheck      string            `json:",omitempty"`
+	ClaimCheckParts int               `json:",omitempty"`
+	Size            int               `json:",omitempty"`
+	Type            string            `json:",omitempty"`
+	Tenant          string            `json:",omitempty"`
+	Priority        int               `json:",omitempty"`
+	Labels          map[string]string `json:",omitempty"`
+	Route           string            `json:",omitempty"`
+	RequestID       string            `json:",omitempty"`
+	Deadline        *time.Time        `json:",omitempty"`
+	TraceContext    map[string]string `json:",omitempty"`
 }
 
 // NewReport creates a new Report.
@@ -18,6 +44,15 @@ func NewReport(id string, data []byte) Report {
 	}
 }
 
+// DataSize returns the size in bytes of the data of the report, also if
+// it is stored with a claim check.
+func (r Report) DataSize() int {
+	if len(r.ClaimCheck) > 0 {
+		return r.Size
+	}
+	return len(r.Data)
+}
+
 // JSON returns a JSON representation of a Report.
 func (r Report) JSON() []byte {
 	b, _ := json.Marshal(r)
@@ -2,18 +2,61 @@ package report
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	dapr "github.com/dapr/go-sdk/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
-	defaultReporterName    = "reports"
-	defaultReporterTimeout = time.Second * 10
+	defaultReporterName        = "reports"
+	defaultReporterTimeout     = time.Second * 10
+	defaultReporterConcurrency = 10
 )
 
-// client is the interface that wraps around method InvokeOutputBinding and PublishEvent.
+var (
+	// ErrUnavailable is returned when the DAPR sidecar or the component
+	// used by a reporter cannot be reached.
+	ErrUnavailable = errors.New("reporter unavailable")
+	// ErrTimeout is returned when the timeout of a reporter expires.
+	ErrTimeout = errors.New("reporter timeout")
+)
+
+// client is the interface that wraps around methods InvokeBinding,
+// InvokeOutputBinding, PublishEvent and PublishEvents.
 type client interface {
+	InvokeBinding(ctx context.Context, in *dapr.InvokeBindingRequest) (*dapr.BindingEvent, error)
 	InvokeOutputBinding(ctx context.Context, in *dapr.InvokeBindingRequest) error
 	PublishEvent(ctx context.Context, pubsubName, topic string, data any, options ...dapr.PublishEventOption) error
+	PublishEvents(ctx context.Context, pubsubName, topic string, events []any, options ...dapr.PublishEventsOption) dapr.PublishEventsResponse
+}
+
+// NotSent returns true if the provided error from running a report means
+// that the report was definitely not sent. A timeout or a cancelled
+// context may have happened after the report was sent, and it is then
+// unknown if the report was sent.
+func NotSent(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, ErrTimeout) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// classifyError wraps the provided error with ErrTimeout or ErrUnavailable
+// based on its gRPC status code.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := status.Code(err)
+	if errors.Is(err, context.DeadlineExceeded) || code == codes.DeadlineExceeded {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	if code == codes.Unavailable {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
 }
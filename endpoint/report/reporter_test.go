@@ -2,12 +2,25 @@ package report
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"testing"
 
 	dapr "github.com/dapr/go-sdk/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type mockClient struct {
-	err error
+	err    error
+	failed map[string]bool
+}
+
+func (c *mockClient) InvokeBinding(ctx context.Context, in *dapr.InvokeBindingRequest) (*dapr.BindingEvent, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dapr.BindingEvent{}, nil
 }
 
 func (c *mockClient) InvokeOutputBinding(ctx context.Context, in *dapr.InvokeBindingRequest) error {
@@ -23,3 +36,69 @@ func (c *mockClient) PublishEvent(ctx context.Context, pubsubName, topic string,
 	}
 	return nil
 }
+
+func (c *mockClient) PublishEvents(ctx context.Context, pubsubName, topic string, events []any, options ...dapr.PublishEventsOption) dapr.PublishEventsResponse {
+	if c.err != nil {
+		return dapr.PublishEventsResponse{Error: c.err, FailedEvents: events}
+	}
+	var failed []any
+	for _, event := range events {
+		e := event.(dapr.PublishEventsEvent)
+		if c.failed[e.EntryID] {
+			failed = append(failed, event)
+		}
+	}
+	if len(failed) > 0 {
+		return dapr.PublishEventsResponse{Error: errors.New("error"), FailedEvents: failed}
+	}
+	return dapr.PublishEventsResponse{}
+}
+
+func TestClassifyError(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input error
+		want  error
+	}{
+		{
+			name:  "Nil",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "Unavailable",
+			input: fmt.Errorf("error invoking output binding: %w", status.Error(codes.Unavailable, "unavailable")),
+			want:  ErrUnavailable,
+		},
+		{
+			name:  "Deadline exceeded",
+			input: fmt.Errorf("error invoking output binding: %w", status.Error(codes.DeadlineExceeded, "deadline exceeded")),
+			want:  ErrTimeout,
+		},
+		{
+			name:  "Context deadline exceeded",
+			input: context.DeadlineExceeded,
+			want:  ErrTimeout,
+		},
+		{
+			name:  "Other",
+			input: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := classifyError(test.input)
+
+			if test.want != nil && !errors.Is(got, test.want) {
+				t.Errorf("classifyError() = unexpected, want: %v, got: %v\n", test.want, got)
+			}
+			if test.want == nil && (errors.Is(got, ErrUnavailable) || errors.Is(got, ErrTimeout)) {
+				t.Errorf("classifyError() = unexpected, want unclassified error, got: %v\n", got)
+			}
+			if !errors.Is(got, test.input) {
+				t.Errorf("classifyError() = unexpected, want wrapped error %v, got: %v\n", test.input, got)
+			}
+		})
+	}
+}
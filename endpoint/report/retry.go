@@ -0,0 +1,105 @@
+package report
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// defaultRetryCodes are the gRPC codes of errors that are retried if
+	// no codes are set.
+	defaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+)
+
+// RetryPolicy contains settings for retries of a reporter. Retries are
+// disabled if MaxAttempts is less than 2. All attempts share the timeout
+// of the reporter, and an attempt is not retried if the backoff would
+// exceed it.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	MaxAttempts int
+	// BaseBackoff is the backoff before the second attempt. The backoff
+	// is doubled for every attempt, up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the fraction (0 to 1) of the backoff that is randomized.
+	Jitter float64
+	// Codes are the gRPC codes of errors that are retried. Defaults to
+	// Unavailable, ResourceExhausted and Aborted.
+	Codes []codes.Code
+	// OnAttempt is called after every attempt. Optional.
+	OnAttempt func(a Attempt)
+}
+
+// Attempt contains the result of an attempt to send one or more reports.
+type Attempt struct {
+	// IDs are the IDs of the reports of the attempt.
+	IDs []string
+	// Number is the number of the attempt, starting at 1.
+	Number int
+	// Err is the error of the attempt, if any.
+	Err error
+	// Retry is true if the attempt is retried after Backoff.
+	Retry   bool
+	Backoff time.Duration
+}
+
+// do calls fn until it succeeds, fails with an error that is not
+// retryable, the attempts are used up or ctx is done. Returns the error
+// of the last attempt.
+func (p RetryPolicy) do(ctx context.Context, ids []string, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		a := Attempt{IDs: ids, Number: attempt, Err: err}
+		if err != nil && attempt < p.MaxAttempts && ctx.Err() == nil && p.retryable(err) {
+			a.Backoff = p.backoff(attempt)
+			deadline, ok := ctx.Deadline()
+			a.Retry = !ok || time.Until(deadline) > a.Backoff
+		}
+		if p.OnAttempt != nil {
+			p.OnAttempt(a)
+		}
+		if !a.Retry {
+			return err
+		}
+
+		timer := time.NewTimer(a.Backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable returns true if the gRPC code of the error is retried.
+func (p RetryPolicy) retryable(err error) bool {
+	retryCodes := p.Codes
+	if len(retryCodes) == 0 {
+		retryCodes = defaultRetryCodes
+	}
+	return slices.Contains(retryCodes, status.Code(err))
+}
+
+// backoff returns the backoff after the provided attempt, with the
+// jitter applied.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		jitter := min(p.Jitter, 1)
+		backoff -= time.Duration(float64(backoff) * jitter * rand.Float64())
+	}
+	return backoff
+}
@@ -0,0 +1,223 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+
+	var tests = []struct {
+		name  string
+		input struct {
+			policy  RetryPolicy
+			timeout time.Duration
+			errs    []error
+		}
+		wantAttempts []bool
+		wantErr      error
+	}{
+		{
+			name: "Success",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy: RetryPolicy{MaxAttempts: 3},
+			},
+			wantAttempts: []bool{false},
+		},
+		{
+			name: "Retried",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy: RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+				errs:   []error{unavailable, unavailable},
+			},
+			wantAttempts: []bool{true, true, false},
+		},
+		{
+			name: "Attempts used up",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy: RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+				errs:   []error{unavailable, unavailable, unavailable},
+			},
+			wantAttempts: []bool{true, false},
+			wantErr:      unavailable,
+		},
+		{
+			name: "Not retryable",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy: RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+				errs:   []error{invalid},
+			},
+			wantAttempts: []bool{false},
+			wantErr:      invalid,
+		},
+		{
+			name: "Retryable with codes",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy: RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, Codes: []codes.Code{codes.InvalidArgument}},
+				errs:   []error{invalid},
+			},
+			wantAttempts: []bool{true, false},
+		},
+		{
+			name: "Backoff exceeds timeout",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				policy:  RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second},
+				timeout: time.Millisecond * 100,
+				errs:    []error{unavailable},
+			},
+			wantAttempts: []bool{false},
+			wantErr:      unavailable,
+		},
+		{
+			name: "Retries disabled",
+			input: struct {
+				policy  RetryPolicy
+				timeout time.Duration
+				errs    []error
+			}{
+				errs: []error{unavailable},
+			},
+			wantAttempts: []bool{false},
+			wantErr:      unavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.input.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.input.timeout)
+				defer cancel()
+			}
+
+			var gotAttempts []bool
+			policy := test.input.policy
+			policy.OnAttempt = func(a Attempt) {
+				if a.Number != len(gotAttempts)+1 {
+					t.Errorf("do() = unexpected attempt number, want: %d, got: %d\n", len(gotAttempts)+1, a.Number)
+				}
+				gotAttempts = append(gotAttempts, a.Retry)
+			}
+
+			var calls int
+			gotErr := policy.do(ctx, []string{"123"}, func(ctx context.Context) error {
+				defer func() { calls++ }()
+				if calls < len(test.input.errs) {
+					return test.input.errs[calls]
+				}
+				return nil
+			})
+
+			if diff := cmp.Diff(test.wantAttempts, gotAttempts); diff != "" {
+				t.Errorf("do() = unexpected attempts, (-want +got):\n%s\n", diff)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("do() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			policy  RetryPolicy
+			attempt int
+		}
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name: "First attempt",
+			input: struct {
+				policy  RetryPolicy
+				attempt int
+			}{
+				policy:  RetryPolicy{BaseBackoff: time.Millisecond * 100, MaxBackoff: time.Second},
+				attempt: 1,
+			},
+			wantMin: time.Millisecond * 100,
+			wantMax: time.Millisecond * 100,
+		},
+		{
+			name: "Third attempt",
+			input: struct {
+				policy  RetryPolicy
+				attempt int
+			}{
+				policy:  RetryPolicy{BaseBackoff: time.Millisecond * 100, MaxBackoff: time.Second},
+				attempt: 3,
+			},
+			wantMin: time.Millisecond * 400,
+			wantMax: time.Millisecond * 400,
+		},
+		{
+			name: "Max backoff",
+			input: struct {
+				policy  RetryPolicy
+				attempt int
+			}{
+				policy:  RetryPolicy{BaseBackoff: time.Millisecond * 100, MaxBackoff: time.Second},
+				attempt: 10,
+			},
+			wantMin: time.Second,
+			wantMax: time.Second,
+		},
+		{
+			name: "With jitter",
+			input: struct {
+				policy  RetryPolicy
+				attempt int
+			}{
+				policy:  RetryPolicy{BaseBackoff: time.Millisecond * 100, MaxBackoff: time.Second, Jitter: 0.5},
+				attempt: 2,
+			},
+			wantMin: time.Millisecond * 100,
+			wantMax: time.Millisecond * 200,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.policy.backoff(test.input.attempt)
+
+			if got < test.wantMin || got > test.wantMax {
+				t.Errorf("backoff() = unexpected result, want between %v and %v, got: %v\n", test.wantMin, test.wantMax, got)
+			}
+		})
+	}
+}
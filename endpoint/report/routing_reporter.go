@@ -0,0 +1,189 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// Match contains the conditions a report must meet to match a route. All
+// set conditions must be met, and a Match without conditions matches every
+// report.
+type Match struct {
+	// Types and Tenants match reports with one of the types or tenants.
+	Types   []string
+	Tenants []string
+	// MinPriority and MaxPriority match reports with a priority in the
+	// range, inclusive.
+	MinPriority *int
+	MaxPriority *int
+	// Labels match reports with all of the labels and values.
+	Labels map[string]string
+	// MinSize and MaxSize match reports with a data size in bytes in the
+	// range, inclusive. 0 is no limit.
+	MinSize int
+	MaxSize int
+}
+
+// Matches returns true if the report meets the conditions.
+func (m Match) Matches(report Report) bool {
+	if len(m.Types) > 0 && !slices.Contains(m.Types, report.Type) {
+		return false
+	}
+	if len(m.Tenants) > 0 && !slices.Contains(m.Tenants, report.Tenant) {
+		return false
+	}
+	if m.MinPriority != nil && report.Priority < *m.MinPriority {
+		return false
+	}
+	if m.MaxPriority != nil && report.Priority > *m.MaxPriority {
+		return false
+	}
+	for k, v := range m.Labels {
+		if lv, ok := report.Labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	if m.MinSize > 0 && report.DataSize() < m.MinSize {
+		return false
+	}
+	if m.MaxSize > 0 && report.DataSize() > m.MaxSize {
+		return false
+	}
+	return true
+}
+
+// Route is a named route to a reporter for the reports that match it.
+type Route struct {
+	Name     string
+	Match    Match
+	Reporter NamedReporter
+}
+
+// RoutingReporter is a reporter that runs every report with the reporter
+// of the first route it matches, in order, or with the reporter of the
+// default route if it matches none. The name of the route is set as the
+// Route of the report, and the reporter is recorded as the reporter that
+// accepted the report (see WithReceipts).
+type RoutingReporter struct {
+	routes       []Route
+	defaultRoute Route
+	onRoute      func(report Report)
+}
+
+// RoutingReporterOptions contains settings for a RoutingReporter.
+type RoutingReporterOptions struct {
+	// OnRoute is called with every report when its route is chosen,
+	// before it is run. Optional.
+	OnRoute func(report Report)
+}
+
+// RoutingReporterOption is a function that sets *RoutingReporterOptions.
+type RoutingReporterOption func(o *RoutingReporterOptions)
+
+// NewRoutingReporter creates a new *RoutingReporter with the provided
+// routes and default route, and options. Route names must be unique.
+func NewRoutingReporter(routes []Route, defaultRoute Route, options ...RoutingReporterOption) (*RoutingReporter, error) {
+	names := make(map[string]struct{}, len(routes)+1)
+	for _, route := range append(slices.Clone(routes), defaultRoute) {
+		if route.Reporter.Reporter == nil {
+			return nil, errors.New("reporter of route " + route.Name + " is nil")
+		}
+		if _, ok := names[route.Name]; ok {
+			return nil, errors.New("route " + route.Name + " is not unique")
+		}
+		names[route.Name] = struct{}{}
+	}
+
+	opts := RoutingReporterOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &RoutingReporter{
+		routes:       routes,
+		defaultRoute: defaultRoute,
+		onRoute:      opts.OnRoute,
+	}, nil
+}
+
+// Run a report routine with the reporter of the route of the report.
+func (r RoutingReporter) Run(ctx context.Context, report Report) error {
+	route := r.route(&report)
+	if err := route.Reporter.Reporter.Run(ctx, report); err != nil {
+		return err
+	}
+	recordAccepted(ctx, report.ID, route.Reporter.Name)
+	return nil
+}
+
+// RunBatch runs a report routine for every report. The reports are grouped
+// by route, and the groups are run concurrently. Reporters that are
+// BatchReporters run their group as a batch. Returns one error per report,
+// in the same order as the reports.
+func (r RoutingReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	type group struct {
+		route   Route
+		indexes []int
+		reports []Report
+	}
+
+	var groups []*group
+	byName := map[string]*group{}
+	for i, report := range reports {
+		route := r.route(&report)
+		g, ok := byName[route.Name]
+		if !ok {
+			g = &group{route: route}
+			byName[route.Name] = g
+			groups = append(groups, g)
+		}
+		g.indexes = append(g.indexes, i)
+		g.reports = append(g.reports, report)
+	}
+
+	errs := make([]error, len(reports))
+	var wg sync.WaitGroup
+	for _, g := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nr := g.route.Reporter
+			var groupErrs []error
+			if br, ok := nr.Reporter.(BatchReporter); ok {
+				groupErrs = br.RunBatch(ctx, g.reports)
+			} else {
+				groupErrs = make([]error, len(g.reports))
+				for j, report := range g.reports {
+					groupErrs[j] = nr.Reporter.Run(ctx, report)
+				}
+			}
+			for j, i := range g.indexes {
+				errs[i] = groupErrs[j]
+				if errs[i] == nil {
+					recordAccepted(ctx, reports[i].ID, nr.Name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// route returns the route of the report and sets it as the Route of the
+// report.
+func (r RoutingReporter) route(report *Report) Route {
+	route := r.defaultRoute
+	for _, rt := range r.routes {
+		if rt.Match.Matches(*report) {
+			route = rt
+			break
+		}
+	}
+	report.Route = route.Name
+	if r.onRoute != nil {
+		r.onRoute(*report)
+	}
+	return route
+}
@@ -0,0 +1,291 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatch_Matches(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			match  Match
+			report Report
+		}
+		want bool
+	}{
+		{
+			name: "Without conditions",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{},
+				report: Report{ID: "123"},
+			},
+			want: true,
+		},
+		{
+			name: "Type and tenant",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{Types: []string{"incident", "alert"}, Tenants: []string{"tenant-a"}},
+				report: Report{Type: "alert", Tenant: "tenant-a"},
+			},
+			want: true,
+		},
+		{
+			name: "Type does not match",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{Types: []string{"incident"}},
+				report: Report{Type: "alert"},
+			},
+			want: false,
+		},
+		{
+			name: "Priority in range",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{MinPriority: toPtr(5), MaxPriority: toPtr(7)},
+				report: Report{Priority: 7},
+			},
+			want: true,
+		},
+		{
+			name: "Priority below range",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{MinPriority: toPtr(5)},
+				report: Report{Priority: 4},
+			},
+			want: false,
+		},
+		{
+			name: "Labels",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{Labels: map[string]string{"region": "eu"}},
+				report: Report{Labels: map[string]string{"region": "eu", "team": "a"}},
+			},
+			want: true,
+		},
+		{
+			name: "Labels do not match",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{Labels: map[string]string{"region": "eu", "team": "b"}},
+				report: Report{Labels: map[string]string{"region": "eu", "team": "a"}},
+			},
+			want: false,
+		},
+		{
+			name: "Size in range",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{MinSize: 2, MaxSize: 4},
+				report: Report{Data: []byte(`"ab"`)},
+			},
+			want: true,
+		},
+		{
+			name: "Size above range",
+			input: struct {
+				match  Match
+				report Report
+			}{
+				match:  Match{MaxSize: 4},
+				report: Report{Data: []byte(`{"a":1}`)},
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.match.Matches(test.input.report)
+
+			if test.want != got {
+				t.Errorf("Matches() = unexpected result, want: %v, got: %v\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestNewRoutingReporter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			routes       []Route
+			defaultRoute Route
+		}
+		wantErr bool
+	}{
+		{
+			name: "With routes",
+			input: struct {
+				routes       []Route
+				defaultRoute Route
+			}{
+				routes:       []Route{{Name: "alerts", Reporter: NamedReporter{Name: "alerts", Reporter: mockReporter{}}}},
+				defaultRoute: Route{Name: "default", Reporter: NamedReporter{Name: "primary", Reporter: mockReporter{}}},
+			},
+		},
+		{
+			name: "With nil default reporter",
+			input: struct {
+				routes       []Route
+				defaultRoute Route
+			}{
+				routes:       []Route{{Name: "alerts", Reporter: NamedReporter{Name: "alerts", Reporter: mockReporter{}}}},
+				defaultRoute: Route{Name: "default"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "With duplicate route names",
+			input: struct {
+				routes       []Route
+				defaultRoute Route
+			}{
+				routes:       []Route{{Name: "default", Reporter: NamedReporter{Name: "alerts", Reporter: mockReporter{}}}},
+				defaultRoute: Route{Name: "default", Reporter: NamedReporter{Name: "primary", Reporter: mockReporter{}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := NewRoutingReporter(test.input.routes, test.input.defaultRoute)
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("NewRoutingReporter() = unexpected error, want error: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestRoutingReporter_Run(t *testing.T) {
+	var tests = []struct {
+		name         string
+		input        Report
+		wantRoute    string
+		wantAccepted string
+		wantErr      error
+	}{
+		{
+			name:         "First matching route",
+			input:        Report{ID: "123", Type: "alert", Priority: 9},
+			wantRoute:    "urgent",
+			wantAccepted: "urgent-queue",
+		},
+		{
+			name:         "Second matching route",
+			input:        Report{ID: "123", Type: "alert", Priority: 1},
+			wantRoute:    "alerts",
+			wantAccepted: "alerts-topic",
+		},
+		{
+			name:         "Default route",
+			input:        Report{ID: "123", Type: "incident"},
+			wantRoute:    "default",
+			wantAccepted: "primary",
+		},
+		{
+			name:      "Error",
+			input:     Report{ID: "123", Tenant: "failing"},
+			wantRoute: "failing",
+			wantErr:   ErrUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			urgent, alerts, failing, primary := &mockRecordingReporter{}, &mockRecordingReporter{}, &mockRecordingReporter{err: ErrUnavailable}, &mockRecordingReporter{}
+			var gotRoutes []string
+			r, _ := NewRoutingReporter([]Route{
+				{Name: "urgent", Match: Match{MinPriority: toPtr(8)}, Reporter: NamedReporter{Name: "urgent-queue", Reporter: urgent}},
+				{Name: "alerts", Match: Match{Types: []string{"alert"}}, Reporter: NamedReporter{Name: "alerts-topic", Reporter: alerts}},
+				{Name: "failing", Match: Match{Tenants: []string{"failing"}}, Reporter: NamedReporter{Name: "failing", Reporter: failing}},
+			}, Route{Name: "default", Reporter: NamedReporter{Name: "primary", Reporter: primary}}, func(o *RoutingReporterOptions) {
+				o.OnRoute = func(report Report) {
+					gotRoutes = append(gotRoutes, report.Route)
+				}
+			})
+			ctx := WithReceipts(context.Background())
+
+			gotErr := r.Run(ctx, test.input)
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Run() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if got := AcceptedBy(ctx, "123"); got != test.wantAccepted {
+				t.Errorf("Run() = unexpected accepted by, want: %s, got: %s\n", test.wantAccepted, got)
+			}
+			if diff := cmp.Diff([]string{test.wantRoute}, gotRoutes); diff != "" {
+				t.Errorf("OnRoute = unexpected routes, (-want +got):\n%s\n", diff)
+			}
+
+			var got []Report
+			for _, re := range []*mockRecordingReporter{urgent, alerts, failing, primary} {
+				got = append(got, re.reports...)
+			}
+			want := test.input
+			want.Route = test.wantRoute
+			if diff := cmp.Diff([]Report{want}, got); diff != "" {
+				t.Errorf("Run() = unexpected reports, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRoutingReporter_RunBatch(t *testing.T) {
+	alerts := &mockRecordingReporter{}
+	r, _ := NewRoutingReporter([]Route{
+		{Name: "alerts", Match: Match{Types: []string{"alert"}}, Reporter: NamedReporter{Name: "alerts-topic", Reporter: alerts}},
+	}, Route{Name: "default", Reporter: NamedReporter{Name: "primary", Reporter: mockBatchReporter{errs: map[string]error{"789": ErrUnavailable}}}})
+	ctx := WithReceipts(context.Background())
+	reports := []Report{{ID: "123", Type: "alert"}, {ID: "456"}, {ID: "789"}, {ID: "012", Type: "alert"}}
+
+	errs := r.RunBatch(ctx, reports)
+
+	if diff := cmp.Diff([]error{nil, nil, ErrUnavailable, nil}, errs, cmp.Comparer(func(x, y error) bool { return errors.Is(x, y) })); diff != "" {
+		t.Errorf("RunBatch() = unexpected errors, (-want +got):\n%s\n", diff)
+	}
+
+	got := map[string]string{}
+	for _, re := range reports {
+		got[re.ID] = AcceptedBy(ctx, re.ID)
+	}
+	want := map[string]string{"123": "alerts-topic", "456": "primary", "789": "", "012": "alerts-topic"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RunBatch() = unexpected accepted by, (-want +got):\n%s\n", diff)
+	}
+
+	wantReports := []Report{{ID: "123", Type: "alert", Route: "alerts"}, {ID: "012", Type: "alert", Route: "alerts"}}
+	if diff := cmp.Diff(wantReports, alerts.reports); diff != "" {
+		t.Errorf("RunBatch() = unexpected reports for alerts reporter, (-want +got):\n%s\n", diff)
+	}
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
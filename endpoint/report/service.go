@@ -1,38 +1,190 @@
 package report
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+var (
+	// ErrReportExists is returned when a report with the same ID
+	// has already been created.
+	ErrReportExists = errors.New("report already exists")
 )
 
 // Reporter is the interface that wraps around method Run.
 type Reporter interface {
-	Run(report Report) error
+	Run(ctx context.Context, report Report) error
+}
+
+// BatchReporter is the interface that wraps around method RunBatch.
+type BatchReporter interface {
+	RunBatch(ctx context.Context, reports []Report) []error
 }
 
-// Service is the interface that wraps around method Create.
+// Service is the interface that wraps around methods Create, CreateBatch
+// and Status.
 type Service interface {
-	Create(report Report) error
+	Create(ctx context.Context, report Report) error
+	CreateBatch(ctx context.Context, reports []Report) []error
+	Status(id string) (Status, error)
 }
 
 // service is service containing settings and a reporter.
 type service struct {
-	r Reporter
+	r        Reporter
+	statuses *statuses
 }
 
+// ServiceOptions contains options for a service.
+type ServiceOptions struct {
+	// Store is the state store used to track the status of reports.
+	// Status tracking, and with it the check for reports with the same
+	// ID, is disabled if nil.
+	Store state.Store
+	// Retention is the time statuses are kept. Statuses are kept until
+	// removed if 0.
+	Retention time.Duration
+}
+
+// ServiceOption is a function that sets *ServiceOptions.
+type ServiceOption func(o *ServiceOptions)
+
 // NewService returns a new *service.
-func NewService(r Reporter) (*service, error) {
+func NewService(r Reporter, options ...ServiceOption) (*service, error) {
 	if r == nil {
 		return nil, errors.New("error creating service: reporter is nil")
 	}
-	return &service{
+
+	opts := ServiceOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	s := &service{
 		r: r,
-	}, nil
+	}
+	if opts.Store != nil {
+		s.statuses = &statuses{store: opts.Store, retention: opts.Retention}
+	}
+
+	return s, nil
 }
 
-// Create a report.
-func (s service) Create(report Report) error {
+// Create a report. If status tracking is enabled the report is
+// recorded as accepted before it is run, and as queued (with the
+// reporter that accepted it) or failed after, with the deliveries of the
+// report if it was sent with several reporters. A report with the same ID
+// as an existing report that has not failed returns ErrReportExists.
+// Without status tracking IDs are not checked, and a report with the same
+// ID as an existing report is sent again.
+func (s service) Create(ctx context.Context, report Report) error {
 	if s.r == nil {
 		return errors.New("error creating report: reporter is nil")
 	}
-	return s.r.Run(report)
+	if s.statuses == nil {
+		return s.r.Run(ctx, report)
+	}
+	ctx = WithReceipts(ctx)
+
+	if err := s.accept(report); err != nil {
+		return err
+	}
+	if err := s.r.Run(ctx, report); err != nil {
+		// The error from the reporter takes precedence over an error
+		// setting the failed state.
+		_ = s.statuses.fail(report.ID, err.Error(), Deliveries(ctx, report.ID))
+		return err
+	}
+	// The report has been sent at this point, an error setting the
+	// status should not result in the report being sent again.
+	_ = s.statuses.queue(report.ID, AcceptedBy(ctx, report.ID), Deliveries(ctx, report.ID))
+	return nil
+}
+
+// CreateBatch creates a batch of reports. Returns one error per report, in
+// the same order as the reports. If the reporter is a BatchReporter the
+// reports are run as a batch, otherwise they are run one by one. Status
+// tracking works as with Create.
+func (s service) CreateBatch(ctx context.Context, reports []Report) []error {
+	errs := make([]error, len(reports))
+	if s.r == nil {
+		for i := range errs {
+			errs[i] = errors.New("error creating report: reporter is nil")
+		}
+		return errs
+	}
+
+	pending := make([]int, 0, len(reports))
+	for i, report := range reports {
+		if s.statuses != nil {
+			if err := s.accept(report); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return errs
+	}
+
+	batch := make([]Report, len(pending))
+	for j, i := range pending {
+		batch[j] = reports[i]
+	}
+	if s.statuses != nil {
+		ctx = WithReceipts(ctx)
+	}
+
+	var batchErrs []error
+	if br, ok := s.r.(BatchReporter); ok {
+		batchErrs = br.RunBatch(ctx, batch)
+	} else {
+		batchErrs = make([]error, len(batch))
+		for j, report := range batch {
+			batchErrs[j] = s.r.Run(ctx, report)
+		}
+	}
+
+	for j, i := range pending {
+		errs[i] = batchErrs[j]
+		if s.statuses == nil {
+			continue
+		}
+		if errs[i] != nil {
+			_ = s.statuses.fail(reports[i].ID, errs[i].Error(), Deliveries(ctx, reports[i].ID))
+		} else {
+			_ = s.statuses.queue(reports[i].ID, AcceptedBy(ctx, reports[i].ID), Deliveries(ctx, reports[i].ID))
+		}
+	}
+	return errs
+}
+
+// accept claims the ID of the provided report for its tenant and records
+// the report as accepted. Returns ErrReportExists if a report with the ID
+// exists.
+func (s service) accept(report Report) error {
+	if err := s.statuses.claim(report.ID, report.Tenant); err != nil {
+		if errors.Is(err, ErrReportExists) {
+			return err
+		}
+		return fmt.Errorf("error creating report: claiming ID: %w", classifyError(err))
+	}
+	return nil
+}
+
+// Status returns the status of a report.
+func (s service) Status(id string) (Status, error) {
+	if s.statuses == nil {
+		return Status{}, ErrStatusDisabled
+	}
+	status, err := s.statuses.get(id)
+	if err != nil && !errors.Is(err, ErrStatusNotFound) {
+		return Status{}, classifyError(err)
+	}
+	return status, err
 }
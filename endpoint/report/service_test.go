@@ -1,7 +1,9 @@
 package report
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -108,7 +110,7 @@ func TestService_Create(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			s := &service{r: test.input.r}
 
-			gotErr := s.Create(NewReport(test.input.id, test.input.data))
+			gotErr := s.Create(context.Background(), NewReport(test.input.id, test.input.data))
 
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("Create = want error, got nil\n")
@@ -118,13 +120,279 @@ func TestService_Create(t *testing.T) {
 
 }
 
+func TestService_Create_Status(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			r     Reporter
+			store *mockStore
+		}
+		wantState    State
+		wantReporter string
+		wantErr      error
+	}{
+		{
+			name: "Queued",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r:     mockReporter{},
+				store: &mockStore{},
+			},
+			wantState: StateQueued,
+		},
+		{
+			name: "Queued with chain",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r: &ChainReporter{reporters: []NamedReporter{
+					{Name: "primary", Reporter: mockReporter{err: errors.New("error")}},
+					{Name: "fallback", Reporter: mockReporter{}},
+				}},
+				store: &mockStore{},
+			},
+			wantState:    StateQueued,
+			wantReporter: "fallback",
+		},
+		{
+			name: "Failed",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r:     mockReporter{err: errors.New("error")},
+				store: &mockStore{},
+			},
+			wantState: StateFailed,
+			wantErr:   errors.New("error"),
+		},
+		{
+			name: "With store error",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r:     mockReporter{},
+				store: &mockStore{err: errors.New("error")},
+			},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, _ := NewService(test.input.r, func(o *ServiceOptions) {
+				o.Store = test.input.store
+			})
+
+			gotErr := s.Create(context.Background(), NewReport("id", []byte("data")))
+
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("Create = want error, got nil\n")
+			}
+
+			if len(test.wantState) == 0 {
+				return
+			}
+
+			got, err := s.Status("id")
+			if err != nil {
+				t.Fatalf("Status = unexpected error: %v\n", err)
+			}
+			if got.State != test.wantState {
+				t.Errorf("Status = unexpected state, want: %s, got: %s\n", test.wantState, got.State)
+			}
+			if got.Reporter != test.wantReporter {
+				t.Errorf("Status = unexpected reporter, want: %s, got: %s\n", test.wantReporter, got.Reporter)
+			}
+		})
+	}
+}
+
+func TestService_Create_Exists(t *testing.T) {
+	s, _ := NewService(mockReporter{}, func(o *ServiceOptions) {
+		o.Store = &mockStore{}
+	})
+
+	if err := s.Create(context.Background(), NewReport("id", []byte("data"))); err != nil {
+		t.Fatalf("Create = unexpected error: %v\n", err)
+	}
+	if err := s.Create(context.Background(), NewReport("id", []byte("data"))); !errors.Is(err, ErrReportExists) {
+		t.Errorf("Create = unexpected result, want: %v, got: %v\n", ErrReportExists, err)
+	}
+
+	s.r = mockReporter{err: errors.New("error")}
+	if err := s.Create(context.Background(), NewReport("failed", []byte("data"))); err == nil {
+		t.Fatalf("Create = want error, got nil\n")
+	}
+	s.r = mockReporter{}
+	other := NewReport("failed", []byte("data"))
+	other.Tenant = "other"
+	if err := s.Create(context.Background(), other); !errors.Is(err, ErrReportExists) {
+		t.Errorf("Create = unexpected result on retry by another tenant, want: %v, got: %v\n", ErrReportExists, err)
+	}
+	if err := s.Create(context.Background(), NewReport("failed", []byte("data"))); err != nil {
+		t.Errorf("Create = unexpected error on retry after failure: %v\n", err)
+	}
+	got, _ := s.Status("failed")
+	if got.State != StateQueued || len(got.Reason) > 0 || len(got.Timestamps) != 2 {
+		t.Errorf("Create = unexpected status on retry after failure, got: %+v\n", got)
+	}
+}
+
+func TestService_Create_Concurrent(t *testing.T) {
+	s, _ := NewService(mockReporter{}, func(o *ServiceOptions) {
+		o.Store = &mockStore{}
+	})
+
+	n := 10
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Create(context.Background(), NewReport("id", []byte("data")))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var created, exists int
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrReportExists):
+			exists++
+		default:
+			t.Errorf("Create = unexpected error: %v\n", err)
+		}
+	}
+	if created != 1 || exists != n-1 {
+		t.Errorf("Create = unexpected result, want 1 created and %d existing, got: %d and %d\n", n-1, created, exists)
+	}
+}
+
+func TestService_CreateBatch(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			r     Reporter
+			store *mockStore
+		}
+		wantErrs []error
+	}{
+		{
+			name: "With reporter",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r: mockReporter{},
+			},
+			wantErrs: []error{nil, nil, nil},
+		},
+		{
+			name: "With batch reporter",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r: mockBatchReporter{errs: map[string]error{"2": errors.New("error")}},
+			},
+			wantErrs: []error{nil, errors.New("error"), nil},
+		},
+		{
+			name: "With existing report",
+			input: struct {
+				r     Reporter
+				store *mockStore
+			}{
+				r: mockBatchReporter{},
+				store: &mockStore{
+					data: map[string][]byte{
+						statusKeyPrefix + "1": Status{ID: "1", State: StateQueued}.JSON(),
+					},
+				},
+			},
+			wantErrs: []error{ErrReportExists, nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, _ := NewService(test.input.r, func(o *ServiceOptions) {
+				if test.input.store != nil {
+					o.Store = test.input.store
+				}
+			})
+
+			gotErrs := s.CreateBatch(context.Background(), []Report{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+
+			if len(gotErrs) != len(test.wantErrs) {
+				t.Fatalf("CreateBatch = unexpected, want %d errors, got %d\n", len(test.wantErrs), len(gotErrs))
+			}
+			for i, err := range gotErrs {
+				if (test.wantErrs[i] == nil) != (err == nil) {
+					t.Errorf("CreateBatch = unexpected error for report %d, want: %v, got: %v\n", i, test.wantErrs[i], err)
+				}
+				if errors.Is(test.wantErrs[i], ErrReportExists) && !errors.Is(err, ErrReportExists) {
+					t.Errorf("CreateBatch = unexpected error for report %d, want: %v, got: %v\n", i, test.wantErrs[i], err)
+				}
+			}
+
+			if test.input.store == nil {
+				return
+			}
+			status, _ := s.Status("2")
+			if status.State != StateQueued {
+				t.Errorf("CreateBatch = unexpected state, want: %s, got: %s\n", StateQueued, status.State)
+			}
+		})
+	}
+}
+
+func TestService_Status(t *testing.T) {
+	s, _ := NewService(mockReporter{})
+	if _, err := s.Status("id"); !errors.Is(err, ErrStatusDisabled) {
+		t.Errorf("Status = unexpected, want: %v, got: %v\n", ErrStatusDisabled, err)
+	}
+
+	s, _ = NewService(mockReporter{}, func(o *ServiceOptions) {
+		o.Store = &mockStore{}
+	})
+	if _, err := s.Status("id"); !errors.Is(err, ErrStatusNotFound) {
+		t.Errorf("Status = unexpected, want: %v, got: %v\n", ErrStatusNotFound, err)
+	}
+}
+
 type mockReporter struct {
 	err error
 }
 
-func (r mockReporter) Run(report Report) error {
+func (r mockReporter) Run(ctx context.Context, report Report) error {
 	if r.err != nil {
 		return r.err
 	}
 	return nil
 }
+
+type mockBatchReporter struct {
+	errs map[string]error
+}
+
+func (r mockBatchReporter) Run(ctx context.Context, report Report) error {
+	return r.errs[report.ID]
+}
+
+func (r mockBatchReporter) RunBatch(ctx context.Context, reports []Report) []error {
+	errs := make([]error, len(reports))
+	for i, report := range reports {
+		errs[i] = r.errs[report.ID]
+	}
+	return errs
+}
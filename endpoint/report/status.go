@@ -0,0 +1,200 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+const (
+	statusKeyPrefix = "status:"
+)
+
+var (
+	// ErrStatusNotFound is returned when no status exists for a report.
+	ErrStatusNotFound = errors.New("status not found")
+	// ErrStatusDisabled is returned when status tracking is not enabled.
+	ErrStatusDisabled = errors.New("status tracking is not enabled")
+)
+
+// State is the lifecycle state of a report.
+type State string
+
+const (
+	StateAccepted   State = "accepted"
+	StateQueued     State = "queued"
+	StateProcessing State = "processing"
+	StateStored     State = "stored"
+	StateFailed     State = "failed"
+)
+
+// stateOrder contains the order of the lifecycle states. Used to prevent
+// late updates from moving a status backwards.
+var stateOrder = map[State]int{
+	StateAccepted:   1,
+	StateQueued:     2,
+	StateProcessing: 3,
+	StateStored:     4,
+}
+
+// Status represents the lifecycle status of a report. Tenant is the client
+// that sent the report, and only that client can get the status. Reporter
+// is the name of the reporter that accepted the report, if it was sent
+// with one of several reporters, and Deliveries are the results of the
+// reporters if it was sent with several reporters.
+type Status struct {
+	ID         string              `json:"id"`
+	Tenant     string              `json:"tenant,omitempty"`
+	State      State               `json:"state"`
+	Reason     string              `json:"reason,omitempty"`
+	Reporter   string              `json:"reporter,omitempty"`
+	Deliveries []Delivery          `json:"deliveries,omitempty"`
+	Timestamps map[State]time.Time `json:"timestamps"`
+	Updated    time.Time           `json:"updated"`
+}
+
+// Set the state of the status and record the time it was reached. A state
+// that comes before the current state is recorded, but does not replace
+// the current state. A failed state always replaces the current state,
+// and is only replaced by a state that comes after every state reached
+// before it, so that late updates do not hide a failure.
+func (s *Status) Set(state State, reason string, t time.Time) {
+	reached := s.reached()
+	if s.Timestamps == nil {
+		s.Timestamps = make(map[State]time.Time)
+	}
+	s.Timestamps[state] = t
+	s.Updated = t
+
+	switch {
+	case state == StateFailed:
+	case s.State == StateFailed && stateOrder[state] <= reached:
+		return
+	case s.State != StateFailed && stateOrder[state] < stateOrder[s.State]:
+		return
+	}
+	s.State = state
+	if state == StateFailed {
+		s.Reason = reason
+	} else {
+		s.Reason = ""
+	}
+}
+
+// Retry starts the status over as accepted, for a report that is sent
+// again after it failed.
+func (s *Status) Retry(t time.Time) {
+	*s = Status{ID: s.ID, Tenant: s.Tenant}
+	s.Set(StateAccepted, "", t)
+}
+
+// reached returns the order of the latest state that has been reached.
+func (s Status) reached() int {
+	var order int
+	for state := range s.Timestamps {
+		order = max(order, stateOrder[state])
+	}
+	return order
+}
+
+// JSON returns a JSON representation of a Status.
+func (s Status) JSON() []byte {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// statuses handles the persistence of report statuses in a state store.
+// Statuses expire after retention, if set.
+type statuses struct {
+	store     state.Store
+	retention time.Duration
+}
+
+// get the status for the report with the provided ID.
+func (s statuses) get(id string) (Status, error) {
+	b, err := s.store.Get(statusKeyPrefix + id)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return Status{}, ErrStatusNotFound
+		}
+		return Status{}, err
+	}
+	var status Status
+	if err := json.Unmarshal(b, &status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+// set the state for the report with the provided ID.
+func (s statuses) set(id string, st State, reason string) error {
+	return s.update(id, func(status *Status) {
+		status.Set(st, reason, time.Now().UTC())
+	})
+}
+
+// queue sets the queued state for the report with the provided ID,
+// together with the name of the reporter that accepted it and the
+// deliveries, if any.
+func (s statuses) queue(id string, reporter string, deliveries []Delivery) error {
+	return s.update(id, func(status *Status) {
+		status.Set(StateQueued, "", time.Now().UTC())
+		status.Reporter = reporter
+		status.Deliveries = deliveries
+	})
+}
+
+// fail sets the failed state for the report with the provided ID,
+// together with the deliveries, if any.
+func (s statuses) fail(id string, reason string, deliveries []Delivery) error {
+	return s.update(id, func(status *Status) {
+		status.Set(StateFailed, reason, time.Now().UTC())
+		status.Deliveries = deliveries
+	})
+}
+
+// claim records the report with the provided ID as accepted for the
+// provided tenant if it has no status, or if it has failed for the same
+// tenant. The status is created with first-write-wins concurrency, and a
+// failed status is retried with optimistic concurrency, so that only one
+// of several concurrent reports with the same ID is accepted. Returns
+// ErrReportExists if the ID is claimed by another report.
+func (s statuses) claim(id, tenant string) error {
+	status := Status{ID: id, Tenant: tenant}
+	status.Set(StateAccepted, "", time.Now().UTC())
+	err := s.store.Create(statusKeyPrefix+id, status.JSON(), s.retention)
+	if !errors.Is(err, state.ErrExists) {
+		return err
+	}
+	return s.store.Update(statusKeyPrefix+id, s.retention, func(value []byte) ([]byte, error) {
+		var status Status
+		if value != nil {
+			if err := json.Unmarshal(value, &status); err != nil {
+				return nil, err
+			}
+			if status.State != StateFailed || status.Tenant != tenant {
+				return nil, ErrReportExists
+			}
+		}
+		status.ID, status.Tenant = id, tenant
+		status.Retry(time.Now().UTC())
+		return status.JSON(), nil
+	})
+}
+
+// update the status for the report with the provided ID with fn.
+func (s statuses) update(id string, fn func(status *Status)) error {
+	return s.store.Update(statusKeyPrefix+id, s.retention, func(value []byte) ([]byte, error) {
+		var status Status
+		if value != nil {
+			if err := json.Unmarshal(value, &status); err != nil {
+				return nil, err
+			}
+		}
+		status.ID = id
+		fn(&status)
+		return status.JSON(), nil
+	})
+}
@@ -0,0 +1,300 @@
+package report
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatus_Set(t *testing.T) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+
+	var tests = []struct {
+		name  string
+		input struct {
+			status Status
+			state  State
+			reason string
+		}
+		want Status
+	}{
+		{
+			name: "Empty status",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{ID: "id"},
+				state:  StateAccepted,
+			},
+			want: Status{
+				ID:    "id",
+				State: StateAccepted,
+				Timestamps: map[State]time.Time{
+					StateAccepted: t2,
+				},
+				Updated: t2,
+			},
+		},
+		{
+			name: "Later state",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{
+					ID:         "id",
+					State:      StateQueued,
+					Timestamps: map[State]time.Time{StateQueued: t1},
+					Updated:    t1,
+				},
+				state: StateProcessing,
+			},
+			want: Status{
+				ID:    "id",
+				State: StateProcessing,
+				Timestamps: map[State]time.Time{
+					StateQueued:     t1,
+					StateProcessing: t2,
+				},
+				Updated: t2,
+			},
+		},
+		{
+			name: "Earlier state",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{
+					ID:         "id",
+					State:      StateProcessing,
+					Timestamps: map[State]time.Time{StateProcessing: t1},
+					Updated:    t1,
+				},
+				state: StateQueued,
+			},
+			want: Status{
+				ID:    "id",
+				State: StateProcessing,
+				Timestamps: map[State]time.Time{
+					StateQueued:     t2,
+					StateProcessing: t1,
+				},
+				Updated: t2,
+			},
+		},
+		{
+			name: "Failed state",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{
+					ID:         "id",
+					State:      StateAccepted,
+					Timestamps: map[State]time.Time{StateAccepted: t1},
+					Updated:    t1,
+				},
+				state:  StateFailed,
+				reason: "error",
+			},
+			want: Status{
+				ID:     "id",
+				State:  StateFailed,
+				Reason: "error",
+				Timestamps: map[State]time.Time{
+					StateAccepted: t1,
+					StateFailed:   t2,
+				},
+				Updated: t2,
+			},
+		},
+		{
+			name: "After failed state",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{
+					ID:         "id",
+					State:      StateFailed,
+					Reason:     "error",
+					Timestamps: map[State]time.Time{StateFailed: t1},
+					Updated:    t1,
+				},
+				state: StateProcessing,
+			},
+			want: Status{
+				ID:    "id",
+				State: StateProcessing,
+				Timestamps: map[State]time.Time{
+					StateFailed:     t1,
+					StateProcessing: t2,
+				},
+				Updated: t2,
+			},
+		},
+		{
+			name: "Earlier state after failed state",
+			input: struct {
+				status Status
+				state  State
+				reason string
+			}{
+				status: Status{
+					ID:         "id",
+					State:      StateFailed,
+					Reason:     "error",
+					Timestamps: map[State]time.Time{StateProcessing: t1, StateFailed: t1},
+					Updated:    t1,
+				},
+				state: StateQueued,
+			},
+			want: Status{
+				ID:     "id",
+				State:  StateFailed,
+				Reason: "error",
+				Timestamps: map[State]time.Time{
+					StateQueued:     t2,
+					StateProcessing: t1,
+					StateFailed:     t1,
+				},
+				Updated: t2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.status
+			got.Set(test.input.state, test.input.reason, t2)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Set() = unexpected, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	s := statuses{store: &mockStore{}}
+
+	if _, err := s.get("id"); !errors.Is(err, ErrStatusNotFound) {
+		t.Errorf("get() = unexpected, want: %v, got: %v\n", ErrStatusNotFound, err)
+	}
+
+	if err := s.set("id", StateAccepted, ""); err != nil {
+		t.Fatalf("set() = unexpected error: %v\n", err)
+	}
+	if err := s.fail("id", "unavailable", []Delivery{{Reporter: "queue/reports/create", Error: "unavailable"}}); err != nil {
+		t.Fatalf("fail() = unexpected error: %v\n", err)
+	}
+	if err := s.queue("id", "queue/reports/create", []Delivery{{Reporter: "queue/reports/create"}}); err != nil {
+		t.Fatalf("queue() = unexpected error: %v\n", err)
+	}
+
+	got, err := s.get("id")
+	if err != nil {
+		t.Fatalf("get() = unexpected error: %v\n", err)
+	}
+
+	if got.ID != "id" || got.State != StateQueued || got.Reporter != "queue/reports/create" || len(got.Timestamps) != 3 {
+		t.Errorf("get() = unexpected result, got: %+v\n", got)
+	}
+	if diff := cmp.Diff([]Delivery{{Reporter: "queue/reports/create"}}, got.Deliveries); diff != "" {
+		t.Errorf("get() = unexpected deliveries, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestStatuses_Retention(t *testing.T) {
+	store := &mockStore{}
+	s := statuses{store: store, retention: time.Hour}
+
+	if err := s.set("id", StateAccepted, ""); err != nil {
+		t.Fatalf("set() = unexpected error: %v\n", err)
+	}
+	if store.ttl != time.Hour {
+		t.Errorf("set() = unexpected ttl, want: %v, got: %v\n", time.Hour, store.ttl)
+	}
+}
+
+type mockStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttl  time.Duration
+	err  error
+}
+
+func (s *mockStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	b, ok := s.data[key]
+	if !ok {
+		return nil, state.ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *mockStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Create(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok && s.err == nil {
+		return state.ErrExists
+	}
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	value, err := fn(s.data[key])
+	if err != nil {
+		return err
+	}
+	return s.set(key, value, ttl)
+}
+
+func (s *mockStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mockStore) set(key string, value []byte, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = value
+	s.ttl = ttl
+	return nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	deadlineHeader = "X-Request-Deadline"
+)
+
+// deadlineKey is the context key for the deadline of a request.
+type deadlineKey struct{}
+
+// withDeadline is a middleware that sets the deadline of the request from
+// the X-Request-Deadline header, an RFC 3339 timestamp. The context of the
+// request is cancelled at the deadline, and the deadline is sent along with
+// the reports of the request so that the worker can skip reports that have
+// expired. Requests with a deadline that is not valid or has passed are
+// rejected.
+func withDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(deadlineHeader)
+		if len(header) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		deadline, err := time.Parse(time.RFC3339Nano, header)
+		if err != nil {
+			writeProblem(w, r, newProblem(http.StatusBadRequest, codeInvalidDeadline, deadlineHeader+" must be an RFC 3339 timestamp."))
+			return
+		}
+		if !deadline.After(time.Now()) {
+			writeProblem(w, r, newProblem(http.StatusBadRequest, codeInvalidDeadline, deadlineHeader+" has passed."))
+			return
+		}
+
+		ctx, cancel := context.WithDeadline(r.Context(), deadline)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, deadlineKey{}, deadline)))
+	})
+}
+
+// requestDeadline returns the deadline of the request set by the
+// withDeadline middleware, or nil if the request has no deadline.
+func requestDeadline(r *http.Request) *time.Time {
+	if deadline, ok := r.Context().Value(deadlineKey{}).(time.Time); ok {
+		return &deadline
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithDeadline(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	var tests = []struct {
+		name         string
+		input        string
+		wantStatus   int
+		wantDeadline *time.Time
+	}{
+		{
+			name:       "Without deadline",
+			input:      "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "With deadline",
+			input:        future.Format(time.RFC3339),
+			wantStatus:   http.StatusOK,
+			wantDeadline: &future,
+		},
+		{
+			name:       "With invalid deadline",
+			input:      "tomorrow",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "With passed deadline",
+			input:      time.Now().Add(-time.Minute).Format(time.RFC3339),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotDeadline *time.Time
+			var gotContextDeadline time.Time
+			handler := withDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotDeadline = requestDeadline(r)
+				gotContextDeadline, _ = r.Context().Deadline()
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", nil)
+			if len(test.input) > 0 {
+				req.Header.Set(deadlineHeader, test.input)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.wantStatus {
+				t.Errorf("withDeadline() = unexpected status, want: %d, got: %d\n", test.wantStatus, w.Code)
+			}
+			if test.wantDeadline == nil {
+				if gotDeadline != nil {
+					t.Errorf("withDeadline() = unexpected result, want no deadline, got: %v\n", gotDeadline)
+				}
+				return
+			}
+			if gotDeadline == nil || !gotDeadline.Equal(*test.wantDeadline) {
+				t.Errorf("withDeadline() = unexpected result, want: %v, got: %v\n", test.wantDeadline, gotDeadline)
+			}
+			if !gotContextDeadline.Equal(*test.wantDeadline) {
+				t.Errorf("withDeadline() = unexpected context deadline, want: %v, got: %v\n", test.wantDeadline, gotContextDeadline)
+			}
+		})
+	}
+}
@@ -2,34 +2,401 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
 	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/tracing"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
 )
 
-// reportHandler returns a handler for incoming reports.
+const (
+	// reportedByHeader is the header with the name of the reporter that
+	// accepted a report, when reports are sent with one of several
+	// reporters.
+	reportedByHeader = "X-Reported-By"
+)
+
+// reportHandler returns a handler for incoming reports. A report is sent
+// as JSON, as a raw body or as a multipart/form-data file part. A report
+// without an ID is given a generated ID. If idempotency is enabled a repeated
+// request with the same Idempotency-Key header (defaults to the report ID
+// provided by the client) returns the original response without creating
+// the report again. If usage is tracked a report is rejected once the
+// quota of the client is used up. The reporter that accepted the report
+// is returned in the X-Reported-By header.
 func (s server) reportHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			methodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		mediaType, ok := reportMediaType(r)
+		if !ok {
+			writeProblem(w, r, newProblem(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Type must be application/json, application/octet-stream or multipart/form-data."))
 			return
 		}
 
+		if mediaType == mediaTypeJSON {
+			limitBody(w, r, s.validation.MaxBodySize)
+		} else {
+			limitBody(w, r, s.validation.maxUploadSize())
+		}
 		var re Report
-		if err := json.NewDecoder(r.Body).Decode(&re); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		var up upload
+		var errs []FieldError
+		var err error
+		switch mediaType {
+		case mediaTypeRaw:
+			if re, up, errs, err = s.validation.readRawReport(r, s.uploads); err != nil {
+				writeProblem(w, r, uploadErrorProblem(err, mediaType))
+				return
+			}
+		case mediaTypeMultipart:
+			if re, up, errs, err = s.validation.readMultipartReport(r, s.uploads); err != nil {
+				writeProblem(w, r, uploadErrorProblem(err, mediaType))
+				return
+			}
+		default:
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeProblem(w, r, bodyErrorProblem(err))
+				return
+			}
+			if re, errs, err = s.validation.decodeReport(b); err != nil {
+				writeProblem(w, r, bodyErrorProblem(err))
+				return
+			}
+			up = newUpload(re.Data)
+		}
+		// Uploaded data that was stored is removed unless the report is
+		// sent.
+		var sent bool
+		defer func() {
+			if !sent {
+				up.release(r.Context(), s.uploads)
+			}
+		}()
+		if len(errs) > 0 {
+			writeProblem(w, r, validationProblem(errs))
+			return
+		}
+		// The report as sent by the client identifies the request for
+		// idempotency, regardless of how it was sent.
+		hash := fingerprint(re, up.hash)
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		if len(re.ID) == 0 {
+			if re.ID, err = report.NewID(); err != nil {
+				s.log.Error("Error generating report ID.", "error", err, "request_id", requestID(r))
+				writeProblem(w, r, reportErrorProblem(err))
+				return
+			}
+		} else if len(key) == 0 {
+			key = re.ID
+		}
+		s.log.Info("Incoming report.", "handler", "report", "id", re.ID, "client", clientName(r), "request_id", requestID(r))
+
+		rep := newReport(r, re)
+		up.apply(&rep)
+		if topic, ok := s.forbiddenTopic(r, rep); ok {
+			writeProblem(w, r, topicProblem(topic))
+			return
+		}
+
+		idempotent := s.idempotency != nil && len(key) > 0
+		if idempotent {
+			record, err := s.idempotency.begin(clientName(r), key, hash)
+			if err != nil {
+				if errors.Is(err, errIdempotencyInProgress) {
+					writeProblem(w, r, newProblem(http.StatusConflict, codeRequestInProgress, "A request with the same idempotency key is in progress."))
+					return
+				}
+				if errors.Is(err, errIdempotencyMismatch) {
+					writeProblem(w, r, newProblem(http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "The idempotency key has been used with a different request."))
+					return
+				}
+				s.log.Error("Error checking idempotency key.", "error", err, "request_id", requestID(r))
+				writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeUnavailable, "The idempotency store is unavailable."))
+				return
+			}
+			if record != nil {
+				s.log.Info("Report already sent for creation.", "handler", "report", "id", re.ID, "request_id", requestID(r))
+				if len(record.Location) > 0 {
+					w.Header().Set("Location", record.Location)
+				}
+				for name, value := range record.Header {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set(idempotencyReplayedHeader, "true")
+				w.WriteHeader(record.Status)
+				w.Write(record.Body)
+				return
+			}
+		}
+
+		reserved, ok := s.reserveUsage(w, r, usage.Count{Reports: 1, Bytes: int64(rep.DataSize())})
+		if !ok {
+			if idempotent {
+				if err := s.idempotency.cancel(clientName(r), key); err != nil {
+					s.log.Error("Error removing idempotency key.", "error", err, "request_id", requestID(r))
+				}
+			}
+			return
+		}
+
+		ctx := report.WithReceipts(r.Context())
+		if err := s.reporter.Create(ctx, rep); err != nil {
+			// Uploaded data is kept if the report might have been sent.
+			sent = !report.NotSent(err)
+			if idempotent {
+				if err := s.idempotency.cancel(clientName(r), key); err != nil {
+					s.log.Error("Error removing idempotency key.", "error", err, "request_id", requestID(r))
+				}
+			}
+			s.releaseUsage(r, reserved)
+			if !errors.Is(err, report.ErrReportExists) {
+				s.log.Error("Error creating report.", "error", err, "request_id", requestID(r))
+			}
+			writeProblem(w, r, reportErrorProblem(err))
+			return
+		}
+		sent = true
+		reportedBy := report.AcceptedBy(ctx, re.ID)
+		s.log.Info("Report sent for creation.", "handler", "report", "id", re.ID, "reported_by", reportedBy, "request_id", requestID(r))
+
+		location := "/reports/" + re.ID
+		body := re.JSON()
+		if mediaType != mediaTypeJSON {
+			// Uploaded data is not sent back.
+			body = Report{ID: re.ID}.JSON()
+		}
+		header := map[string]string{"Content-Type": mediaTypeJSON}
+		if len(reportedBy) > 0 {
+			header[reportedByHeader] = reportedBy
+		}
+		if idempotent {
+			if err := s.idempotency.complete(clientName(r), key, hash, idempotencyResponse{
+				status:   http.StatusAccepted,
+				location: location,
+				header:   header,
+				body:     body,
+			}); err != nil {
+				s.log.Error("Error storing idempotency key.", "error", err, "request_id", requestID(r))
+			}
+		}
+
+		w.Header().Set("Location", location)
+		for name, value := range header {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	})
+}
+
+// batchHandler returns a handler for incoming batches of reports. Every report
+// in the batch is handled on its own, and the response contains a result for
+// every report in the same order as the request.
+func (s server) batchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		if !hasJSONContentType(r) {
+			writeProblem(w, r, newProblem(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Type must be application/json."))
+			return
+		}
+
+		limitBody(w, r, s.validation.MaxBatchBodySize)
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeProblem(w, r, bodyErrorProblem(err))
+			return
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(b, &items); err != nil {
+			writeProblem(w, r, bodyErrorProblem(err))
+			return
+		}
+		if len(items) == 0 {
+			writeProblem(w, r, newProblem(http.StatusBadRequest, codeEmptyBatch, "Batch must contain at least one report."))
+			return
+		}
+		if len(items) > s.maxBatchSize {
+			writeProblem(w, r, newProblem(http.StatusRequestEntityTooLarge, codeBatchTooLarge, "Batch must not contain more than "+strconv.Itoa(s.maxBatchSize)+" reports."))
+			return
+		}
+		s.log.Info("Incoming batch.", "handler", "batch", "size", len(items), "client", clientName(r), "request_id", requestID(r))
+
+		results := make([]BatchResult, len(items))
+		reports := make([]report.Report, 0, len(items))
+		indexes := make([]int, 0, len(items))
+		ids := make(map[string]struct{}, len(items))
+		for i, item := range items {
+			re, errs, err := s.validation.decodeReport(item)
+			if err != nil {
+				results[i] = newBatchError("", newProblem(http.StatusBadRequest, codeInvalidReport, "Report is not a valid JSON object."))
+				continue
+			}
+			if len(errs) > 0 {
+				results[i] = newBatchError(re.ID, validationProblem(errs))
+				continue
+			}
+			if len(re.ID) == 0 {
+				id, err := report.NewID()
+				if err != nil {
+					s.log.Error("Error generating report ID.", "error", err, "request_id", requestID(r))
+					results[i] = newBatchError("", reportErrorProblem(err))
+					continue
+				}
+				re.ID = id
+			}
+			if _, ok := ids[re.ID]; ok {
+				results[i] = newBatchError(re.ID, newProblem(http.StatusConflict, codeDuplicateID, "Report ID is used more than once in the batch."))
+				continue
+			}
+			ids[re.ID] = struct{}{}
+
+			rep := newReport(r, re)
+			if topic, ok := s.forbiddenTopic(r, rep); ok {
+				results[i] = newBatchError(re.ID, topicProblem(topic))
+				continue
+			}
+			reports = append(reports, rep)
+			indexes = append(indexes, i)
+		}
+
+		// The quota is checked for the batch as a whole, so that a batch is
+		// either rejected or handled in full. The usage of reports that
+		// fail is released.
+		var count usage.Count
+		for _, re := range reports {
+			count.Reports++
+			count.Bytes += int64(len(re.Data))
+		}
+		reserved, ok := s.reserveUsage(w, r, count)
+		if !ok {
+			return
+		}
+
+		if len(reports) > 0 {
+			var failed usage.Count
+			ctx := report.WithReceipts(r.Context())
+			errs := s.reporter.CreateBatch(ctx, reports)
+			for j, i := range indexes {
+				id := reports[j].ID
+				if errs[j] == nil {
+					results[i] = BatchResult{ID: id, Status: http.StatusAccepted, Location: "/reports/" + id, ReportedBy: report.AcceptedBy(ctx, id), Deliveries: report.Deliveries(ctx, id)}
+					continue
+				}
+				if !errors.Is(errs[j], report.ErrReportExists) {
+					s.log.Error("Error creating report.", "handler", "batch", "id", id, "error", errs[j], "request_id", requestID(r))
+				}
+				results[i] = newBatchError(id, reportErrorProblem(errs[j]))
+				results[i].Deliveries = report.Deliveries(ctx, id)
+				failed.Reports++
+				failed.Bytes += int64(len(reports[j].Data))
+			}
+			reserved.Count = failed
+			s.releaseUsage(r, reserved)
+		}
+		s.log.Info("Batch handled.", "handler", "batch", "size", len(items), "accepted", len(reports), "request_id", requestID(r))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write(BatchResponse{Results: results}.JSON())
+	})
+}
+
+// statusHandler returns a handler for report status lookups. A client
+// only gets the status of its own reports.
+func (s server) statusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/reports/")
+		if len(id) == 0 || strings.Contains(id, "/") {
+			writeProblem(w, r, newProblem(http.StatusNotFound, codeNotFound, "The requested resource was not found."))
 			return
 		}
-		s.log.Info("Incoming report.", "handler", "report", "id", re.ID)
 
-		if err := s.reporter.Create(report.NewReport(re.ID, re.Data)); err != nil {
-			s.log.Error("Error creating report.", "error", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		status, err := s.reporter.Status(id)
+		if err != nil {
+			if errors.Is(err, report.ErrStatusNotFound) {
+				writeProblem(w, r, newProblem(http.StatusNotFound, codeNotFound, "No status found for report "+id+"."))
+				return
+			}
+			if errors.Is(err, report.ErrStatusDisabled) {
+				writeProblem(w, r, newProblem(http.StatusNotImplemented, codeStatusDisabled, "Report status tracking is not enabled."))
+				return
+			}
+			s.log.Error("Error getting report status.", "error", err, "request_id", requestID(r))
+			writeProblem(w, r, reportErrorProblem(err))
+			return
+		}
+		// The status of a report of another client is not found, so that
+		// the reports of clients are not disclosed to each other.
+		if status.Tenant != clientName(r) {
+			writeProblem(w, r, newProblem(http.StatusNotFound, codeNotFound, "No status found for report "+id+"."))
 			return
 		}
-		s.log.Info("Report sent for creation.", "handler", "report", "id", re.ID)
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write(re.JSON())
+		w.Write(status.JSON())
+	})
+}
+
+// newReport creates a new report from the incoming report, with the
+// client of the request as its tenant, and the deadline and trace context,
+// if any, of the request.
+func newReport(r *http.Request, in Report) report.Report {
+	re := report.NewReport(in.ID, in.Data)
+	re.Type = in.Type
+	re.Tenant = clientName(r)
+	re.Priority = in.Priority
+	re.Labels = in.Labels
+	re.RequestID = requestID(r)
+	re.Deadline = requestDeadline(r)
+	re.TraceContext = tracing.Inject(r.Context())
+	return re
+}
+
+// forbiddenTopic returns the first topic or queue the report is sent to
+// that the authenticated client is not allowed to send reports to. Returns
+// false if the client is allowed to send the report.
+func (s server) forbiddenTopic(r *http.Request, re report.Report) (string, bool) {
+	if s.topics == nil {
+		return "", false
+	}
+	identity, _ := auth.IdentityFromContext(r.Context())
+	for _, topic := range s.topics(re) {
+		if !identity.AllowsTopic(topic) {
+			return topic, true
+		}
+	}
+	return "", false
+}
+
+// topicProblem returns a Problem for a report sent to a topic the client
+// is not allowed to send reports to.
+func topicProblem(topic string) Problem {
+	return newProblem(http.StatusForbidden, codeForbidden, "Client is not allowed to send reports to topic "+topic+".")
+}
+
+// notFoundHandler returns a handler for requests that does not match a route.
+func notFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, newProblem(http.StatusNotFound, codeNotFound, "The requested resource was not found."))
 	})
 }
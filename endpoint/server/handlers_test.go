@@ -1,12 +1,21 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestReportHandler(t *testing.T) {
@@ -17,8 +26,9 @@ func TestReportHandler(t *testing.T) {
 			body   string
 			err    error
 		}
-		wantCode int
-		wantBody string
+		wantCode    int
+		wantBody    string
+		wantProblem string
 	}{
 		{
 			name: "With valid request",
@@ -31,9 +41,79 @@ func TestReportHandler(t *testing.T) {
 				body:   `{"id":"123","data":"data"}`,
 				err:    nil,
 			},
-			wantCode: http.StatusOK,
+			wantCode: http.StatusAccepted,
 			wantBody: `{"id":"123","data":"data"}`,
 		},
+		{
+			name: "With invalid id",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"../123","data":"data"}`,
+				err:    nil,
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidReport,
+		},
+		{
+			name: "With unknown field",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"data","other":"other"}`,
+				err:    nil,
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidReport,
+		},
+		{
+			name: "With too large data",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"ZGF0YWRhdGFkYXRh"}`,
+				err:    nil,
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidReport,
+		},
+		{
+			name: "With too large body",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"data"}` + strings.Repeat(" ", 64),
+				err:    nil,
+			},
+			wantCode:    http.StatusRequestEntityTooLarge,
+			wantProblem: codeBodyTooLarge,
+		},
+		{
+			name: "With existing report",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"data"}`,
+				err:    report.ErrReportExists,
+			},
+			wantCode:    http.StatusConflict,
+			wantProblem: codeReportExists,
+		},
 		{
 			name: "With invalid method",
 			input: struct {
@@ -45,8 +125,8 @@ func TestReportHandler(t *testing.T) {
 				body:   `{"id":"123","data":"data"}`,
 				err:    nil,
 			},
-			wantCode: http.StatusMethodNotAllowed,
-			wantBody: "Method not allowed\n",
+			wantCode:    http.StatusMethodNotAllowed,
+			wantProblem: codeMethodNotAllowed,
 		},
 		{
 			name: "With invalid body",
@@ -59,8 +139,8 @@ func TestReportHandler(t *testing.T) {
 				body:   `{"id":"123","data":"data`,
 				err:    nil,
 			},
-			wantCode: http.StatusBadRequest,
-			wantBody: "Invalid request body\n",
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidBody,
 		},
 		{
 			name: "With reporter error",
@@ -73,8 +153,36 @@ func TestReportHandler(t *testing.T) {
 				body:   `{"id":"123","data":"data"}`,
 				err:    errors.New("error"),
 			},
-			wantCode: http.StatusInternalServerError,
-			wantBody: "Internal server error\n",
+			wantCode:    http.StatusInternalServerError,
+			wantProblem: codeInternal,
+		},
+		{
+			name: "With reporter unavailable",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"data"}`,
+				err:    fmt.Errorf("%w: error", report.ErrUnavailable),
+			},
+			wantCode:    http.StatusServiceUnavailable,
+			wantProblem: codeUnavailable,
+		},
+		{
+			name: "With reporter timeout",
+			input: struct {
+				method string
+				body   string
+				err    error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"123","data":"data"}`,
+				err:    fmt.Errorf("%w: error", report.ErrTimeout),
+			},
+			wantCode:    http.StatusGatewayTimeout,
+			wantProblem: codeTimeout,
 		},
 	}
 
@@ -84,7 +192,8 @@ func TestReportHandler(t *testing.T) {
 				reporter: &mockReporter{
 					err: test.input.err,
 				},
-				log: &mockLogger{},
+				log:        &mockLogger{},
+				validation: Validation{MaxBodySize: 64, MaxDataSize: 8},
 			}
 
 			req := httptest.NewRequest(test.input.method, "/reports", strings.NewReader(test.input.body))
@@ -95,13 +204,581 @@ func TestReportHandler(t *testing.T) {
 			resp := w.Result()
 
 			if resp.StatusCode != test.wantCode {
-				t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", resp.StatusCode, test.wantCode)
+				t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
 			}
 
 			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("reportHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+				return
+			}
 			if string(body) != test.wantBody {
 				t.Errorf("reportHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
 			}
 		})
 	}
 }
+
+func TestReportHandler_ID(t *testing.T) {
+	var tests = []struct {
+		name         string
+		input        string
+		wantID       string
+		wantGenerate bool
+	}{
+		{
+			name:   "With id",
+			input:  `{"id":"123","data":"data"}`,
+			wantID: "123",
+		},
+		{
+			name:         "Without id",
+			input:        `{"data":"data"}`,
+			wantGenerate: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter: &mockReporter{},
+				log:      &mockLogger{},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(test.input))
+			w := httptest.NewRecorder()
+
+			s.reportHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusAccepted {
+				t.Fatalf("reportHandler() = unexpected result, want %d, got: %d\n", http.StatusAccepted, resp.StatusCode)
+			}
+
+			var got Report
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("reportHandler() = unexpected error decoding body: %v\n", err)
+			}
+
+			if test.wantGenerate {
+				if err := report.ValidateID(got.ID); err != nil {
+					t.Errorf("reportHandler() = unexpected result, want generated id, got: %q\n", got.ID)
+				}
+			} else if got.ID != test.wantID {
+				t.Errorf("reportHandler() = unexpected result, want id %q, got: %q\n", test.wantID, got.ID)
+			}
+
+			if location := resp.Header.Get("Location"); location != "/reports/"+got.ID {
+				t.Errorf("reportHandler() = unexpected result, want location %q, got: %q\n", "/reports/"+got.ID, location)
+			}
+		})
+	}
+}
+
+func TestReportHandler_ReportedBy(t *testing.T) {
+	chain, _ := report.NewChainReporter([]report.NamedReporter{
+		{Name: "primary", Reporter: mockRunner{err: report.ErrUnavailable}},
+		{Name: "fallback", Reporter: mockRunner{}},
+	})
+	svc, _ := report.NewService(chain)
+	s := &server{
+		reporter:     svc,
+		log:          &mockLogger{},
+		maxBatchSize: defaultMaxBatchSize,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"id":"123","data":"data"}`))
+	w := httptest.NewRecorder()
+	s.reportHandler().ServeHTTP(w, req)
+
+	if got := w.Result().Header.Get(reportedByHeader); got != "fallback" {
+		t.Errorf("reportHandler() = unexpected %s, want: fallback, got: %q\n", reportedByHeader, got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/reports:batch", strings.NewReader(`[{"id":"123","data":"data"}]`))
+	w = httptest.NewRecorder()
+	s.batchHandler().ServeHTTP(w, req)
+
+	var got BatchResponse
+	if err := json.NewDecoder(w.Result().Body).Decode(&got); err != nil {
+		t.Fatalf("batchHandler() = unexpected error decoding body: %v\n", err)
+	}
+	if len(got.Results) != 1 || got.Results[0].ReportedBy != "fallback" {
+		t.Errorf("batchHandler() = unexpected result, want reported by fallback, got: %+v\n", got.Results)
+	}
+}
+
+func TestReportHandler_Routing(t *testing.T) {
+	var got []report.Report
+	router, _ := report.NewRoutingReporter([]report.Route{
+		{Name: "tenant-alerts", Match: report.Match{Types: []string{"alert"}, Tenants: []string{"client"}}, Reporter: report.NamedReporter{Name: "alerts", Reporter: mockRunner{}}},
+	}, report.Route{Name: "default", Reporter: report.NamedReporter{Name: "primary", Reporter: mockRunner{}}}, func(o *report.RoutingReporterOptions) {
+		o.OnRoute = func(re report.Report) {
+			got = append(got, re)
+		}
+	})
+	svc, _ := report.NewService(router)
+	s := &server{
+		reporter:     svc,
+		log:          &mockLogger{},
+		maxBatchSize: defaultMaxBatchSize,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"id":"123","type":"alert","priority":5,"labels":{"region":"eu"}}`))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Name: "client"}))
+	w := httptest.NewRecorder()
+	s.reportHandler().ServeHTTP(w, req)
+
+	if got := w.Result().Header.Get(reportedByHeader); got != "alerts" {
+		t.Errorf("reportHandler() = unexpected %s, want: alerts, got: %q\n", reportedByHeader, got)
+	}
+	if len(got) != 1 {
+		t.Fatalf("reportHandler() = unexpected number of routed reports, want: 1, got: %d\n", len(got))
+	}
+	want := report.Report{ID: "123", Type: "alert", Tenant: "client", Priority: 5, Labels: map[string]string{"region": "eu"}, Route: "tenant-alerts"}
+	if diff := cmp.Diff(want, got[0], cmpopts.IgnoreFields(report.Report{}, "Data", "TraceContext")); diff != "" {
+		t.Errorf("reportHandler() = unexpected report, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestReportHandler_Topics(t *testing.T) {
+	var tests = []struct {
+		name     string
+		input    string
+		wantCode int
+	}{
+		{
+			name:     "Allowed topic",
+			input:    `{"id":"123","type":"report"}`,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "Forbidden topic",
+			input:    `{"id":"123","type":"alert"}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc, _ := report.NewService(mockRunner{})
+			s := &server{
+				reporter:     svc,
+				log:          &mockLogger{},
+				maxBatchSize: defaultMaxBatchSize,
+				topics: func(re report.Report) []string {
+					if re.Type == "alert" {
+						return []string{"create", "alerts"}
+					}
+					return []string{"create"}
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(test.input))
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Name: "client", Topics: []string{"create"}}))
+			w := httptest.NewRecorder()
+			s.reportHandler().ServeHTTP(w, req)
+
+			if w.Code != test.wantCode {
+				t.Errorf("reportHandler() = unexpected status code, want: %d, got: %d\n", test.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestBatchHandler_Topics(t *testing.T) {
+	svc, _ := report.NewService(mockRunner{})
+	s := &server{
+		reporter:     svc,
+		log:          &mockLogger{},
+		maxBatchSize: defaultMaxBatchSize,
+		topics: func(re report.Report) []string {
+			if re.Type == "alert" {
+				return []string{"alerts"}
+			}
+			return []string{"create"}
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/reports:batch", strings.NewReader(`[{"id":"123"},{"id":"456","type":"alert"}]`))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Name: "client", Topics: []string{"create"}}))
+	w := httptest.NewRecorder()
+	s.batchHandler().ServeHTTP(w, req)
+
+	var got BatchResponse
+	if err := json.NewDecoder(w.Result().Body).Decode(&got); err != nil {
+		t.Fatalf("batchHandler() = unexpected error decoding body: %v\n", err)
+	}
+	var gotStatuses []int
+	for _, result := range got.Results {
+		gotStatuses = append(gotStatuses, result.Status)
+	}
+	if diff := cmp.Diff([]int{http.StatusAccepted, http.StatusForbidden}, gotStatuses); diff != "" {
+		t.Errorf("batchHandler() = unexpected statuses, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestBatchHandler_Deliveries(t *testing.T) {
+	fanOut, _ := report.NewFanOutReporter([]report.NamedReporter{
+		{Name: "queue", Reporter: mockRunner{}},
+		{Name: "pubsub", Reporter: mockRunner{err: report.ErrUnavailable}},
+	}, func(o *report.FanOutReporterOptions) {
+		o.Policy = report.FanOutPrimary
+		o.Sequential = true
+	})
+	svc, _ := report.NewService(fanOut)
+	s := &server{
+		reporter:     svc,
+		log:          &mockLogger{},
+		maxBatchSize: defaultMaxBatchSize,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/reports:batch", strings.NewReader(`[{"id":"123","data":"data"}]`))
+	w := httptest.NewRecorder()
+	s.batchHandler().ServeHTTP(w, req)
+
+	var got BatchResponse
+	if err := json.NewDecoder(w.Result().Body).Decode(&got); err != nil {
+		t.Fatalf("batchHandler() = unexpected error decoding body: %v\n", err)
+	}
+	want := []BatchResult{{
+		ID:         "123",
+		Status:     http.StatusAccepted,
+		Location:   "/reports/123",
+		ReportedBy: "queue",
+		Deliveries: []report.Delivery{{Reporter: "queue"}, {Reporter: "pubsub", Error: report.ErrUnavailable.Error()}},
+	}}
+	if diff := cmp.Diff(want, got.Results); diff != "" {
+		t.Errorf("batchHandler() = unexpected results, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestBatchHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method    string
+			body      string
+			batchErrs map[string]error
+		}
+		wantCode     int
+		wantStatuses []int
+	}{
+		{
+			name: "With valid batch",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `[{"id":"1","data":"data"},{"data":"data"}]`,
+			},
+			wantCode:     http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusAccepted, http.StatusAccepted},
+		},
+		{
+			name: "With invalid reports",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `[{"id":"1","data":"data"},{"id":"../2"},"invalid",{"id":"1"}]`,
+			},
+			wantCode:     http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusAccepted, http.StatusBadRequest, http.StatusBadRequest, http.StatusConflict},
+		},
+		{
+			name: "With reporter errors",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `[{"id":"1"},{"id":"2"},{"id":"3"}]`,
+				batchErrs: map[string]error{
+					"2": errors.New("error"),
+					"3": report.ErrReportExists,
+				},
+			},
+			wantCode:     http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusAccepted, http.StatusInternalServerError, http.StatusConflict},
+		},
+		{
+			name: "With invalid method",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodGet,
+			},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "With invalid body",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `{"id":"1"}`,
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "With empty batch",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `[]`,
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "With too large batch",
+			input: struct {
+				method    string
+				body      string
+				batchErrs map[string]error
+			}{
+				method: http.MethodPost,
+				body:   `[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"},{"id":"5"}]`,
+			},
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter: &mockReporter{
+					batchErrs: test.input.batchErrs,
+				},
+				log:          &mockLogger{},
+				maxBatchSize: 4,
+			}
+
+			req := httptest.NewRequest(test.input.method, "/reports:batch", strings.NewReader(test.input.body))
+			w := httptest.NewRecorder()
+
+			s.batchHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("batchHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			if test.wantStatuses == nil {
+				return
+			}
+
+			var got BatchResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("batchHandler() = unexpected error decoding body: %v\n", err)
+			}
+			var gotStatuses []int
+			for _, result := range got.Results {
+				gotStatuses = append(gotStatuses, result.Status)
+				if result.Status == http.StatusAccepted && result.Location != "/reports/"+result.ID {
+					t.Errorf("batchHandler() = unexpected location, want: %q, got: %q\n", "/reports/"+result.ID, result.Location)
+				}
+			}
+			if diff := cmp.Diff(test.wantStatuses, gotStatuses); diff != "" {
+				t.Errorf("batchHandler() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method string
+			path   string
+			status report.Status
+			err    error
+		}
+		wantCode    int
+		wantBody    string
+		wantProblem string
+	}{
+		{
+			name: "With existing status",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/123",
+				status: report.Status{
+					ID:    "123",
+					State: report.StateQueued,
+					Timestamps: map[report.State]time.Time{
+						report.StateQueued: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+					Updated: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"id":"123","state":"queued","timestamps":{"queued":"2023-01-01T00:00:00Z"},"updated":"2023-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "With status of another client",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/123",
+				status: report.Status{ID: "123", Tenant: "other", State: report.StateQueued},
+			},
+			wantCode:    http.StatusNotFound,
+			wantProblem: codeNotFound,
+		},
+		{
+			name: "With invalid method",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodPost,
+				path:   "/reports/123",
+			},
+			wantCode:    http.StatusMethodNotAllowed,
+			wantProblem: codeMethodNotAllowed,
+		},
+		{
+			name: "Without id",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/",
+			},
+			wantCode:    http.StatusNotFound,
+			wantProblem: codeNotFound,
+		},
+		{
+			name: "With status not found",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/123",
+				err:    report.ErrStatusNotFound,
+			},
+			wantCode:    http.StatusNotFound,
+			wantProblem: codeNotFound,
+		},
+		{
+			name: "With status disabled",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/123",
+				err:    report.ErrStatusDisabled,
+			},
+			wantCode:    http.StatusNotImplemented,
+			wantProblem: codeStatusDisabled,
+		},
+		{
+			name: "With reporter error",
+			input: struct {
+				method string
+				path   string
+				status report.Status
+				err    error
+			}{
+				method: http.MethodGet,
+				path:   "/reports/123",
+				err:    errors.New("error"),
+			},
+			wantCode:    http.StatusInternalServerError,
+			wantProblem: codeInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter: &mockReporter{
+					status: test.input.status,
+					err:    test.input.err,
+				},
+				log: &mockLogger{},
+			}
+
+			req := httptest.NewRequest(test.input.method, test.input.path, nil)
+			w := httptest.NewRecorder()
+
+			s.statusHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("statusHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("statusHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+				return
+			}
+			if string(body) != test.wantBody {
+				t.Errorf("statusHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+			}
+		})
+	}
+}
+
+// problemCode returns the code of the problem in the provided response body.
+func problemCode(t *testing.T, resp *http.Response, body []byte) string {
+	t.Helper()
+	if ct := resp.Header.Get("Content-Type"); ct != problemContentType {
+		t.Errorf("unexpected content type, want %s, got: %s\n", problemContentType, ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("unexpected error decoding problem: %v\n", err)
+	}
+	if p.Status != resp.StatusCode {
+		t.Errorf("unexpected problem status, want %d, got: %d\n", resp.StatusCode, p.Status)
+	}
+	return p.Code
+}
+
+type mockRunner struct {
+	err error
+}
+
+func (r mockRunner) Run(ctx context.Context, re report.Report) error {
+	return r.err
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+// HealthChecker is the interface that wraps around method Check.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// CircuitBreaker is the interface that wraps around method State.
+type CircuitBreaker interface {
+	State() report.CircuitState
+}
+
+// HealthResponse is the response for a health or readiness check. Circuit
+// is the state of the circuit breaker of the reporter, if any.
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Circuit string `json:"circuit,omitempty"`
+}
+
+// JSON returns the JSON representation of the health response.
+func (r HealthResponse) JSON() []byte {
+	b, _ := json.Marshal(&r)
+	return b
+}
+
+// healthHandler returns a handler for liveness checks. The server is
+// alive as long as it can respond.
+func (s server) healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(s.healthResponse("ok").JSON())
+	})
+}
+
+// readyHandler returns a handler for readiness checks. The server is not
+// ready while shutting down or if the health check of the DAPR sidecar
+// fails. An open circuit breaker does not make the server not ready, since
+// it applies to the reporter and not to the server.
+func (s server) readyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		if s.shuttingDown() {
+			writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeNotReady, "The server is shutting down."))
+			return
+		}
+		if s.health != nil {
+			if err := s.health.Check(r.Context()); err != nil {
+				s.log.Error("Readiness check failed.", "error", err)
+				writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeNotReady, "The DAPR sidecar or a component is not ready."))
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(s.healthResponse("ready").JSON())
+	})
+}
+
+// healthResponse returns a HealthResponse with the provided status and
+// the state of the circuit breaker.
+func (s server) healthResponse(status string) HealthResponse {
+	res := HealthResponse{Status: status}
+	if s.circuit != nil {
+		res.Circuit = string(s.circuit.State())
+	}
+	return res
+}
+
+// shuttingDown returns true if the server is shutting down.
+func (s server) shuttingDown() bool {
+	return s.shutdown != nil && s.shutdown.Load()
+}
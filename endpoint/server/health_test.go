@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+func TestHealthHandler(t *testing.T) {
+	s := &server{log: &mockLogger{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	s.healthHandler().ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthHandler() = unexpected result, want %d, got: %d\n", http.StatusOK, resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if want := `{"status":"ok"}`; string(body) != want {
+		t.Errorf("healthHandler() = unexpected result, want %s, got: %s\n", want, string(body))
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			health   HealthChecker
+			circuit  CircuitBreaker
+			shutdown bool
+		}
+		wantCode    int
+		wantBody    string
+		wantProblem string
+	}{
+		{
+			name: "Ready",
+			input: struct {
+				health   HealthChecker
+				circuit  CircuitBreaker
+				shutdown bool
+			}{
+				health: mockHealth{},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ready"}`,
+		},
+		{
+			name: "Without health checker",
+			input: struct {
+				health   HealthChecker
+				circuit  CircuitBreaker
+				shutdown bool
+			}{},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ready"}`,
+		},
+		{
+			name: "With circuit breaker",
+			input: struct {
+				health   HealthChecker
+				circuit  CircuitBreaker
+				shutdown bool
+			}{
+				health:  mockHealth{},
+				circuit: mockCircuit{state: report.CircuitOpen},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ready","circuit":"open"}`,
+		},
+		{
+			name: "Sidecar not ready",
+			input: struct {
+				health   HealthChecker
+				circuit  CircuitBreaker
+				shutdown bool
+			}{
+				health: mockHealth{err: errors.New("error")},
+			},
+			wantCode:    http.StatusServiceUnavailable,
+			wantProblem: codeNotReady,
+		},
+		{
+			name: "Shutting down",
+			input: struct {
+				health   HealthChecker
+				circuit  CircuitBreaker
+				shutdown bool
+			}{
+				health:   mockHealth{},
+				shutdown: true,
+			},
+			wantCode:    http.StatusServiceUnavailable,
+			wantProblem: codeNotReady,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log:      &mockLogger{},
+				health:   test.input.health,
+				circuit:  test.input.circuit,
+				shutdown: &atomic.Bool{},
+			}
+			s.shutdown.Store(test.input.shutdown)
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			w := httptest.NewRecorder()
+
+			s.readyHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("readyHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("readyHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+				return
+			}
+			if string(body) != test.wantBody {
+				t.Errorf("readyHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+			}
+		})
+	}
+}
+
+type mockHealth struct {
+	err error
+}
+
+func (h mockHealth) Check(ctx context.Context) error {
+	return h.err
+}
+
+type mockCircuit struct {
+	state report.CircuitState
+}
+
+func (c mockCircuit) State() report.CircuitState {
+	return c.state
+}
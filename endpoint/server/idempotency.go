@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyPrefix      = "idempotency:"
+)
+
+const (
+	// idempotencyPendingMargin is added to the time to live of a pending
+	// key, for the calls of a request other than sending the report.
+	idempotencyPendingMargin = time.Second * 10
+)
+
+var (
+	// errIdempotencyInProgress is returned when a request with the same key
+	// is in progress.
+	errIdempotencyInProgress = errors.New("request with idempotency key is in progress")
+	// errIdempotencyMismatch is returned when a key is reused with a different
+	// request body.
+	errIdempotencyMismatch = errors.New("idempotency key reused with different request")
+)
+
+// Idempotency contains settings for idempotent report submissions.
+type Idempotency struct {
+	// Store is the state store used to store idempotency keys.
+	// Idempotency is disabled if nil.
+	Store state.Store
+	// Window is the duration an idempotency key is kept.
+	Window time.Duration
+	// PendingTTL is the duration a key is held while the request it
+	// belongs to is in progress. It should be at least the longest time
+	// a report can take to be sent, retries included.
+	PendingTTL time.Duration
+}
+
+// idempotencyRecord is the stored result of a request with an
+// idempotency key. A record with status 0 is pending.
+type idempotencyRecord struct {
+	Status   int               `json:"status"`
+	Location string            `json:"location,omitempty"`
+	Header   map[string]string `json:"header,omitempty"`
+	Body     []byte            `json:"body,omitempty"`
+	Hash     string            `json:"hash"`
+}
+
+// idempotencyResponse contains the response to store for a request.
+type idempotencyResponse struct {
+	status   int
+	location string
+	header   map[string]string
+	body     []byte
+}
+
+// idempotency handles idempotency keys for requests.
+type idempotency struct {
+	store      state.Store
+	window     time.Duration
+	pendingTTL time.Duration
+}
+
+// begin checks if a request with the provided key and hash has been made
+// before by the client. If it has, and it has completed, the stored record
+// is returned. If not, the key is marked as pending and nil is returned.
+// The pending record is created with first-write-wins concurrency, so that
+// only one of several concurrent requests with the same key proceeds.
+func (i idempotency) begin(client, key, hash string) (*idempotencyRecord, error) {
+	pending, _ := json.Marshal(idempotencyRecord{Hash: hash})
+	err := i.store.Create(idempotencyStoreKey(client, key), pending, i.pendingTTL)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, state.ErrExists) {
+		return nil, err
+	}
+
+	b, err := i.store.Get(idempotencyStoreKey(client, key))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			// The key was removed by the request that holds it after
+			// the create conflicted.
+			return nil, errIdempotencyInProgress
+		}
+		return nil, err
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		return nil, err
+	}
+	if record.Hash != hash {
+		return nil, errIdempotencyMismatch
+	}
+	if record.Status == 0 {
+		return nil, errIdempotencyInProgress
+	}
+	return &record, nil
+}
+
+// complete stores the response for the provided key of the client.
+func (i idempotency) complete(client, key, hash string, response idempotencyResponse) error {
+	record, _ := json.Marshal(idempotencyRecord{
+		Status:   response.status,
+		Location: response.location,
+		Header:   response.header,
+		Body:     response.body,
+		Hash:     hash,
+	})
+	return i.store.Set(idempotencyStoreKey(client, key), record, i.window)
+}
+
+// cancel removes the provided key of the client so that the request can
+// be retried.
+func (i idempotency) cancel(client, key string) error {
+	return i.store.Delete(idempotencyStoreKey(client, key))
+}
+
+// idempotencyStoreKey returns the key in the state store for the provided
+// key. Keys are scoped per client, so that clients cannot replay the
+// responses of each other.
+func idempotencyStoreKey(client, key string) string {
+	return idempotencyKeyPrefix + client + ":" + key
+}
+
+// fingerprint returns the hex encoded SHA-256 hash of the provided report
+// without its data, and the hash of the data, so that uploaded data does
+// not have to be held in memory to identify a request.
+func fingerprint(re Report, dataHash []byte) string {
+	re.Data = nil
+	h := sha256.New()
+	h.Write(re.JSON())
+	h.Write(dataHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
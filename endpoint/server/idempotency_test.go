@@ -0,0 +1,266 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdempotency(t *testing.T) {
+	i := idempotency{store: &mockStore{}, window: time.Hour}
+
+	record, err := i.begin("client", "key", "hash")
+	if err != nil || record != nil {
+		t.Fatalf("begin() = unexpected result, want nil, nil, got: %v, %v\n", record, err)
+	}
+
+	if _, err := i.begin("client", "key", "hash"); !errors.Is(err, errIdempotencyInProgress) {
+		t.Errorf("begin() = unexpected result, want: %v, got: %v\n", errIdempotencyInProgress, err)
+	}
+
+	if err := i.complete("client", "key", "hash", idempotencyResponse{status: http.StatusAccepted, location: "/reports/key", header: map[string]string{reportedByHeader: "queue"}, body: []byte("response")}); err != nil {
+		t.Fatalf("complete() = unexpected error: %v\n", err)
+	}
+
+	record, err = i.begin("client", "key", "hash")
+	if err != nil {
+		t.Fatalf("begin() = unexpected error: %v\n", err)
+	}
+	want := &idempotencyRecord{Status: http.StatusAccepted, Location: "/reports/key", Header: map[string]string{reportedByHeader: "queue"}, Body: []byte("response"), Hash: "hash"}
+	if diff := cmp.Diff(want, record); diff != "" {
+		t.Errorf("begin() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+
+	if _, err := i.begin("client", "key", "other"); !errors.Is(err, errIdempotencyMismatch) {
+		t.Errorf("begin() = unexpected result, want: %v, got: %v\n", errIdempotencyMismatch, err)
+	}
+
+	if record, err := i.begin("other-client", "key", "other"); err != nil || record != nil {
+		t.Errorf("begin() = unexpected result for other client, want nil, nil, got: %v, %v\n", record, err)
+	}
+
+	if err := i.cancel("client", "key"); err != nil {
+		t.Fatalf("cancel() = unexpected error: %v\n", err)
+	}
+	if record, err := i.begin("client", "key", "other"); err != nil || record != nil {
+		t.Errorf("begin() = unexpected result, want nil, nil, got: %v, %v\n", record, err)
+	}
+}
+
+func TestIdempotency_Concurrent(t *testing.T) {
+	i := idempotency{store: &mockStore{}, window: time.Hour}
+
+	n := 10
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := i.begin("client", "key", "hash")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var begun, inProgress int
+	for err := range errs {
+		switch {
+		case err == nil:
+			begun++
+		case errors.Is(err, errIdempotencyInProgress):
+			inProgress++
+		default:
+			t.Errorf("begin() = unexpected error: %v\n", err)
+		}
+	}
+	if begun != 1 || inProgress != n-1 {
+		t.Errorf("begin() = unexpected result, want 1 begun and %d in progress, got: %d and %d\n", n-1, begun, inProgress)
+	}
+}
+
+func TestReportHandler_Idempotency(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			headers []string
+			bodies  []string
+			errs    []error
+		}
+		wantCodes    []int
+		wantReplayed []string
+	}{
+		{
+			name: "Repeated with report ID",
+			input: struct {
+				headers []string
+				bodies  []string
+				errs    []error
+			}{
+				headers: []string{"", ""},
+				bodies:  []string{`{"id":"123","data":"data"}`, `{"id":"123","data":"data"}`},
+				errs:    []error{nil, errors.New("error")},
+			},
+			wantCodes:    []int{http.StatusAccepted, http.StatusAccepted},
+			wantReplayed: []string{"", "true"},
+		},
+		{
+			name: "Repeated with idempotency key",
+			input: struct {
+				headers []string
+				bodies  []string
+				errs    []error
+			}{
+				headers: []string{"key", "key"},
+				bodies:  []string{`{"id":"123","data":"data"}`, `{"id":"123","data":"data"}`},
+				errs:    []error{nil, errors.New("error")},
+			},
+			wantCodes:    []int{http.StatusAccepted, http.StatusAccepted},
+			wantReplayed: []string{"", "true"},
+		},
+		{
+			name: "Reused idempotency key",
+			input: struct {
+				headers []string
+				bodies  []string
+				errs    []error
+			}{
+				headers: []string{"key", "key"},
+				bodies:  []string{`{"id":"123","data":"data"}`, `{"id":"456","data":"data"}`},
+				errs:    []error{nil, nil},
+			},
+			wantCodes:    []int{http.StatusAccepted, http.StatusUnprocessableEntity},
+			wantReplayed: []string{"", ""},
+		},
+		{
+			name: "Retry after reporter error",
+			input: struct {
+				headers []string
+				bodies  []string
+				errs    []error
+			}{
+				headers: []string{"", ""},
+				bodies:  []string{`{"id":"123","data":"data"}`, `{"id":"123","data":"data"}`},
+				errs:    []error{errors.New("error"), nil},
+			},
+			wantCodes:    []int{http.StatusInternalServerError, http.StatusAccepted},
+			wantReplayed: []string{"", ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log:         &mockLogger{},
+				idempotency: &idempotency{store: &mockStore{}, window: time.Hour},
+			}
+
+			var bodies []string
+			for i := range test.input.bodies {
+				s.reporter = &mockReporter{err: test.input.errs[i]}
+
+				req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(test.input.bodies[i]))
+				if len(test.input.headers[i]) > 0 {
+					req.Header.Set(idempotencyKeyHeader, test.input.headers[i])
+				}
+				w := httptest.NewRecorder()
+
+				s.reportHandler().ServeHTTP(w, req)
+
+				resp := w.Result()
+				if resp.StatusCode != test.wantCodes[i] {
+					t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", test.wantCodes[i], resp.StatusCode)
+				}
+				if got := resp.Header.Get(idempotencyReplayedHeader); got != test.wantReplayed[i] {
+					t.Errorf("reportHandler() = unexpected result, want header %q, got: %q\n", test.wantReplayed[i], got)
+				}
+				if got := resp.Header.Get("Content-Type"); resp.StatusCode == http.StatusAccepted && got != mediaTypeJSON {
+					t.Errorf("reportHandler() = unexpected content type, want %s, got: %s\n", mediaTypeJSON, got)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				bodies = append(bodies, string(body))
+			}
+
+			if test.wantReplayed[len(test.wantReplayed)-1] == "true" && bodies[0] != bodies[len(bodies)-1] {
+				t.Errorf("reportHandler() = unexpected result, want replayed body %s, got: %s\n", bodies[0], bodies[len(bodies)-1])
+			}
+		})
+	}
+}
+
+type mockStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	err  error
+}
+
+func (s *mockStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	b, ok := s.data[key]
+	if !ok {
+		return nil, state.ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *mockStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value)
+}
+
+func (s *mockStore) Create(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok && s.err == nil {
+		return state.ErrExists
+	}
+	return s.set(key, value)
+}
+
+func (s *mockStore) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	value, err := fn(s.data[key])
+	if err != nil {
+		return err
+	}
+	return s.set(key, value)
+}
+
+func (s *mockStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mockStore) set(key string, value []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = value
+	return nil
+}
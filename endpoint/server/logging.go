@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength is the maximum length of a request ID provided by
+	// a client.
+	maxRequestIDLength = 128
+	// redacted replaces the values of redacted headers and fields in the
+	// access log.
+	redacted = "[REDACTED]"
+)
+
+// AccessLog contains settings for the access log. The access log is
+// disabled if Enabled is false.
+type AccessLog struct {
+	Enabled bool
+	// RedactHeaders are the names of request headers with values that are
+	// redacted.
+	RedactHeaders map[string]struct{}
+	// RedactFields are the names of query parameters, report attributes
+	// (id, type, priority and labels) and labels with values that are
+	// redacted.
+	RedactFields map[string]struct{}
+}
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// requestInfoKey is the context key for the *requestInfo of a request.
+type requestInfoKey struct{}
+
+// requestInfo contains information about a request that is set by the
+// handlers for the access log.
+type requestInfo struct {
+	client string
+}
+
+// withRequestID is a middleware that sets the ID of the request from the
+// X-Request-ID header, or generates an ID if the header is missing or not
+// valid. The ID is returned in the X-Request-ID header of the response and
+// added to the request context.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID returns the ID of the request. The ID set by the withRequestID
+// middleware is used if set, otherwise the X-Request-ID header.
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return r.Header.Get(requestIDHeader)
+}
+
+// validRequestID returns true if the request ID is not empty, not too long
+// and only contains letters, digits and the characters '-', '_', '.' and
+// ':', so that it is safe to log and send along with reports.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// accessLog is a middleware that logs the method, path, status, duration,
+// number of bytes written and client of every request, together with the
+// request headers and query parameters. The values of the headers, query
+// parameters, report attributes and labels to redact are replaced.
+func accessLog(al AccessLog, log log, next http.Handler) http.Handler {
+	if !al.Enabled {
+		return next
+	}
+	headers := make(map[string]struct{}, len(al.RedactHeaders))
+	for header := range al.RedactHeaders {
+		headers[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+	fields := make(map[string]struct{}, len(al.RedactFields))
+	for field := range al.RedactFields {
+		fields[strings.ToLower(field)] = struct{}{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestInfo{}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		log.Info("Request handled.",
+			"request_id", requestID(r),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"query", redactQuery(r.URL.Query(), fields),
+			"status", rec.status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes", rec.bytes,
+			"client", info.client,
+			"remote_addr", r.RemoteAddr,
+			"headers", redactHeaders(r.Header, headers, fields),
+		)
+	})
+}
+
+// recordClient is a middleware that sets the name of the authenticated
+// client for the access log.
+func recordClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+			info.client = clientName(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redactHeaders returns the headers as a map with the values of the
+// headers to redact replaced. The report attribute headers (X-Report-*) are
+// redacted if the attribute is a field to redact, and the values of the
+// labels to redact are replaced in X-Report-Labels.
+func redactHeaders(h http.Header, redact, fields map[string]struct{}) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		if _, ok := redact[name]; ok {
+			headers[name] = redacted
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if attribute, ok := strings.CutPrefix(name, "X-Report-"); ok {
+			if _, ok := fields[strings.ToLower(attribute)]; ok {
+				headers[name] = redacted
+				continue
+			}
+			if name == reportLabelsHeader {
+				value = redactLabels(value, fields)
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// redactLabels returns the comma separated key=value pairs with the values
+// of the labels to redact replaced. Keys are matched without regard to
+// case.
+func redactLabels(s string, redact map[string]struct{}) string {
+	if len(redact) == 0 {
+		return s
+	}
+	pairs := strings.Split(s, ",")
+	for i, pair := range pairs {
+		k, _, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if _, ok := redact[strings.ToLower(strings.TrimSpace(k))]; ok {
+			pairs[i] = k + "=" + redacted
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// redactQuery returns the query parameters as a map with the values of the
+// parameters to redact replaced. Parameter names are matched without
+// regard to case.
+func redactQuery(q url.Values, redact map[string]struct{}) map[string]string {
+	if len(q) == 0 {
+		return nil
+	}
+	query := make(map[string]string, len(q))
+	for name, values := range q {
+		if _, ok := redact[strings.ToLower(name)]; ok {
+			query[name] = redacted
+			continue
+		}
+		query[name] = strings.Join(values, ",")
+	}
+	return query
+}
+
+// responseRecorder is a http.ResponseWriter that records the status code
+// and the number of bytes written of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and writes it.
+func (w *responseRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes and writes the data of the response.
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
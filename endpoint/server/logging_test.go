@@ -0,0 +1,146 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var tests = []struct {
+		name          string
+		input         string
+		wantGenerated bool
+	}{
+		{
+			name:  "With request ID",
+			input: "6f1c1a4e-2b7d-4a8e-9d1b-7c2f5a3e1d0b",
+		},
+		{
+			name:          "Without request ID",
+			input:         "",
+			wantGenerated: true,
+		},
+		{
+			name:          "With invalid request ID",
+			input:         "id\nwith newline",
+			wantGenerated: true,
+		},
+		{
+			name:          "With too long request ID",
+			input:         strings.Repeat("a", maxRequestIDLength+1),
+			wantGenerated: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestID(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/reports/123", nil)
+			if len(test.input) > 0 {
+				req.Header.Set(requestIDHeader, test.input)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if test.wantGenerated && (got == test.input || len(got) == 0) {
+				t.Errorf("withRequestID() = unexpected result, want generated request ID, got: %q\n", got)
+			}
+			if !test.wantGenerated && got != test.input {
+				t.Errorf("withRequestID() = unexpected result, want: %s, got: %s\n", test.input, got)
+			}
+			if header := w.Header().Get(requestIDHeader); header != got {
+				t.Errorf("withRequestID() = unexpected header, want: %s, got: %s\n", got, header)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input AccessLog
+		want  map[string]any
+	}{
+		{
+			name:  "Disabled",
+			input: AccessLog{},
+			want:  nil,
+		},
+		{
+			name: "Enabled",
+			input: AccessLog{
+				Enabled:       true,
+				RedactHeaders: map[string]struct{}{"x-api-key": {}},
+				RedactFields:  map[string]struct{}{"token": {}, "type": {}, "secret": {}},
+			},
+			want: map[string]any{
+				"request_id":  "123",
+				"method":      http.MethodPost,
+				"path":        "/reports",
+				"query":       map[string]string{"date": "2024-01-02", "Token": redacted},
+				"status":      http.StatusAccepted,
+				"bytes":       2,
+				"client":      "client",
+				"remote_addr": "192.0.2.1:1234",
+				"headers": map[string]string{
+					"X-Api-Key":       redacted,
+					"X-Request-Id":    "123",
+					"X-Report-Type":   redacted,
+					"X-Report-Labels": "team=a, secret=" + redacted,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			handler := withRequestID(accessLog(test.input, logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				recordClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusAccepted)
+					w.Write([]byte("ok"))
+				})).ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{Name: "client"})))
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, "/reports?date=2024-01-02&Token=secret", nil)
+			req.Header.Set(requestIDHeader, "123")
+			req.Header.Set("X-API-Key", "secret")
+			req.Header.Set(reportTypeHeader, "alert")
+			req.Header.Set(reportLabelsHeader, "team=a, secret=s")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if test.want == nil {
+				if logger.args != nil {
+					t.Errorf("accessLog() = unexpected result, want no log, got: %v\n", logger.args)
+				}
+				return
+			}
+			delete(logger.args, "duration_ms")
+			if diff := cmp.Diff(test.want, logger.args); diff != "" {
+				t.Errorf("accessLog() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+type recordingLogger struct {
+	args map[string]any
+}
+
+func (l *recordingLogger) Error(msg string, args ...any) {}
+
+func (l *recordingLogger) Info(msg string, args ...any) {
+	l.args = make(map[string]any, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		l.args[args[i].(string)] = args[i+1]
+	}
+}
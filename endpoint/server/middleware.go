@@ -1,23 +1,249 @@
 package server
 
-import "net/http"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+)
+
+const (
+	authHeader   = "X-API-Key"
+	bearerPrefix = "Bearer "
+)
+
+const (
+	// globalRateLimitKey is the rate limit key of requests without a client.
+	globalRateLimitKey = "global"
+)
 
 const (
-	authHeader = "X-API-Key"
+	signatureHeader          = "X-Signature"
+	signatureKeyIDHeader     = "X-Signature-Key-ID"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	signatureNonceHeader     = "X-Signature-Nonce"
 )
 
-// authenticate is a middleware that checks for a valid API key in the request.
-func authenticate(keys map[string]struct{}, next http.Handler) http.Handler {
+// KeyAuthenticator is the interface that wraps around method Authenticate.
+type KeyAuthenticator interface {
+	Authenticate(key string) (auth.Identity, error)
+}
+
+// TokenValidator is the interface that wraps around method Validate.
+type TokenValidator interface {
+	Validate(token string) (auth.Identity, error)
+}
+
+// SignatureVerifier is the interface that wraps around method Verify.
+type SignatureVerifier interface {
+	Verify(s auth.Signature) (auth.Identity, error)
+}
+
+// RateLimiter is the interface that wraps around method Allow.
+type RateLimiter interface {
+	Allow(key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// authenticate is a middleware that checks for a valid request signature,
+// bearer token or API key in the request, depending on which are enabled
+// in the provided security. A signature is checked first, then a bearer
+// token and last an API key. The body of a signed request is read before
+// the handler, and is limited to maxBodySize. The identity of the client
+// is added to the request context.
+func authenticate(security Security, maxBodySize int64, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var identity auth.Identity
+		var err error
+		if len(r.Header.Get(signatureHeader)) > 0 && security.Signatures != nil {
+			body, err := readBody(w, r, maxBodySize)
+			if err != nil {
+				writeProblem(w, r, bodyErrorProblem(err))
+				return
+			}
+			if identity, err = verifySignature(r, body, security.Signatures); err != nil {
+				writeProblem(w, r, signatureErrorProblem(err))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+			return
+		}
+
+		if token, ok := bearerToken(r); ok && security.Tokens != nil {
+			if identity, err = security.Tokens.Validate(token); err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeProblem(w, r, newProblem(http.StatusUnauthorized, codeUnauthorized, "Invalid bearer token."))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+			return
+		}
+
 		key := r.Header.Get(authHeader)
+		if len(key) == 0 || security.Keys == nil {
+			if security.Tokens != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			writeProblem(w, r, newProblem(http.StatusUnauthorized, codeUnauthorized, missingCredentials(security)))
+			return
+		}
+		if identity, err = security.Keys.Authenticate(key); err != nil {
+			if errors.Is(err, auth.ErrKeyExpired) {
+				writeProblem(w, r, newProblem(http.StatusUnauthorized, codeUnauthorized, "API key has expired."))
+				return
+			}
+			writeProblem(w, r, newProblem(http.StatusUnauthorized, codeUnauthorized, "Invalid API key."))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+	})
+}
+
+// authorize is a middleware that checks that the authenticated client has
+// the provided scope. The topics of reports are checked by the handlers,
+// once the reports are read (see server.forbiddenTopic).
+func authorize(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := auth.IdentityFromContext(r.Context())
+		if !identity.HasScope(scope) {
+			writeProblem(w, r, newProblem(http.StatusForbidden, codeForbidden, "Client is missing scope "+scope+"."))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimit is a middleware that limits the rate of requests of the
+// authenticated client, with the limit of the client or the default limit.
+// Requests are allowed if the limiter fails, unless the rate limit fails
+// closed.
+func rateLimit(rl RateLimit, log log, next http.Handler) http.Handler {
+	if rl.Limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := auth.IdentityFromContext(r.Context())
+		limit := rl.Limit
+		if identity.Limit != nil {
+			limit = *identity.Limit
+		}
+		if limit.IsZero() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := identity.Name
 		if len(key) == 0 {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			key = globalRateLimitKey
+		}
+
+		result, err := rl.Limiter.Allow(key, limit)
+		if err != nil {
+			log.Error("Error checking rate limit.", "error", err, "client", identity.Name)
+			if rl.OnError != nil {
+				rl.OnError(err)
+			}
+			if rl.FailClosed {
+				writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeUnavailable, "The rate limiter is unavailable."))
+				return
+			}
+			next.ServeHTTP(w, r)
 			return
 		}
-		if _, ok := keys[key]; !ok {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			writeProblem(w, r, newProblem(http.StatusTooManyRequests, codeRateLimited, "Rate limit exceeded."))
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// bearerToken returns the bearer token of the request, if any.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+	return header[len(bearerPrefix):], true
+}
+
+// readBody reads the body of the request, limited to maxBodySize. The body
+// is replaced so that it can be read again.
+func readBody(w http.ResponseWriter, r *http.Request, maxBodySize int64) ([]byte, error) {
+	limitBody(w, r, maxBodySize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// verifySignature verifies the signature of the request with the provided
+// body.
+func verifySignature(r *http.Request, body []byte, signatures SignatureVerifier) (auth.Identity, error) {
+	return signatures.Verify(auth.Signature{
+		KeyID:     r.Header.Get(signatureKeyIDHeader),
+		Timestamp: r.Header.Get(signatureTimestampHeader),
+		Nonce:     r.Header.Get(signatureNonceHeader),
+		Method:    r.Method,
+		Path:      r.URL.EscapedPath(),
+		Query:     r.URL.RawQuery,
+		Body:      body,
+		Signature: r.Header.Get(signatureHeader),
+	})
+}
+
+// signatureErrorProblem returns a Problem for an error from verifying a
+// request signature.
+func signatureErrorProblem(err error) Problem {
+	switch {
+	case errors.Is(err, auth.ErrStaleTimestamp):
+		return newProblem(http.StatusUnauthorized, codeUnauthorized, "Request signature timestamp is outside of the allowed window.")
+	case errors.Is(err, auth.ErrNonceUsed):
+		return newProblem(http.StatusUnauthorized, codeUnauthorized, "Request signature nonce has already been used.")
+	case errors.Is(err, auth.ErrInvalidSignature):
+		return newProblem(http.StatusUnauthorized, codeUnauthorized, "Invalid request signature.")
+	default:
+		return newProblem(http.StatusServiceUnavailable, codeUnavailable, "The nonce store is unavailable.")
+	}
+}
+
+// missingCredentials returns the detail for a request without valid
+// credentials for the enabled schemes.
+func missingCredentials(security Security) string {
+	var schemes []string
+	if security.Keys != nil || (security.Tokens == nil && security.Signatures == nil) {
+		schemes = append(schemes, "API key")
+	}
+	if security.Tokens != nil {
+		schemes = append(schemes, "bearer token")
+	}
+	if security.Signatures != nil {
+		schemes = append(schemes, "request signature")
+	}
+	if len(schemes) == 1 {
+		return "Missing " + schemes[0] + "."
+	}
+	return "Missing " + strings.Join(schemes[:len(schemes)-1], ", ") + " or " + schemes[len(schemes)-1] + "."
+}
+
+// clientName returns the name of the authenticated client of the request.
+func clientName(r *http.Request) string {
+	identity, _ := auth.IdentityFromContext(r.Context())
+	return identity.Name
+}
@@ -1,9 +1,18 @@
 package server
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
+	"github.com/google/go-cmp/cmp"
 )
 
 // TestAuthenticate tests the authenticate middleware with table-driven tests.
@@ -11,37 +20,39 @@ func TestAuthenticate(t *testing.T) {
 	var tests = []struct {
 		name  string
 		input struct {
-			keys map[string]struct{}
-			req  func() *http.Request
+			security Security
+			req      func() *http.Request
 		}
-		wantCode int
+		wantCode   int
+		wantClient string
 	}{
 		{
 			name: "valid key",
 			input: struct {
-				keys map[string]struct{}
-				req  func() *http.Request
+				security Security
+				req      func() *http.Request
 			}{
-				keys: map[string]struct{}{
-					"valid-key": {},
-				},
+				security: Security{Keys: mockKeys{
+					"valid-key": "",
+				}},
 				req: func() *http.Request {
 					req := httptest.NewRequest("GET", "/", nil)
 					req.Header.Set(authHeader, "valid-key")
 					return req
 				},
 			},
-			wantCode: http.StatusOK,
+			wantCode:   http.StatusOK,
+			wantClient: "valid-key",
 		},
 		{
 			name: "missing key",
 			input: struct {
-				keys map[string]struct{}
-				req  func() *http.Request
+				security Security
+				req      func() *http.Request
 			}{
-				keys: map[string]struct{}{
-					"valid-key": {},
-				},
+				security: Security{Keys: mockKeys{
+					"valid-key": "",
+				}},
 				req: func() *http.Request {
 					req := httptest.NewRequest("GET", "/", nil)
 					return req
@@ -52,12 +63,12 @@ func TestAuthenticate(t *testing.T) {
 		{
 			name: "invalid key",
 			input: struct {
-				keys map[string]struct{}
-				req  func() *http.Request
+				security Security
+				req      func() *http.Request
 			}{
-				keys: map[string]struct{}{
-					"valid-key": {},
-				},
+				security: Security{Keys: mockKeys{
+					"valid-key": "",
+				}},
 				req: func() *http.Request {
 					req := httptest.NewRequest("GET", "/", nil)
 					req.Header.Set(authHeader, "invalid-key")
@@ -66,6 +77,99 @@ func TestAuthenticate(t *testing.T) {
 			},
 			wantCode: http.StatusUnauthorized,
 		},
+		{
+			name: "no keys",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set(authHeader, "valid-key")
+					return req
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid token",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{Keys: mockKeys{"valid-key": ""}, Tokens: mockTokens{"valid-token": "client"}},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set("Authorization", "Bearer valid-token")
+					return req
+				},
+			},
+			wantCode:   http.StatusOK,
+			wantClient: "client",
+		},
+		{
+			name: "invalid token",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{Keys: mockKeys{"valid-key": ""}, Tokens: mockTokens{"valid-token": "client"}},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set("Authorization", "Bearer invalid-token")
+					req.Header.Set(authHeader, "valid-key")
+					return req
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid key with tokens enabled",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{Keys: mockKeys{"valid-key": ""}, Tokens: mockTokens{"valid-token": "client"}},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set(authHeader, "valid-key")
+					return req
+				},
+			},
+			wantCode:   http.StatusOK,
+			wantClient: "valid-key",
+		},
+		{
+			name: "key with only tokens enabled",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{Tokens: mockTokens{"valid-token": "client"}},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set(authHeader, "valid-key")
+					return req
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "token with only keys enabled",
+			input: struct {
+				security Security
+				req      func() *http.Request
+			}{
+				security: Security{Keys: mockKeys{"valid-key": ""}},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.Header.Set("Authorization", "Bearer valid-token")
+					return req
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
 	}
 
 	for _, test := range tests {
@@ -73,8 +177,10 @@ func TestAuthenticate(t *testing.T) {
 			// Create a mock response recorder.
 			rr := httptest.NewRecorder()
 
-			// Create a mock handler.
+			// Create a mock handler that records the client.
+			var gotClient string
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotClient = clientName(r)
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -82,13 +188,455 @@ func TestAuthenticate(t *testing.T) {
 			req := test.input.req()
 
 			// Call the authenticate middleware with the mock handler.
-			authenticate(test.input.keys, handler).ServeHTTP(rr, req)
+			authenticate(test.input.security, 0, handler).ServeHTTP(rr, req)
 
 			// Check the status code.
 			if status := rr.Code; status != test.wantCode {
 				t.Errorf("handler returned wrong status code: got %v want %v\n",
 					status, test.wantCode)
 			}
+			if gotClient != test.wantClient {
+				t.Errorf("handler returned wrong client: got %q want %q\n", gotClient, test.wantClient)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_Signature(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			signatures mockSignatures
+			headers    map[string]string
+			body       string
+		}
+		wantCode   int
+		wantClient string
+		wantBody   string
+	}{
+		{
+			name: "valid signature",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{},
+				headers: map[string]string{
+					signatureHeader:      "signature",
+					signatureKeyIDHeader: "client",
+				},
+				body: `{"data":"ZGF0YQ=="}`,
+			},
+			wantCode:   http.StatusOK,
+			wantClient: "client",
+			wantBody:   `{"data":"ZGF0YQ=="}`,
+		},
+		{
+			name: "invalid signature",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{err: auth.ErrInvalidSignature},
+				headers: map[string]string{
+					signatureHeader: "signature",
+					authHeader:      "valid-key",
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "used nonce",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{err: auth.ErrNonceUsed},
+				headers: map[string]string{
+					signatureHeader: "signature",
+				},
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "store error",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{err: errors.New("error")},
+				headers: map[string]string{
+					signatureHeader: "signature",
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "body too large",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{},
+				headers: map[string]string{
+					signatureHeader: "signature",
+				},
+				body: strings.Repeat("a", 65),
+			},
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "without signature",
+			input: struct {
+				signatures mockSignatures
+				headers    map[string]string
+				body       string
+			}{
+				signatures: mockSignatures{},
+				headers: map[string]string{
+					authHeader: "valid-key",
+				},
+			},
+			wantCode:   http.StatusOK,
+			wantClient: "valid-key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			var gotClient, gotBody string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotClient = clientName(r)
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/reports", strings.NewReader(test.input.body))
+			for k, v := range test.input.headers {
+				req.Header.Set(k, v)
+			}
+
+			security := Security{Keys: mockKeys{"valid-key": ""}, Signatures: test.input.signatures}
+			authenticate(security, 64, handler).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.wantCode {
+				t.Errorf("handler returned wrong status code: got %v want %v\n", status, test.wantCode)
+			}
+			if gotClient != test.wantClient {
+				t.Errorf("handler returned wrong client: got %q want %q\n", gotClient, test.wantClient)
+			}
+			if gotBody != test.wantBody {
+				t.Errorf("handler returned wrong body: got %q want %q\n", gotBody, test.wantBody)
+			}
 		})
 	}
 }
+
+func TestAuthenticate_SignatureBodyError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/reports", iotest.ErrReader(errors.New("error")))
+	req.Header.Set(signatureHeader, "signature")
+
+	security := Security{Signatures: mockSignatures{}}
+	authenticate(security, 64, handler).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v\n", status, http.StatusBadRequest)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			identity auth.Identity
+			scope    string
+		}
+		wantCode int
+	}{
+		{
+			name: "with scope",
+			input: struct {
+				identity auth.Identity
+				scope    string
+			}{
+				identity: auth.Identity{Name: "client", Scopes: []string{auth.ScopeReportsWrite}},
+				scope:    auth.ScopeReportsWrite,
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "missing scope",
+			input: struct {
+				identity auth.Identity
+				scope    string
+			}{
+				identity: auth.Identity{Name: "client", Scopes: []string{auth.ScopeReportsRead}},
+				scope:    auth.ScopeReportsWrite,
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "without identity",
+			input: struct {
+				identity auth.Identity
+				scope    string
+			}{
+				scope: auth.ScopeReportsRead,
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/reports", nil)
+			if len(test.input.identity.Name) > 0 {
+				req = req.WithContext(auth.WithIdentity(req.Context(), test.input.identity))
+			}
+
+			authorize(test.input.scope, handler).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.wantCode {
+				t.Errorf("handler returned wrong status code: got %v want %v\n", status, test.wantCode)
+			}
+			if rr.Code == http.StatusForbidden && rr.Header().Get("Content-Type") != problemContentType {
+				t.Errorf("handler returned wrong content type: got %q want %q\n", rr.Header().Get("Content-Type"), problemContentType)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			rateLimit RateLimit
+			identity  auth.Identity
+		}
+		wantCode    int
+		wantKey     string
+		wantLimit   ratelimit.Limit
+		wantHeaders map[string]string
+		wantErrors  int
+	}{
+		{
+			name: "allowed",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter: &mockLimiter{result: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Millisecond * 100}},
+					Limit:   ratelimit.Limit{Rate: 10, Burst: 10},
+				},
+				identity: auth.Identity{Name: "client"},
+			},
+			wantCode:  http.StatusOK,
+			wantKey:   "client",
+			wantLimit: ratelimit.Limit{Rate: 10, Burst: 10},
+			wantHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "9",
+				"RateLimit-Reset":     "1",
+			},
+		},
+		{
+			name: "limited",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter: &mockLimiter{result: ratelimit.Result{Limit: 10, Reset: time.Second * 2, RetryAfter: time.Millisecond * 1500}},
+					Limit:   ratelimit.Limit{Rate: 10, Burst: 10},
+				},
+				identity: auth.Identity{Name: "client", Limit: &ratelimit.Limit{Rate: 5, Burst: 10}},
+			},
+			wantCode:  http.StatusTooManyRequests,
+			wantKey:   "client",
+			wantLimit: ratelimit.Limit{Rate: 5, Burst: 10},
+			wantHeaders: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "2",
+				"Retry-After":         "2",
+				"Content-Type":        problemContentType,
+			},
+		},
+		{
+			name: "without client",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter: &mockLimiter{result: ratelimit.Result{Allowed: true}},
+					Limit:   ratelimit.Limit{Rate: 10, Burst: 10},
+				},
+			},
+			wantCode:  http.StatusOK,
+			wantKey:   globalRateLimitKey,
+			wantLimit: ratelimit.Limit{Rate: 10, Burst: 10},
+		},
+		{
+			name: "without limit",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter: &mockLimiter{},
+				},
+				identity: auth.Identity{Name: "client"},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "limiter error",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter: &mockLimiter{err: errors.New("error")},
+					Limit:   ratelimit.Limit{Rate: 10, Burst: 10},
+				},
+				identity: auth.Identity{Name: "client"},
+			},
+			wantCode:   http.StatusOK,
+			wantKey:    "client",
+			wantLimit:  ratelimit.Limit{Rate: 10, Burst: 10},
+			wantErrors: 1,
+		},
+		{
+			name: "limiter error fail closed",
+			input: struct {
+				rateLimit RateLimit
+				identity  auth.Identity
+			}{
+				rateLimit: RateLimit{
+					Limiter:    &mockLimiter{err: errors.New("error")},
+					Limit:      ratelimit.Limit{Rate: 10, Burst: 10},
+					FailClosed: true,
+				},
+				identity: auth.Identity{Name: "client"},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantKey:    "client",
+			wantLimit:  ratelimit.Limit{Rate: 10, Burst: 10},
+			wantErrors: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/reports", nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), test.input.identity))
+
+			var gotErrors int
+			rl := test.input.rateLimit
+			rl.OnError = func(err error) {
+				gotErrors++
+			}
+
+			rateLimit(rl, mockLogger{}, handler).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.wantCode {
+				t.Errorf("handler returned wrong status code: got %v want %v\n", status, test.wantCode)
+			}
+			limiter := test.input.rateLimit.Limiter.(*mockLimiter)
+			if limiter.key != test.wantKey {
+				t.Errorf("handler used wrong key: got %q want %q\n", limiter.key, test.wantKey)
+			}
+			if diff := cmp.Diff(test.wantLimit, limiter.limit); diff != "" {
+				t.Errorf("handler used wrong limit, (-want +got):\n%s\n", diff)
+			}
+			for k, v := range test.wantHeaders {
+				if got := rr.Header().Get(k); got != v {
+					t.Errorf("handler returned wrong header %s: got %q want %q\n", k, got, v)
+				}
+			}
+			if gotErrors != test.wantErrors {
+				t.Errorf("handler reported wrong number of errors: got %d want %d\n", gotErrors, test.wantErrors)
+			}
+		})
+	}
+}
+
+// mockKeys maps keys to the name of their client. The key is used as
+// name if no name is set.
+type mockKeys map[string]string
+
+func (k mockKeys) Authenticate(key string) (auth.Identity, error) {
+	name, ok := k[key]
+	if !ok {
+		return auth.Identity{}, auth.ErrInvalidKey
+	}
+	if len(name) == 0 {
+		name = key
+	}
+	return auth.Identity{Name: name}, nil
+}
+
+// mockTokens maps tokens to the name of their client.
+type mockTokens map[string]string
+
+func (t mockTokens) Validate(token string) (auth.Identity, error) {
+	name, ok := t[token]
+	if !ok {
+		return auth.Identity{}, auth.ErrInvalidToken
+	}
+	return auth.Identity{Name: name}, nil
+}
+
+// mockSignatures accepts every signature with a matching method and path
+// unless err is set.
+type mockSignatures struct {
+	err error
+}
+
+func (s mockSignatures) Verify(signature auth.Signature) (auth.Identity, error) {
+	if s.err != nil {
+		return auth.Identity{}, s.err
+	}
+	if signature.Method != "POST" || signature.Path != "/reports" {
+		return auth.Identity{}, auth.ErrInvalidSignature
+	}
+	return auth.Identity{Name: signature.KeyID}, nil
+}
+
+type mockLimiter struct {
+	result ratelimit.Result
+	err    error
+	key    string
+	limit  ratelimit.Limit
+}
+
+func (l *mockLimiter) Allow(key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	l.key = key
+	l.limit = limit
+	return l.result, l.err
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "/problems/"
+)
+
+const (
+	// retryAfter is the number of seconds a client should wait before
+	// retrying a request when a downstream dependency is unavailable.
+	retryAfter = 5
+)
+
+// Problem codes.
+const (
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeMethodNotAllowed     = "method-not-allowed"
+	codeNotFound             = "not-found"
+	codeInvalidBody          = "invalid-body"
+	codeInvalidReport        = "invalid-report"
+	codeInvalidParameter     = "invalid-parameter"
+	codeInvalidDeadline      = "invalid-deadline"
+	codeBodyTooLarge         = "body-too-large"
+	codeUnsupportedMediaType = "unsupported-media-type"
+	codeEmptyBatch           = "empty-batch"
+	codeBatchTooLarge        = "batch-too-large"
+	codeDuplicateID          = "duplicate-id"
+	codeReportExists         = "report-exists"
+	codeRequestInProgress    = "request-in-progress"
+	codeIdempotencyKeyReused = "idempotency-key-reused"
+	codeRateLimited          = "rate-limited"
+	codeQuotaExceeded        = "quota-exceeded"
+	codeStatusDisabled       = "status-disabled"
+	codeUsageDisabled        = "usage-disabled"
+	codeUnavailable          = "unavailable"
+	codeCircuitOpen          = "circuit-open"
+	codeNotReady             = "not-ready"
+	codeTimeout              = "timeout"
+	codeInternal             = "internal"
+)
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// retryAfter is the time a client should wait before retrying a
+	// request that is unavailable. The default is used if 0.
+	retryAfter time.Duration
+}
+
+// newProblem creates a new Problem with the provided status, code and detail.
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// JSON returns the JSON representation of the problem.
+func (p Problem) JSON() []byte {
+	b, _ := json.Marshal(&p)
+	return b
+}
+
+// writeProblem writes the problem as the response. The request path and
+// request ID are added to the problem.
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = requestID(r)
+	if p.Status == http.StatusServiceUnavailable {
+		seconds := retryAfter
+		if p.retryAfter > 0 {
+			seconds = max(1, ceilSeconds(p.retryAfter))
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(p.JSON())
+}
+
+// reportErrorProblem returns a Problem for an error returned from the report
+// service. Errors from an unavailable downstream dependency, an open circuit
+// breaker or an expired timeout are told apart from other errors.
+func reportErrorProblem(err error) Problem {
+	var circuitErr *report.CircuitOpenError
+	switch {
+	case errors.As(err, &circuitErr):
+		p := newProblem(http.StatusServiceUnavailable, codeCircuitOpen, "The report service is failing and does not accept reports right now.")
+		p.retryAfter = circuitErr.RetryAfter
+		return p
+	case errors.Is(err, report.ErrReportExists):
+		return newProblem(http.StatusConflict, codeReportExists, "A report with the same ID already exists.")
+	case errors.Is(err, report.ErrUnavailable):
+		return newProblem(http.StatusServiceUnavailable, codeUnavailable, "The report service is unavailable.")
+	case errors.Is(err, report.ErrTimeout):
+		return newProblem(http.StatusGatewayTimeout, codeTimeout, "The report service did not respond in time.")
+	default:
+		return newProblem(http.StatusInternalServerError, codeInternal, "An internal error occurred.")
+	}
+}
+
+// bodyErrorProblem returns a Problem for an error from reading or decoding
+// a request body.
+func bodyErrorProblem(err error) Problem {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return newProblem(http.StatusRequestEntityTooLarge, codeBodyTooLarge, "Request body must not be larger than "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes.")
+	}
+	if errors.Is(err, errReadBody) {
+		return newProblem(http.StatusBadRequest, codeInvalidBody, "Request body could not be read.")
+	}
+	return newProblem(http.StatusBadRequest, codeInvalidBody, "Request body is not valid JSON.")
+}
+
+// methodNotAllowed writes a method not allowed problem with the allowed method.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeProblem(w, r, newProblem(http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method "+r.Method+" is not allowed."))
+}
+
+// hasJSONContentType returns true if the request has no content type or a
+// JSON content type.
+func hasJSONContentType(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	if len(ct) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json"
+}
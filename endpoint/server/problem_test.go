@@ -0,0 +1,185 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteProblem(t *testing.T) {
+	var tests = []struct {
+		name           string
+		input          Problem
+		wantRetryAfter string
+		want           Problem
+	}{
+		{
+			name:  "Bad request",
+			input: newProblem(http.StatusBadRequest, codeInvalidBody, "detail"),
+			want: Problem{
+				Type:      problemTypePrefix + codeInvalidBody,
+				Title:     "Bad Request",
+				Status:    http.StatusBadRequest,
+				Detail:    "detail",
+				Instance:  "/reports",
+				Code:      codeInvalidBody,
+				RequestID: "request-id",
+			},
+		},
+		{
+			name:           "Service unavailable",
+			input:          newProblem(http.StatusServiceUnavailable, codeUnavailable, "detail"),
+			wantRetryAfter: "5",
+			want: Problem{
+				Type:      problemTypePrefix + codeUnavailable,
+				Title:     "Service Unavailable",
+				Status:    http.StatusServiceUnavailable,
+				Detail:    "detail",
+				Instance:  "/reports",
+				Code:      codeUnavailable,
+				RequestID: "request-id",
+			},
+		},
+		{
+			name:           "Circuit open",
+			input:          reportErrorProblem(&report.CircuitOpenError{RetryAfter: time.Millisecond * 12500}),
+			wantRetryAfter: "13",
+			want: Problem{
+				Type:      problemTypePrefix + codeCircuitOpen,
+				Title:     "Service Unavailable",
+				Status:    http.StatusServiceUnavailable,
+				Detail:    "The report service is failing and does not accept reports right now.",
+				Instance:  "/reports",
+				Code:      codeCircuitOpen,
+				RequestID: "request-id",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/reports", nil)
+			req.Header.Set(requestIDHeader, "request-id")
+			w := httptest.NewRecorder()
+
+			writeProblem(w, req, test.input)
+
+			resp := w.Result()
+			if resp.StatusCode != test.want.Status {
+				t.Errorf("writeProblem() = unexpected status, want %d, got: %d\n", test.want.Status, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Retry-After"); got != test.wantRetryAfter {
+				t.Errorf("writeProblem() = unexpected Retry-After, want %q, got: %q\n", test.wantRetryAfter, got)
+			}
+			if got := resp.Header.Get("Content-Type"); got != problemContentType {
+				t.Errorf("writeProblem() = unexpected Content-Type, want %q, got: %q\n", problemContentType, got)
+			}
+			if diff := cmp.Diff(string(test.want.JSON()), w.Body.String()); diff != "" {
+				t.Errorf("writeProblem() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReportErrorProblem(t *testing.T) {
+	var tests = []struct {
+		name       string
+		input      error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "Report exists",
+			input:      report.ErrReportExists,
+			wantStatus: http.StatusConflict,
+			wantCode:   codeReportExists,
+		},
+		{
+			name:       "Unavailable",
+			input:      fmt.Errorf("%w: error", report.ErrUnavailable),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   codeUnavailable,
+		},
+		{
+			name:       "Circuit open",
+			input:      &report.CircuitOpenError{},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   codeCircuitOpen,
+		},
+		{
+			name:       "Timeout",
+			input:      fmt.Errorf("%w: error", report.ErrTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   codeTimeout,
+		},
+		{
+			name:       "Other",
+			input:      errors.New("error"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   codeInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := reportErrorProblem(test.input)
+
+			if got.Status != test.wantStatus || got.Code != test.wantCode {
+				t.Errorf("reportErrorProblem() = unexpected result, want %d %s, got: %d %s\n", test.wantStatus, test.wantCode, got.Status, got.Code)
+			}
+		})
+	}
+}
+
+func TestBodyErrorProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"id":"123"}`))
+	body := http.MaxBytesReader(w, req.Body, 4)
+	_, err := body.Read(make([]byte, 16))
+
+	if got := bodyErrorProblem(err); got.Status != http.StatusRequestEntityTooLarge || got.Code != codeBodyTooLarge {
+		t.Errorf("bodyErrorProblem() = unexpected result, want %d %s, got: %d %s\n", http.StatusRequestEntityTooLarge, codeBodyTooLarge, got.Status, got.Code)
+	}
+
+	if got := bodyErrorProblem(fmt.Errorf("%w: %w", errReadBody, io.ErrUnexpectedEOF)); got.Detail != "Request body could not be read." {
+		t.Errorf("bodyErrorProblem() = unexpected result, want read error, got: %s\n", got.Detail)
+	}
+
+	if got := bodyErrorProblem(errors.New("error")); got.Status != http.StatusBadRequest || got.Code != codeInvalidBody {
+		t.Errorf("bodyErrorProblem() = unexpected result, want %d %s, got: %d %s\n", http.StatusBadRequest, codeInvalidBody, got.Status, got.Code)
+	}
+}
+
+func TestHasJSONContentType(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  bool
+	}{
+		{input: "", want: true},
+		{input: "application/json", want: true},
+		{input: "application/json; charset=utf-8", want: true},
+		{input: "application/x-www-form-urlencoded", want: false},
+		{input: "text/plain", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/reports", nil)
+			if len(test.input) > 0 {
+				req.Header.Set("Content-Type", test.input)
+			}
+
+			if got := hasJSONContentType(req); got != test.want {
+				t.Errorf("hasJSONContentType(%q) = unexpected result, want %v, got: %v\n", test.input, test.want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package server
 
-import "encoding/json"
+import (
+	"encoding/json"
 
-// Report is a incoming report request.
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+// Report is a incoming report request. Type, Priority and Labels are
+// optional attributes the report can be routed on.
 type Report struct {
-	ID   string `json:"id"`
-	Data []byte `json:"data"`
+	ID       string            `json:"id"`
+	Data     []byte            `json:"data,omitempty"`
+	Type     string            `json:"type,omitempty"`
+	Priority int               `json:"priority,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
 }
 
 // JSON returns the JSON representation of the message.
@@ -13,3 +21,36 @@ func (r Report) JSON() []byte {
 	b, _ := json.Marshal(&r)
 	return b
 }
+
+// BatchResult is the result for a report in a batch request. ReportedBy
+// is the name of the reporter that accepted the report, if it was sent
+// with one of several reporters, and Deliveries are the results of the
+// reporters, if it was sent with several reporters.
+type BatchResult struct {
+	ID         string            `json:"id,omitempty"`
+	Status     int               `json:"status"`
+	Location   string            `json:"location,omitempty"`
+	ReportedBy string            `json:"reportedBy,omitempty"`
+	Deliveries []report.Delivery `json:"deliveries,omitempty"`
+	Error      *Problem          `json:"error,omitempty"`
+}
+
+// newBatchError creates a new BatchResult from the provided problem.
+func newBatchError(id string, p Problem) BatchResult {
+	return BatchResult{
+		ID:     id,
+		Status: p.Status,
+		Error:  &p,
+	}
+}
+
+// BatchResponse is the response for a batch request.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// JSON returns the JSON representation of the batch response.
+func (r BatchResponse) JSON() []byte {
+	b, _ := json.Marshal(&r)
+	return b
+}
@@ -1,6 +1,47 @@
 package server
 
-// routes setups registers routes and handlers for the server.
+import (
+	"net/http"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+)
+
+// routes setups registers routes and handlers for the server. Every route
+// declares the scope it requires, except for the health checks and
+// metrics.
 func (s server) routes() {
-	s.router.Handle("/reports", authenticate(s.security.Keys, s.reportHandler()))
+	s.handle("/reports", s.protect(auth.ScopeReportsWrite, withDeadline(s.reportHandler())))
+	s.handle("/reports:batch", s.protect(auth.ScopeReportsWrite, withDeadline(s.batchHandler())))
+	s.handle("/reports/", s.protect(auth.ScopeReportsRead, s.statusHandler()))
+	s.handle("/usage", s.protect(auth.ScopeReportsRead, s.usageHandler()))
+	s.handle("/admin/usage", s.protect(auth.ScopeAdmin, s.adminUsageHandler()))
+	s.handle("/healthz", s.healthHandler())
+	s.handle("/readyz", s.readyHandler())
+	if s.metrics != nil {
+		s.router.Handle("/metrics", s.metrics.Handler())
+	}
+	s.handle("/", notFoundHandler())
+}
+
+// handle registers the handler for the pattern with a request ID,
+// instrumented with the pattern as route if metrics or tracing are enabled.
+// Health checks are neither traced nor logged.
+func (s server) handle(pattern string, handler http.Handler) {
+	health := pattern == "/healthz" || pattern == "/readyz"
+	if s.tracing != nil && !health {
+		handler = s.tracing.Instrument(pattern, handler)
+	}
+	if s.metrics != nil {
+		handler = s.metrics.Instrument(pattern, handler)
+	}
+	if !health {
+		handler = accessLog(s.accessLog, s.log, handler)
+	}
+	s.router.Handle(pattern, withRequestID(handler))
+}
+
+// protect wraps the handler with the middleware for authentication, rate
+// limiting and authorization with the provided scope.
+func (s server) protect(scope string, next http.Handler) http.Handler {
+	return authenticate(s.security, s.validation.MaxBatchBodySize, recordClient(rateLimit(s.rateLimit, s.log, authorize(scope, next))))
 }
@@ -3,27 +3,38 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"strconv"
-	"syscall"
+	"sync/atomic"
 	"time"
 
+	"github.com/RedeployAB/container-apps-dapr/endpoint/ratelimit"
 	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
 )
 
+// Defaults.
 const (
-	stopTimeout = time.Second * 10
+	defaultPort            = 3000
+	defaultReadTimeout     = time.Second * 15
+	defaultWriteTimeout    = time.Second * 15
+	defaultIdleTimeout     = time.Second * 30
+	defaultShutdownTimeout = time.Second * 10
+	defaultMaxBatchSize    = 100
+)
+
+const (
+	defaultIdempotencyWindow     = time.Hour * 24
+	defaultIdempotencyPendingTTL = time.Minute
 )
 
-// Defaults.
 const (
-	defaultPort         = 3000
-	defaultReadTimeout  = time.Second * 15
-	defaultWriteTimeout = time.Second * 15
-	defaultIdleTimeout  = time.Second * 30
+	defaultMaxBodySize      = 1 << 20
+	defaultMaxBatchBodySize = 16 << 20
+	defaultMaxDataSize      = 128 << 10
 )
 
 // log is the interface that wraps around methods Error and Info.
@@ -38,31 +49,104 @@ type router interface {
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
 }
 
+// Metrics is the interface that wraps around methods Instrument and Handler.
+type Metrics interface {
+	Instrument(route string, next http.Handler) http.Handler
+	Handler() http.Handler
+}
+
+// Tracing is the interface that wraps around method Instrument.
+type Tracing interface {
+	Instrument(route string, next http.Handler) http.Handler
+}
+
 // server represents a server containing a *http.Server, a router (handler) and
 // a logger.
 type server struct {
-	httpServer *http.Server
-	router     router
-	log        log
-	reporter   report.Service
-	security   Security
+	httpServer      *http.Server
+	router          router
+	log             log
+	reporter        report.Service
+	security        Security
+	idempotency     *idempotency
+	validation      Validation
+	uploads         Uploads
+	rateLimit       RateLimit
+	usage           Usage
+	health          HealthChecker
+	circuit         CircuitBreaker
+	metrics         Metrics
+	tracing         Tracing
+	accessLog       AccessLog
+	shutdown        *atomic.Bool
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	topics          func(re report.Report) []string
+	maxBatchSize    int
 }
 
-// Security contains keys for the authenticate middleware.
+// Security contains the authenticators for the authenticate middleware.
+// API keys, bearer tokens or request signatures are disabled if nil.
 type Security struct {
-	Keys map[string]struct{}
+	Keys       KeyAuthenticator
+	Tokens     TokenValidator
+	Signatures SignatureVerifier
+}
+
+// RateLimit contains settings for rate limiting of clients. Rate limiting
+// is disabled if Limiter is nil.
+type RateLimit struct {
+	Limiter RateLimiter
+	// Limit is the default limit for clients without a limit.
+	Limit ratelimit.Limit
+	// FailClosed rejects requests if the limiter fails, instead of
+	// allowing them.
+	FailClosed bool
+	// OnError is called when the limiter fails. Optional.
+	OnError func(err error)
 }
 
 // Options for the server.
 type Options struct {
-	Logger       log
-	Reporter     report.Service
-	Security     Security
+	Logger      log
+	Reporter    report.Service
+	Security    Security
+	Idempotency Idempotency
+	Validation  Validation
+	// Uploads contains settings for raw and multipart uploads.
+	Uploads   Uploads
+	RateLimit RateLimit
+	Usage     Usage
+	// Health checks the readiness of the DAPR sidecar. Readiness only
+	// depends on shutdown if nil.
+	Health HealthChecker
+	// Circuit is the circuit breaker of the primary reporter. Its state is
+	// shown in the health and readiness responses if set.
+	Circuit CircuitBreaker
+	// Metrics records metrics of requests and serves them on /metrics.
+	// Metrics are disabled if nil.
+	Metrics Metrics
+	// Tracing creates a span for every request, except for health checks.
+	// Tracing is disabled if nil.
+	Tracing Tracing
+	// AccessLog logs every request, except for health checks.
+	AccessLog AccessLog
+	// ShutdownDelay is the time the server reports not ready before it
+	// stops, so that it is taken out of rotation.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout is the time in-flight requests have to complete
+	// when the server stops.
+	ShutdownTimeout time.Duration
+	// Topics returns the topics or queues a report is sent to. Clients
+	// with a topic allow-list must allow all of them to send the report.
+	// Topics are not checked if nil.
+	Topics       func(re report.Report) []string
 	Host         string
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	MaxBatchSize int
 }
 
 // New returns a new *server with the provided router and Options.
@@ -82,6 +166,21 @@ func New(router router, options Options) (*server, error) {
 	if options.IdleTimeout == 0 {
 		options.IdleTimeout = defaultIdleTimeout
 	}
+	if options.ShutdownTimeout == 0 {
+		options.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if options.MaxBatchSize == 0 {
+		options.MaxBatchSize = defaultMaxBatchSize
+	}
+	if options.Validation.MaxBodySize == 0 {
+		options.Validation.MaxBodySize = defaultMaxBodySize
+	}
+	if options.Validation.MaxBatchBodySize == 0 {
+		options.Validation.MaxBatchBodySize = defaultMaxBatchBodySize
+	}
+	if options.Validation.MaxDataSize == 0 {
+		options.Validation.MaxDataSize = defaultMaxDataSize
+	}
 	if options.Logger == nil {
 		options.Logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	}
@@ -94,44 +193,104 @@ func New(router router, options Options) (*server, error) {
 		IdleTimeout:  options.IdleTimeout,
 	}
 
-	return &server{
-		router:     router,
-		httpServer: srv,
-		log:        options.Logger,
-		reporter:   options.Reporter,
-		security:   options.Security,
-	}, nil
+	s := &server{
+		router:          router,
+		httpServer:      srv,
+		log:             options.Logger,
+		reporter:        options.Reporter,
+		security:        options.Security,
+		validation:      options.Validation,
+		uploads:         options.Uploads,
+		rateLimit:       options.RateLimit,
+		usage:           options.Usage,
+		health:          options.Health,
+		circuit:         options.Circuit,
+		metrics:         options.Metrics,
+		tracing:         options.Tracing,
+		accessLog:       options.AccessLog,
+		shutdown:        &atomic.Bool{},
+		shutdownDelay:   options.ShutdownDelay,
+		shutdownTimeout: options.ShutdownTimeout,
+		topics:          options.Topics,
+		maxBatchSize:    options.MaxBatchSize,
+	}
+	if options.Idempotency.Store != nil {
+		if options.Idempotency.Window == 0 {
+			options.Idempotency.Window = defaultIdempotencyWindow
+		}
+		if options.Idempotency.PendingTTL == 0 {
+			options.Idempotency.PendingTTL = defaultIdempotencyPendingTTL
+		}
+		s.idempotency = &idempotency{
+			store:      options.Idempotency.Store,
+			window:     options.Idempotency.Window,
+			pendingTTL: options.Idempotency.PendingTTL + idempotencyPendingMargin,
+		}
+	}
+
+	return s, nil
 }
 
-// Start the server.
-func (s server) Start() {
+// Run the server until the context is cancelled or the server fails.
+// When the context is cancelled the server reports not ready for the
+// shutdown delay, and in-flight requests are drained within the shutdown
+// timeout before it stops. Requests that are not drained are cancelled.
+func (s server) Run(ctx context.Context) error {
 	s.routes()
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("server: %w", err)
+	}
+
+	// The contexts of requests are derived from baseCtx, so that they can
+	// be cancelled if they are not drained within the shutdown timeout.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	s.httpServer.BaseContext = func(net.Listener) context.Context {
+		return baseCtx
+	}
+
+	errs := make(chan error, 1)
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.log.Error("Server failed to start.", "error", err)
-			os.Exit(1)
+		if err := s.httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
 		}
 	}()
-	s.log.Info("Server started.", "type", "server", "address", s.httpServer.Addr)
-	sig, err := s.stop()
-	if err != nil {
-		s.log.Error("Error stopping server.", "error", err)
+	s.log.Info("Server started.", "type", "server", "address", ln.Addr().String())
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server: %w", err)
+	case <-ctx.Done():
 	}
-	s.log.Info("Server stopped.", "type", "server", "reason", sig.String())
+
+	if err := s.stop(cancelRequests); err != nil {
+		return fmt.Errorf("stopping server: %w", err)
+	}
+	s.log.Info("Server stopped.", "type", "server", "reason", context.Cause(ctx).Error())
+	return nil
 }
 
-// stop server on SIGINT and SIGTERM.
-func (s server) stop() (os.Signal, error) {
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-stop
+// stop the server. The server reports not ready for the shutdown delay
+// before it stops. If in-flight requests are not drained within the
+// shutdown timeout they are cancelled with cancelRequests, and their
+// connections are closed.
+func (s server) stop(cancelRequests context.CancelFunc) error {
+	if s.shutdown != nil {
+		s.shutdown.Store(true)
+	}
+	time.Sleep(s.shutdownDelay)
 
-	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
 	s.httpServer.SetKeepAlivesEnabled(false)
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		return nil, err
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		cancelRequests()
+		if closeErr := s.httpServer.Close(); closeErr != nil {
+			return errors.Join(err, closeErr)
+		}
 	}
-	return sig, nil
+	return err
 }
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"strconv"
-	"syscall"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -43,6 +46,14 @@ func TestNew(t *testing.T) {
 				router:   &mockRouter{},
 				log:      &slog.Logger{},
 				reporter: &mockReporter{},
+				validation: Validation{
+					MaxBodySize:      defaultMaxBodySize,
+					MaxBatchBodySize: defaultMaxBatchBodySize,
+					MaxDataSize:      defaultMaxDataSize,
+				},
+				shutdown:        &atomic.Bool{},
+				shutdownTimeout: defaultShutdownTimeout,
+				maxBatchSize:    defaultMaxBatchSize,
 			},
 		},
 		{
@@ -53,11 +64,17 @@ func TestNew(t *testing.T) {
 				ReadTimeout:  time.Second * 10,
 				WriteTimeout: time.Second * 10,
 				IdleTimeout:  time.Second * 20,
-				Logger:       mockLogger{},
-				Reporter:     &mockReporter{},
+				MaxBatchSize: 10,
+				Validation: Validation{
+					MaxBodySize:      1024,
+					MaxBatchBodySize: 4096,
+					MaxDataSize:      512,
+				},
+				Logger:   mockLogger{},
+				Reporter: &mockReporter{},
 				Security: Security{
-					Keys: map[string]struct{}{
-						"key": {},
+					Keys: mockKeys{
+						"key": "",
 					},
 				},
 			},
@@ -73,10 +90,53 @@ func TestNew(t *testing.T) {
 				log:      mockLogger{},
 				reporter: &mockReporter{},
 				security: Security{
-					Keys: map[string]struct{}{
-						"key": {},
+					Keys: mockKeys{
+						"key": "",
 					},
 				},
+				validation: Validation{
+					MaxBodySize:      1024,
+					MaxBatchBodySize: 4096,
+					MaxDataSize:      512,
+				},
+				shutdown:        &atomic.Bool{},
+				shutdownTimeout: defaultShutdownTimeout,
+				maxBatchSize:    10,
+			},
+		},
+		{
+			name: "With idempotency",
+			input: Options{
+				Reporter: &mockReporter{},
+				Logger:   mockLogger{},
+				Idempotency: Idempotency{
+					Store: &mockStore{},
+				},
+			},
+			want: &server{
+				httpServer: &http.Server{
+					Addr:         ":" + strconv.Itoa(defaultPort),
+					Handler:      &mockRouter{},
+					ReadTimeout:  defaultReadTimeout,
+					WriteTimeout: defaultWriteTimeout,
+					IdleTimeout:  defaultIdleTimeout,
+				},
+				router:   &mockRouter{},
+				log:      mockLogger{},
+				reporter: &mockReporter{},
+				idempotency: &idempotency{
+					store:      &mockStore{},
+					window:     defaultIdempotencyWindow,
+					pendingTTL: defaultIdempotencyPendingTTL + idempotencyPendingMargin,
+				},
+				validation: Validation{
+					MaxBodySize:      defaultMaxBodySize,
+					MaxBatchBodySize: defaultMaxBatchBodySize,
+					MaxDataSize:      defaultMaxDataSize,
+				},
+				shutdown:        &atomic.Bool{},
+				shutdownTimeout: defaultShutdownTimeout,
+				maxBatchSize:    defaultMaxBatchSize,
 			},
 		},
 	}
@@ -85,7 +145,7 @@ func TestNew(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got, gotErr := New(&mockRouter{}, test.input)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, mockReporter{}), cmpopts.IgnoreUnexported(http.Server{}, slog.Logger{})); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, mockReporter{}, idempotency{}, mockStore{}), cmpopts.IgnoreUnexported(http.Server{}, slog.Logger{}, atomic.Bool{}, sync.Mutex{})); diff != "" {
 				t.Errorf("New(%+v) = unexpected result, (-want, +got)\n%s\n", test.input, diff)
 			}
 
@@ -96,18 +156,27 @@ func TestNew(t *testing.T) {
 	}
 }
 
-func TestServer_Start(t *testing.T) {
+func TestServer_Run(t *testing.T) {
 	var tests = []struct {
-		name string
-		want []string
+		name    string
+		input   string
+		want    []string
+		wantErr bool
 	}{
 		{
-			name: "Start",
+			name:  "Run",
+			input: "localhost:0",
 			want: []string{
 				"Server started.",
 				"Server stopped.",
 			},
 		},
+		{
+			name:    "Listener error",
+			input:   "invalid:address:0",
+			want:    []string{},
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -115,26 +184,99 @@ func TestServer_Start(t *testing.T) {
 			logMessages = []string{}
 			srv := &server{
 				httpServer: &http.Server{
-					Addr: "localhost:3000",
+					Addr: test.input,
 				},
-				router:   &mockRouter{},
-				log:      mockLogger{},
-				reporter: &mockReporter{},
+				router:          &mockRouter{},
+				log:             mockLogger{},
+				reporter:        &mockReporter{},
+				shutdownTimeout: time.Second,
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			err := srv.Run(ctx)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Run() = unexpected error, want error: %v, got: %v\n", test.wantErr, err)
 			}
-			go func() {
-				time.Sleep(time.Millisecond * 100)
-				syscall.Kill(syscall.Getpid(), syscall.SIGINT)
-			}()
-			srv.Start()
 
 			if diff := cmp.Diff(test.want, logMessages); diff != "" {
-				t.Errorf("Start() = unexpected result, (-want, +got)\n%s\n", diff)
+				t.Errorf("Run() = unexpected result, (-want, +got)\n%s\n", diff)
 			}
 			logMessages = []string{}
 		})
 	}
 }
 
+func TestServer_Run_ShutdownTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	srv := &server{
+		httpServer: &http.Server{
+			Addr: addr,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-r.Context().Done()
+				close(cancelled)
+			}),
+		},
+		router:          &mockRouter{},
+		log:             mockLogger{},
+		reporter:        &mockReporter{},
+		shutdownTimeout: time.Millisecond * 50,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Run(ctx)
+	}()
+
+	go func() {
+		for {
+			resp, err := http.Get("http://" + addr)
+			if err == nil {
+				resp.Body.Close()
+				return
+			}
+			select {
+			case <-started:
+				return
+			case <-time.After(time.Millisecond * 10):
+			}
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Run() = request was not started")
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run() = unexpected error, want: %v, got: %v\n", context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Run() = server did not stop")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second * 5):
+		t.Error("Run() = request context was not cancelled")
+	}
+	logMessages = []string{}
+}
+
 type mockRouter struct{}
 
 func (ro mockRouter) Handle(pattern string, handler http.Handler) {}
@@ -154,12 +296,33 @@ func (l mockLogger) Info(msg string, args ...any) {
 }
 
 type mockReporter struct {
-	err error
+	err       error
+	batchErrs map[string]error
+	status    report.Status
 }
 
-func (r mockReporter) Create(report report.Report) error {
+func (r mockReporter) Create(ctx context.Context, report report.Report) error {
 	if r.err != nil {
 		return r.err
 	}
 	return nil
 }
+
+func (r mockReporter) CreateBatch(ctx context.Context, reports []report.Report) []error {
+	errs := make([]error, len(reports))
+	for i, re := range reports {
+		if r.batchErrs != nil {
+			errs[i] = r.batchErrs[re.ID]
+			continue
+		}
+		errs[i] = r.err
+	}
+	return errs
+}
+
+func (r mockReporter) Status(id string) (report.Status, error) {
+	if r.err != nil {
+		return report.Status{}, r.err
+	}
+	return r.status, nil
+}
@@ -0,0 +1,394 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+const (
+	reportIDHeader       = "X-Report-ID"
+	reportTypeHeader     = "X-Report-Type"
+	reportPriorityHeader = "X-Report-Priority"
+	reportLabelsHeader   = "X-Report-Labels"
+)
+
+const (
+	mediaTypeJSON      = "application/json"
+	mediaTypeRaw       = "application/octet-stream"
+	mediaTypeMultipart = "multipart/form-data"
+)
+
+const (
+	// maxFormFieldSize is the maximum size in bytes of a form field other
+	// than the data.
+	maxFormFieldSize = 1024
+	// maxUploadOverhead is the maximum size in bytes of a raw or multipart
+	// request body in addition to the data, for the boundaries, headers
+	// and other fields of multipart requests.
+	maxUploadOverhead = 64 << 10
+)
+
+var (
+	// errFormFieldTooLarge is returned when a form field is larger than
+	// maxFormFieldSize.
+	errFormFieldTooLarge = errors.New("form field too large")
+	// errDataTooLarge is returned when uploaded data is larger than the
+	// data size limit.
+	errDataTooLarge = errors.New("data too large")
+	// errClaimCheckStore is returned when uploaded data could not be
+	// stored with a claim check.
+	errClaimCheckStore = errors.New("claim check store unavailable")
+)
+
+// ClaimCheckStore is the interface that wraps around methods Store and
+// Delete.
+type ClaimCheckStore interface {
+	Store(ctx context.Context, name string, r io.Reader) (int, int, error)
+	Delete(ctx context.Context, name string, parts int)
+}
+
+// Uploads contains settings for raw and multipart uploads.
+type Uploads struct {
+	// Store stores the data of uploads above Threshold with a claim check
+	// as it is read, so that it is not held in memory as a whole. Uploads
+	// are read into memory if nil.
+	Store ClaimCheckStore
+	// Threshold is the size in bytes above which the data of uploads is
+	// streamed to Store.
+	Threshold int
+}
+
+// upload is the data of an uploaded report. The data is either held in
+// memory, or stored with a claim check in parts. Hash is the SHA-256 hash
+// of the data.
+type upload struct {
+	data       []byte
+	claimCheck string
+	parts      int
+	size       int
+	hash       []byte
+}
+
+// newUpload returns an upload of data held in memory.
+func newUpload(data []byte) upload {
+	hash := sha256.Sum256(data)
+	return upload{data: data, size: len(data), hash: hash[:]}
+}
+
+// apply sets the data, or the claim check of the stored data, of the
+// upload on the report.
+func (up upload) apply(re *report.Report) {
+	if len(up.claimCheck) == 0 {
+		return
+	}
+	re.Data = nil
+	re.ClaimCheck = up.claimCheck
+	re.ClaimCheckParts = up.parts
+	re.Size = up.size
+}
+
+// release removes the stored data of the upload, if any.
+func (up upload) release(ctx context.Context, u Uploads) {
+	if len(up.claimCheck) == 0 || u.Store == nil {
+		return
+	}
+	u.Store.Delete(ctx, up.claimCheck, up.parts)
+}
+
+// reportMediaType returns the media type of a report request. Requests without
+// a content type are treated as JSON. Returns false if the media type is not
+// supported.
+func reportMediaType(r *http.Request) (string, bool) {
+	ct := r.Header.Get("Content-Type")
+	if len(ct) == 0 {
+		return mediaTypeJSON, true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case mediaTypeJSON, mediaTypeRaw, mediaTypeMultipart:
+		return mediaType, true
+	}
+	return "", false
+}
+
+// readRawReport reads a report from a raw request body. The body is the data
+// of the report, the ID is read from the X-Report-ID header and the type,
+// priority and labels from the X-Report-Type, X-Report-Priority and
+// X-Report-Labels headers. The data is read once, without decoding, and
+// reading stops at the data size limit (see readData). The stored data
+// of the returned upload must be released if the report is not sent.
+func (v Validation) readRawReport(r *http.Request, u Uploads) (Report, upload, []FieldError, error) {
+	re := Report{ID: r.Header.Get(reportIDHeader)}
+	errs := checkID(re.ID)
+	errs = append(errs, headerAttributes(r.Header).set(&re)...)
+
+	if r.ContentLength > 0 {
+		if dataErrs := v.checkDataSize(int(r.ContentLength)); len(dataErrs) > 0 {
+			return re, upload{}, append(errs, dataErrs...), nil
+		}
+	}
+
+	up, err := v.readData(r.Context(), r.Body, u)
+	if err != nil {
+		return Report{}, upload{}, nil, err
+	}
+	if dataErrs := v.checkDataSize(up.size); len(dataErrs) > 0 {
+		return re, upload{}, append(errs, dataErrs...), nil
+	}
+	re.Data = up.data
+
+	return re, up, errs, nil
+}
+
+// readMultipartReport reads a report from a multipart/form-data request body.
+// The data is read from the file part "data", and the ID, type, priority and
+// labels from the form fields "id", "type", "priority" and "labels", or their
+// headers if the fields are not set. The parts are read as they arrive,
+// without storing them in temporary files, and the data as with
+// readRawReport.
+func (v Validation) readMultipartReport(r *http.Request, u Uploads) (Report, upload, []FieldError, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return Report{}, upload{}, nil, err
+	}
+
+	re := Report{ID: r.Header.Get(reportIDHeader)}
+	attrs := headerAttributes(r.Header)
+	var up upload
+	var errs []FieldError
+	var hasData bool
+	// fail releases the data stored so far and returns the error.
+	fail := func(err error) (Report, upload, []FieldError, error) {
+		up.release(r.Context(), u)
+		return Report{}, upload{}, nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fail(err)
+		}
+
+		switch part.FormName() {
+		case "id":
+			id, err := readFormField(part)
+			if err != nil {
+				return fail(err)
+			}
+			re.ID = id
+		case "type", "priority", "labels":
+			value, err := readFormField(part)
+			if err != nil {
+				return fail(err)
+			}
+			attrs[part.FormName()] = value
+		case "data":
+			if hasData {
+				errs = append(errs, FieldError{Field: "data", Code: fieldCodeInvalidValue, Detail: "Field must only be set once."})
+				continue
+			}
+			hasData = true
+			data, err := v.readData(r.Context(), part, u)
+			if err != nil {
+				return fail(err)
+			}
+			if dataErrs := v.checkDataSize(data.size); len(dataErrs) > 0 {
+				errs = append(errs, dataErrs...)
+				continue
+			}
+			up = data
+			re.Data = up.data
+		default:
+			errs = append(errs, FieldError{Field: part.FormName(), Code: fieldCodeUnknown, Detail: "Field is not allowed."})
+		}
+		part.Close()
+	}
+
+	errs = append(append(checkID(re.ID), attrs.set(&re)...), errs...)
+	if !hasData {
+		errs = append(errs, FieldError{Field: "data", Code: fieldCodeRequired, Detail: "Field is required."})
+	}
+	return re, up, errs, nil
+}
+
+// attributes contains the type, priority and labels of an uploaded report,
+// by field name, as sent in headers or form fields.
+type attributes map[string]string
+
+// headerAttributes returns the attributes set in the headers of a request.
+func headerAttributes(h http.Header) attributes {
+	return attributes{
+		"type":     h.Get(reportTypeHeader),
+		"priority": h.Get(reportPriorityHeader),
+		"labels":   h.Get(reportLabelsHeader),
+	}
+}
+
+// set validates the attributes and sets them on the report. Attributes
+// that are empty are not set. Labels are comma separated key=value pairs.
+func (a attributes) set(re *Report) []FieldError {
+	var errs []FieldError
+	if typ := a["type"]; len(typ) > 0 {
+		re.Type = typ
+		if len(typ) > maxTypeLength {
+			errs = append(errs, FieldError{Field: "type", Code: fieldCodeInvalidValue, Detail: "Field must not be longer than " + strconv.Itoa(maxTypeLength) + " characters."})
+		}
+	}
+
+	if priority := a["priority"]; len(priority) > 0 {
+		p, err := strconv.Atoi(strings.TrimSpace(priority))
+		switch {
+		case err != nil:
+			errs = append(errs, FieldError{Field: "priority", Code: fieldCodeInvalidType, Detail: "Field must be an integer."})
+		case p < 0 || p > maxPriority:
+			errs = append(errs, FieldError{Field: "priority", Code: fieldCodeInvalidValue, Detail: "Field must be between 0 and " + strconv.Itoa(maxPriority) + "."})
+		default:
+			re.Priority = p
+		}
+	}
+
+	if labels := a["labels"]; len(labels) > 0 {
+		parsed, ok := parseLabels(labels)
+		if !ok {
+			errs = append(errs, FieldError{Field: "labels", Code: fieldCodeInvalidType, Detail: "Field must be comma separated key=value pairs."})
+		} else {
+			re.Labels = parsed
+			errs = append(errs, checkLabels(parsed)...)
+		}
+	}
+	return errs
+}
+
+// parseLabels parses comma separated key=value pairs. Keys and values are
+// trimmed of spaces. Returns false if a pair is missing "=".
+func parseLabels(s string) (map[string]string, bool) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, false
+		}
+		labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return labels, true
+}
+
+// maxUploadSize returns the maximum size in bytes of a raw or multipart
+// request body. Uploads are limited by the data size limit, which is the
+// limit of claim checks if enabled, instead of the body size limit of JSON
+// requests. Returns 0, no limit, if there is no data size limit.
+func (v Validation) maxUploadSize() int64 {
+	if v.MaxDataSize <= 0 {
+		return 0
+	}
+	return int64(v.MaxDataSize) + maxUploadOverhead
+}
+
+// readData reads the data of an uploaded report and hashes it as it is
+// read. Data up to the threshold of u is held in memory, larger data is
+// streamed to the claim check store of u in parts. Reading stops at the
+// data size limit, the returned upload then has a size above the limit
+// and no data.
+func (v Validation) readData(ctx context.Context, r io.Reader, u Uploads) (upload, error) {
+	dr := &dataReader{r: r, max: v.MaxDataSize}
+	h := sha256.New()
+	tr := io.TeeReader(dr, h)
+
+	var buf bytes.Buffer
+	var err error
+	if u.Store == nil {
+		_, err = buf.ReadFrom(tr)
+	} else {
+		_, err = buf.ReadFrom(io.LimitReader(tr, int64(u.Threshold)+1))
+	}
+	if errors.Is(err, errDataTooLarge) {
+		return upload{size: dr.n}, nil
+	}
+	if err != nil {
+		return upload{}, err
+	}
+	if u.Store == nil || buf.Len() <= u.Threshold {
+		return upload{data: buf.Bytes(), size: buf.Len(), hash: h.Sum(nil)}, nil
+	}
+
+	id, err := report.NewID()
+	if err != nil {
+		return upload{}, err
+	}
+	name := "upload-" + id + ".data"
+	parts, size, err := u.Store.Store(ctx, name, io.MultiReader(&buf, tr))
+	if err != nil {
+		if errors.Is(dr.err, errDataTooLarge) {
+			return upload{size: dr.n}, nil
+		}
+		if dr.err != nil {
+			return upload{}, dr.err
+		}
+		return upload{}, fmt.Errorf("%w: %w", errClaimCheckStore, err)
+	}
+	return upload{claimCheck: name, parts: parts, size: size, hash: h.Sum(nil)}, nil
+}
+
+// dataReader reads the data of an uploaded report and returns
+// errDataTooLarge once more than max bytes are read, if max is set. The
+// number of bytes read and the first read error are kept.
+type dataReader struct {
+	r   io.Reader
+	max int
+	n   int
+	err error
+}
+
+// Read reads from the underlying reader.
+func (d *dataReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.n += n
+	if d.max > 0 && d.n > d.max {
+		err = errDataTooLarge
+	}
+	if err != nil && !errors.Is(err, io.EOF) && d.err == nil {
+		d.err = err
+	}
+	return n, err
+}
+
+// readFormField reads a form field of at most maxFormFieldSize bytes.
+func readFormField(part *multipart.Part) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(b) > maxFormFieldSize {
+		return "", errFormFieldTooLarge
+	}
+	return string(b), nil
+}
+
+// uploadErrorProblem returns a Problem for an error from reading a raw or
+// multipart request body.
+func uploadErrorProblem(err error, mediaType string) Problem {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, errReadBody) {
+		return bodyErrorProblem(err)
+	}
+	if errors.Is(err, errClaimCheckStore) {
+		return newProblem(http.StatusServiceUnavailable, codeUnavailable, "The claim check store is unavailable.")
+	}
+	return newProblem(http.StatusBadRequest, codeInvalidBody, "Request body is not valid "+mediaType+".")
+}
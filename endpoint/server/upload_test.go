@@ -0,0 +1,662 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportMediaType(t *testing.T) {
+	var tests = []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "Empty", input: "", want: mediaTypeJSON, wantOK: true},
+		{name: "JSON", input: "application/json; charset=utf-8", want: mediaTypeJSON, wantOK: true},
+		{name: "Raw", input: "application/octet-stream", want: mediaTypeRaw, wantOK: true},
+		{name: "Multipart", input: "multipart/form-data; boundary=abc", want: mediaTypeMultipart, wantOK: true},
+		{name: "Unsupported", input: "text/plain", want: "", wantOK: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/reports", nil)
+			req.Header.Set("Content-Type", test.input)
+
+			got, gotOK := reportMediaType(req)
+			if got != test.want || gotOK != test.wantOK {
+				t.Errorf("reportMediaType() = unexpected result, want: %q, %t, got: %q, %t\n", test.want, test.wantOK, got, gotOK)
+			}
+		})
+	}
+}
+
+func TestValidation_ReadRawReport(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			id      string
+			headers map[string]string
+			body    string
+		}
+		want     Report
+		wantErrs []FieldError
+	}{
+		{
+			name: "With ID",
+			input: struct {
+				id      string
+				headers map[string]string
+				body    string
+			}{
+				id:   "123",
+				body: "data",
+			},
+			want: Report{ID: "123", Data: []byte("data")},
+		},
+		{
+			name: "Without ID",
+			input: struct {
+				id      string
+				headers map[string]string
+				body    string
+			}{
+				body: "data",
+			},
+			want: Report{Data: []byte("data")},
+		},
+		{
+			name: "With attribute headers",
+			input: struct {
+				id      string
+				headers map[string]string
+				body    string
+			}{
+				id: "123",
+				headers: map[string]string{
+					reportTypeHeader:     "alert",
+					reportPriorityHeader: "8",
+					reportLabelsHeader:   "region=eu, team = ops",
+				},
+				body: "data",
+			},
+			want: Report{ID: "123", Data: []byte("data"), Type: "alert", Priority: 8, Labels: map[string]string{"region": "eu", "team": "ops"}},
+		},
+		{
+			name: "With invalid attribute headers",
+			input: struct {
+				id      string
+				headers map[string]string
+				body    string
+			}{
+				headers: map[string]string{
+					reportTypeHeader:     strings.Repeat("a", maxTypeLength+1),
+					reportPriorityHeader: "high",
+					reportLabelsHeader:   "region",
+				},
+				body: "data",
+			},
+			want: Report{Data: []byte("data"), Type: strings.Repeat("a", maxTypeLength+1)},
+			wantErrs: []FieldError{
+				{Field: "type", Code: fieldCodeInvalidValue, Detail: "Field must not be longer than 64 characters."},
+				{Field: "priority", Code: fieldCodeInvalidType, Detail: "Field must be an integer."},
+				{Field: "labels", Code: fieldCodeInvalidType, Detail: "Field must be comma separated key=value pairs."},
+			},
+		},
+		{
+			name: "With invalid ID and too large data",
+			input: struct {
+				id      string
+				headers map[string]string
+				body    string
+			}{
+				id:   "../123",
+				body: "data data",
+			},
+			want: Report{ID: "../123"},
+			wantErrs: []FieldError{
+				{Field: "id", Code: fieldCodeInvalidValue, Detail: "Field must start with a letter or digit and only contain letters, digits, '.', '_' and '-'."},
+				{Field: "data", Code: fieldCodeTooLarge, Detail: "Field must not be larger than 8 bytes."},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(test.input.body))
+			req.Header.Set("Content-Type", mediaTypeRaw)
+			if len(test.input.id) > 0 {
+				req.Header.Set(reportIDHeader, test.input.id)
+			}
+			for k, v := range test.input.headers {
+				req.Header.Set(k, v)
+			}
+
+			got, _, gotErrs, gotErr := Validation{MaxDataSize: 8}.readRawReport(req, Uploads{})
+			if gotErr != nil {
+				t.Fatalf("readRawReport() = unexpected error: %v\n", gotErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("readRawReport() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErrs, gotErrs); diff != "" {
+				t.Errorf("readRawReport() = unexpected field errors, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestValidation_ReadMultipartReport(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			id     string
+			fields map[string]string
+			files  map[string]string
+		}
+		want     Report
+		wantErrs []FieldError
+	}{
+		{
+			name: "With ID field",
+			input: struct {
+				id     string
+				fields map[string]string
+				files  map[string]string
+			}{
+				fields: map[string]string{"id": "123"},
+				files:  map[string]string{"data": "data"},
+			},
+			want: Report{ID: "123", Data: []byte("data")},
+		},
+		{
+			name: "With ID header",
+			input: struct {
+				id     string
+				fields map[string]string
+				files  map[string]string
+			}{
+				id:    "123",
+				files: map[string]string{"data": "data"},
+			},
+			want: Report{ID: "123", Data: []byte("data")},
+		},
+		{
+			name: "With attribute fields",
+			input: struct {
+				id     string
+				fields map[string]string
+				files  map[string]string
+			}{
+				fields: map[string]string{"type": "alert", "priority": "10", "labels": "region=eu"},
+				files:  map[string]string{"data": "data"},
+			},
+			want: Report{Data: []byte("data"), Type: "alert", Labels: map[string]string{"region": "eu"}},
+			wantErrs: []FieldError{
+				{Field: "priority", Code: fieldCodeInvalidValue, Detail: "Field must be between 0 and 9."},
+			},
+		},
+		{
+			name: "With every error",
+			input: struct {
+				id     string
+				fields map[string]string
+				files  map[string]string
+			}{
+				fields: map[string]string{"id": "../123", "other": "other"},
+			},
+			want: Report{ID: "../123"},
+			wantErrs: []FieldError{
+				{Field: "id", Code: fieldCodeInvalidValue, Detail: "Field must start with a letter or digit and only contain letters, digits, '.', '_' and '-'."},
+				{Field: "other", Code: fieldCodeUnknown, Detail: "Field is not allowed."},
+				{Field: "data", Code: fieldCodeRequired, Detail: "Field is required."},
+			},
+		},
+		{
+			name: "With too large data",
+			input: struct {
+				id     string
+				fields map[string]string
+				files  map[string]string
+			}{
+				files: map[string]string{"data": "data data"},
+			},
+			wantErrs: []FieldError{
+				{Field: "data", Code: fieldCodeTooLarge, Detail: "Field must not be larger than 8 bytes."},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, contentType := newMultipartBody(t, test.input.fields, test.input.files)
+			req := httptest.NewRequest(http.MethodPost, "/reports", body)
+			req.Header.Set("Content-Type", contentType)
+			if len(test.input.id) > 0 {
+				req.Header.Set(reportIDHeader, test.input.id)
+			}
+
+			got, _, gotErrs, gotErr := Validation{MaxDataSize: 8}.readMultipartReport(req, Uploads{})
+			if gotErr != nil {
+				t.Fatalf("readMultipartReport() = unexpected error: %v\n", gotErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("readMultipartReport() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErrs, gotErrs); diff != "" {
+				t.Errorf("readMultipartReport() = unexpected field errors, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestValidation_ReadData(t *testing.T) {
+	hash := func(s string) []byte {
+		h := sha256.Sum256([]byte(s))
+		return h[:]
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			data  io.Reader
+			store *mockClaimCheckStore
+		}
+		want    upload
+		wantErr error
+	}{
+		{
+			name: "Without store",
+			input: struct {
+				data  io.Reader
+				store *mockClaimCheckStore
+			}{
+				data: strings.NewReader("data data"),
+			},
+			want: upload{data: []byte("data data"), size: 9, hash: hash("data data")},
+		},
+		{
+			name: "Under threshold",
+			input: struct {
+				data  io.Reader
+				store *mockClaimCheckStore
+			}{
+				data:  strings.NewReader("data"),
+				store: &mockClaimCheckStore{},
+			},
+			want: upload{data: []byte("data"), size: 4, hash: hash("data")},
+		},
+		{
+			name: "Over threshold",
+			input: struct {
+				data  io.Reader
+				store *mockClaimCheckStore
+			}{
+				data:  strings.NewReader("data data"),
+				store: &mockClaimCheckStore{},
+			},
+			want: upload{claimCheck: "upload.data", parts: 1, size: 9, hash: hash("data data")},
+		},
+		{
+			name: "Too large",
+			input: struct {
+				data  io.Reader
+				store *mockClaimCheckStore
+			}{
+				data:  strings.NewReader("data data data data"),
+				store: &mockClaimCheckStore{},
+			},
+			want: upload{size: 19},
+		},
+		{
+			name: "Store error",
+			input: struct {
+				data  io.Reader
+				store *mockClaimCheckStore
+			}{
+				data:  strings.NewReader("data data"),
+				store: &mockClaimCheckStore{err: errors.New("error")},
+			},
+			wantErr: errClaimCheckStore,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var u Uploads
+			if test.input.store != nil {
+				u = Uploads{Store: test.input.store, Threshold: 8}
+			}
+
+			got, gotErr := Validation{MaxDataSize: 16}.readData(context.Background(), test.input.data, u)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("readData() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if len(got.claimCheck) > 0 {
+				if !strings.HasPrefix(got.claimCheck, "upload-") || string(test.input.store.data) != "data data" {
+					t.Errorf("readData() = unexpected stored data, got: %q, %q\n", got.claimCheck, test.input.store.data)
+				}
+				got.claimCheck = "upload.data"
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(upload{})); diff != "" {
+				t.Errorf("readData() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReportHandler_Upload(t *testing.T) {
+	multipartBody, multipartType := newMultipartBody(t, map[string]string{"id": "123"}, map[string]string{"data": "data"})
+
+	var tests = []struct {
+		name  string
+		input struct {
+			contentType string
+			body        io.Reader
+		}
+		wantCode   int
+		wantBody   string
+		wantDetail string
+	}{
+		{
+			name: "Raw",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: mediaTypeRaw,
+				body:        strings.NewReader("data"),
+			},
+			wantCode: http.StatusAccepted,
+			wantBody: `{"id":"123"}`,
+		},
+		{
+			name: "Multipart",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: multipartType,
+				body:        multipartBody,
+			},
+			wantCode: http.StatusAccepted,
+			wantBody: `{"id":"123"}`,
+		},
+		{
+			name: "Invalid multipart",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: "multipart/form-data; boundary=abc",
+				body:        strings.NewReader("data"),
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Unsupported media type",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: "text/plain",
+				body:        strings.NewReader("data"),
+			},
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "Raw read error",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: mediaTypeRaw,
+				body:        iotest.ErrReader(io.ErrUnexpectedEOF),
+			},
+			wantCode:   http.StatusBadRequest,
+			wantDetail: "Request body could not be read.",
+		},
+		{
+			name: "Multipart read error",
+			input: struct {
+				contentType string
+				body        io.Reader
+			}{
+				contentType: "multipart/form-data; boundary=abc",
+				body:        iotest.ErrReader(io.ErrUnexpectedEOF),
+			},
+			wantCode:   http.StatusBadRequest,
+			wantDetail: "Request body could not be read.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &mockReporter{}
+			s := &server{
+				reporter:   reporter,
+				log:        &mockLogger{},
+				validation: Validation{MaxBodySize: 1024, MaxDataSize: 8},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", test.input.body)
+			req.Header.Set("Content-Type", test.input.contentType)
+			req.Header.Set(reportIDHeader, "123")
+			w := httptest.NewRecorder()
+
+			s.reportHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			if len(test.wantBody) > 0 {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != test.wantBody {
+					t.Errorf("reportHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+				}
+			}
+			if len(test.wantDetail) > 0 {
+				var got Problem
+				json.NewDecoder(resp.Body).Decode(&got)
+				if got.Detail != test.wantDetail {
+					t.Errorf("reportHandler() = unexpected detail, want %s, got: %s\n", test.wantDetail, got.Detail)
+				}
+			}
+		})
+	}
+}
+
+func newMultipartBody(t *testing.T, fields, files map[string]string) (io.Reader, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	// Write the ID first to get a stable order of field errors.
+	if id, ok := fields["id"]; ok {
+		mw.WriteField("id", id)
+	}
+	for name, value := range fields {
+		if name != "id" {
+			mw.WriteField(name, value)
+		}
+	}
+	for name, value := range files {
+		fw, err := mw.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(value))
+	}
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestReportHandler_LargeUpload(t *testing.T) {
+	data := strings.Repeat("a", 2<<20)
+	multipartBody, multipartType := newMultipartBody(t, map[string]string{"id": "123"}, map[string]string{"data": data})
+
+	var tests = []struct {
+		name  string
+		input struct {
+			contentType string
+			body        io.Reader
+			maxDataSize int
+		}
+		wantCode int
+	}{
+		{
+			name: "Raw",
+			input: struct {
+				contentType string
+				body        io.Reader
+				maxDataSize int
+			}{
+				contentType: mediaTypeRaw,
+				body:        strings.NewReader(data),
+				maxDataSize: 10 << 20,
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Multipart",
+			input: struct {
+				contentType string
+				body        io.Reader
+				maxDataSize int
+			}{
+				contentType: multipartType,
+				body:        multipartBody,
+				maxDataSize: 10 << 20,
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Raw above data size limit",
+			input: struct {
+				contentType string
+				body        io.Reader
+				maxDataSize int
+			}{
+				contentType: mediaTypeRaw,
+				body:        strings.NewReader(data),
+				maxDataSize: 1 << 20,
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter:   &mockReporter{},
+				log:        &mockLogger{},
+				validation: Validation{MaxBodySize: 1 << 20, MaxDataSize: test.input.maxDataSize},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", test.input.body)
+			req.Header.Set("Content-Type", test.input.contentType)
+			req.Header.Set(reportIDHeader, "123")
+			w := httptest.NewRecorder()
+
+			s.reportHandler().ServeHTTP(w, req)
+
+			if got := w.Result().StatusCode; got != test.wantCode {
+				body, _ := io.ReadAll(w.Result().Body)
+				t.Errorf("reportHandler() = unexpected result, want %d, got: %d, %s\n", test.wantCode, got, body)
+			}
+		})
+	}
+}
+
+type mockClaimCheckStore struct {
+	data    []byte
+	deleted []string
+	err     error
+}
+
+func (s *mockClaimCheckStore) Store(ctx context.Context, name string, r io.Reader) (int, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	s.data = data
+	return 1, len(data), nil
+}
+
+func (s *mockClaimCheckStore) Delete(ctx context.Context, name string, parts int) {
+	s.deleted = append(s.deleted, name)
+}
+
+func TestReportHandler_StoredUpload(t *testing.T) {
+	var tests = []struct {
+		name        string
+		input       error
+		wantCode    int
+		wantDeleted []string
+	}{
+		{
+			name:     "Sent",
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:        "Not sent",
+			input:       report.ErrUnavailable,
+			wantCode:    http.StatusServiceUnavailable,
+			wantDeleted: []string{"upload"},
+		},
+		{
+			name:     "Might have been sent",
+			input:    report.ErrTimeout,
+			wantCode: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &mockClaimCheckStore{}
+			s := &server{
+				reporter:   &mockReporter{err: test.input},
+				log:        &mockLogger{},
+				validation: Validation{MaxBodySize: 1024, MaxDataSize: 8},
+				uploads:    Uploads{Store: store, Threshold: 2},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader("data"))
+			req.Header.Set("Content-Type", mediaTypeRaw)
+			req.Header.Set(reportIDHeader, "123")
+			w := httptest.NewRecorder()
+
+			s.reportHandler().ServeHTTP(w, req)
+
+			if w.Code != test.wantCode {
+				t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", test.wantCode, w.Code)
+			}
+			if string(store.data) != "data" {
+				t.Errorf("reportHandler() = unexpected stored data, want: data, got: %s\n", store.data)
+			}
+			for i := range store.deleted {
+				store.deleted[i] = "upload"
+			}
+			if diff := cmp.Diff(test.wantDeleted, store.deleted); diff != "" {
+				t.Errorf("reportHandler() = unexpected deleted data, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
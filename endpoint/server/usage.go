@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// usageReleaseTimeout is the time usage has to be released after the
+	// request is done.
+	usageReleaseTimeout = time.Second * 10
+)
+
+// UsageTracker is the interface that wraps around methods Record, Release,
+// Get and List.
+type UsageTracker interface {
+	Record(ctx context.Context, client string, quota usage.Quota, c usage.Count) (usage.Reservation, error)
+	Release(ctx context.Context, r usage.Reservation) error
+	Get(client, period string) (usage.Usage, error)
+	List(period string) ([]usage.Usage, error)
+}
+
+// Usage contains settings for usage accounting and quotas. Usage is not
+// tracked if Tracker is nil.
+type Usage struct {
+	Tracker UsageTracker
+	// Quota is the default quota for clients without a quota.
+	Quota usage.Quota
+}
+
+// quota returns the quota of the provided identity.
+func (u Usage) quota(identity auth.Identity) usage.Quota {
+	if identity.Quota != nil {
+		return *identity.Quota
+	}
+	return u.Quota
+}
+
+// UsageResponse is the response for a usage request of a client.
+type UsageResponse struct {
+	Client  string      `json:"client"`
+	Daily   usage.Usage `json:"daily"`
+	Monthly usage.Usage `json:"monthly"`
+	Quota   usage.Quota `json:"quota"`
+}
+
+// JSON returns the JSON representation of the usage response.
+func (r UsageResponse) JSON() []byte {
+	b, _ := json.Marshal(&r)
+	return b
+}
+
+// UsageListResponse is the response for a usage request of every client.
+type UsageListResponse struct {
+	Period string        `json:"period"`
+	Usage  []usage.Usage `json:"usage"`
+}
+
+// JSON returns the JSON representation of the usage list response.
+func (r UsageListResponse) JSON() []byte {
+	b, _ := json.Marshal(&r)
+	return b
+}
+
+// reserveUsage records the provided count to the usage of the client
+// before the reports are created, if it does not exceed the quota of the
+// client, and writes a problem if it does. The quota is checked and the
+// count recorded atomically, so that concurrent requests cannot exceed
+// the quota. Returns the reservation, to release if the reports fail.
+// The request is rejected if the usage could not be recorded, so that
+// quotas cannot be exceeded while the store is failing.
+func (s server) reserveUsage(w http.ResponseWriter, r *http.Request, c usage.Count) (usage.Reservation, bool) {
+	if s.usage.Tracker == nil || c == (usage.Count{}) {
+		return usage.Reservation{}, true
+	}
+	identity, _ := auth.IdentityFromContext(r.Context())
+
+	reserved, err := s.usage.Tracker.Record(r.Context(), identity.Name, s.usage.quota(identity), c)
+	if err == nil {
+		return reserved, true
+	}
+	var quotaErr *usage.QuotaError
+	if !errors.As(err, &quotaErr) {
+		s.log.Error("Error recording usage.", "error", err, "client", identity.Name, "request_id", requestID(r))
+		writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeUnavailable, "Usage could not be recorded."))
+		return usage.Reservation{}, false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(time.Until(quotaErr.Reset)))))
+	writeProblem(w, r, newProblem(http.StatusTooManyRequests, codeQuotaExceeded, "The "+quotaErr.Period+" quota of "+strconv.FormatInt(quotaErr.Limit, 10)+" "+quotaErr.Kind+" is used up."))
+	return usage.Reservation{}, false
+}
+
+// releaseUsage releases the provided reservation, or the part of it for
+// reports that failed, from the usage of the client, even if the request
+// is cancelled. Errors from the tracker are logged.
+func (s server) releaseUsage(r *http.Request, reserved usage.Reservation) {
+	if s.usage.Tracker == nil || reserved.Count == (usage.Count{}) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), usageReleaseTimeout)
+	defer cancel()
+	if err := s.usage.Tracker.Release(ctx, reserved); err != nil {
+		s.log.Error("Error releasing usage.", "error", err, "client", reserved.Client, "request_id", requestID(r))
+	}
+}
+
+// usageHandler returns a handler for the usage of the client of the
+// request for the current day and month, or the day in the date query
+// parameter (YYYY-MM-DD) and its month.
+func (s server) usageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		if s.usage.Tracker == nil {
+			writeProblem(w, r, newProblem(http.StatusNotImplemented, codeUsageDisabled, "Usage tracking is not enabled."))
+			return
+		}
+
+		date := time.Now()
+		if d := r.URL.Query().Get("date"); len(d) > 0 {
+			var err error
+			if date, err = time.Parse(dateLayout, d); err != nil {
+				writeProblem(w, r, newProblem(http.StatusBadRequest, codeInvalidParameter, "Parameter date must be a date (YYYY-MM-DD)."))
+				return
+			}
+		}
+
+		identity, _ := auth.IdentityFromContext(r.Context())
+		daily, err := s.usage.Tracker.Get(identity.Name, usage.Day(date))
+		if err != nil {
+			s.usageError(w, r, err)
+			return
+		}
+		monthly, err := s.usage.Tracker.Get(identity.Name, usage.Month(date))
+		if err != nil {
+			s.usageError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(UsageResponse{
+			Client:  identity.Name,
+			Daily:   daily,
+			Monthly: monthly,
+			Quota:   s.usage.quota(identity),
+		}.JSON())
+	})
+}
+
+// adminUsageHandler returns a handler for the usage of every client in
+// the period query parameter, a day (YYYY-MM-DD) or a month (YYYY-MM).
+// The period defaults to the current day.
+func (s server) adminUsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		if s.usage.Tracker == nil {
+			writeProblem(w, r, newProblem(http.StatusNotImplemented, codeUsageDisabled, "Usage tracking is not enabled."))
+			return
+		}
+
+		period := usage.Day(time.Now())
+		if p := r.URL.Query().Get("period"); len(p) > 0 {
+			var err error
+			if period, err = usage.ParsePeriod(p); err != nil {
+				writeProblem(w, r, newProblem(http.StatusBadRequest, codeInvalidParameter, "Parameter period must be a day (YYYY-MM-DD) or a month (YYYY-MM)."))
+				return
+			}
+		}
+
+		list, err := s.usage.Tracker.List(period)
+		if err != nil {
+			s.usageError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(UsageListResponse{Period: period, Usage: list}.JSON())
+	})
+}
+
+// usageError logs the provided error from the tracker and writes a problem.
+func (s server) usageError(w http.ResponseWriter, r *http.Request, err error) {
+	s.log.Error("Error getting usage.", "error", err, "request_id", requestID(r))
+	writeProblem(w, r, newProblem(http.StatusServiceUnavailable, codeUnavailable, "The usage store is unavailable."))
+}
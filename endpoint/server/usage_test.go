@@ -0,0 +1,435 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/auth"
+	"github.com/RedeployAB/container-apps-dapr/endpoint/usage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportHandler_Usage(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			identity auth.Identity
+			quota    usage.Quota
+			tracker  *mockTracker
+			err      error
+		}
+		wantCode       int
+		wantProblem    string
+		wantRetryAfter string
+		wantRecorded   map[string]usage.Count
+	}{
+		{
+			name: "Without quota",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client"},
+				tracker:  &mockTracker{},
+			},
+			wantCode:     http.StatusAccepted,
+			wantRecorded: map[string]usage.Count{"client": {Reports: 1, Bytes: 3}},
+		},
+		{
+			name: "Within quota",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client"},
+				quota:    usage.Quota{Daily: usage.Count{Reports: 10}},
+				tracker:  &mockTracker{},
+			},
+			wantCode:     http.StatusAccepted,
+			wantRecorded: map[string]usage.Count{"client": {Reports: 1, Bytes: 3}},
+		},
+		{
+			name: "Quota used up",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client"},
+				quota:    usage.Quota{Daily: usage.Count{Reports: 10}},
+				tracker: &mockTracker{
+					recordErr: &usage.QuotaError{Period: usage.PeriodDaily, Kind: usage.KindReports, Limit: 10, Reset: time.Now().Add(time.Hour)},
+				},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantProblem:    codeQuotaExceeded,
+			wantRetryAfter: "3600",
+		},
+		{
+			name: "Quota of client used up",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client", Quota: &usage.Quota{Monthly: usage.Count{Bytes: 100}}},
+				tracker: &mockTracker{
+					recordErr: &usage.QuotaError{Period: usage.PeriodMonthly, Kind: usage.KindBytes, Limit: 100, Reset: time.Now().Add(time.Hour)},
+				},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantProblem:    codeQuotaExceeded,
+			wantRetryAfter: "3600",
+		},
+		{
+			name: "Tracker error",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client"},
+				quota:    usage.Quota{Daily: usage.Count{Reports: 10}},
+				tracker:  &mockTracker{recordErr: errors.New("error")},
+			},
+			wantCode:       http.StatusServiceUnavailable,
+			wantProblem:    codeUnavailable,
+			wantRetryAfter: "5",
+		},
+		{
+			name: "Reporter error",
+			input: struct {
+				identity auth.Identity
+				quota    usage.Quota
+				tracker  *mockTracker
+				err      error
+			}{
+				identity: auth.Identity{Name: "client"},
+				tracker:  &mockTracker{},
+				err:      errors.New("error"),
+			},
+			wantCode:     http.StatusInternalServerError,
+			wantProblem:  codeInternal,
+			wantRecorded: map[string]usage.Count{"client": {}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter: &mockReporter{err: test.input.err},
+				log:      &mockLogger{},
+				usage:    Usage{Tracker: test.input.tracker, Quota: test.input.quota},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"id":"123","data":"ZGF0"}`))
+			req = req.WithContext(auth.WithIdentity(req.Context(), test.input.identity))
+			w := httptest.NewRecorder()
+
+			s.reportHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("reportHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("reportHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+			}
+			if got := resp.Header.Get("Retry-After"); got != test.wantRetryAfter {
+				t.Errorf("reportHandler() = unexpected Retry-After, want %q, got: %q\n", test.wantRetryAfter, got)
+			}
+			if diff := cmp.Diff(test.wantRecorded, test.input.tracker.recorded); diff != "" {
+				t.Errorf("reportHandler() = unexpected recorded usage, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestBatchHandler_Usage(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			tracker   *mockTracker
+			batchErrs map[string]error
+		}
+		wantCode     int
+		wantChecked  usage.Count
+		wantRecorded map[string]usage.Count
+	}{
+		{
+			name: "Within quota",
+			input: struct {
+				tracker   *mockTracker
+				batchErrs map[string]error
+			}{
+				tracker:   &mockTracker{},
+				batchErrs: map[string]error{"456": errors.New("error")},
+			},
+			wantCode:     http.StatusMultiStatus,
+			wantChecked:  usage.Count{Reports: 2, Bytes: 6},
+			wantRecorded: map[string]usage.Count{"client": {Reports: 1, Bytes: 3}},
+		},
+		{
+			name: "Quota used up",
+			input: struct {
+				tracker   *mockTracker
+				batchErrs map[string]error
+			}{
+				tracker: &mockTracker{
+					recordErr: &usage.QuotaError{Period: usage.PeriodDaily, Kind: usage.KindReports, Limit: 1, Reset: time.Now().Add(time.Hour)},
+				},
+			},
+			wantCode:    http.StatusTooManyRequests,
+			wantChecked: usage.Count{Reports: 2, Bytes: 6},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				reporter:     &mockReporter{batchErrs: test.input.batchErrs},
+				log:          &mockLogger{},
+				validation:   Validation{MaxBatchBodySize: 1024, MaxDataSize: 8},
+				usage:        Usage{Tracker: test.input.tracker, Quota: usage.Quota{Daily: usage.Count{Reports: 1}}},
+				maxBatchSize: 10,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/reports:batch", strings.NewReader(`[{"id":"123","data":"ZGF0"},{"id":"456","data":"ZGF0"},{"id":"../789"}]`))
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Name: "client"}))
+			w := httptest.NewRecorder()
+
+			s.batchHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("batchHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			if diff := cmp.Diff(test.wantChecked, test.input.tracker.checked); diff != "" {
+				t.Errorf("batchHandler() = unexpected checked usage, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantRecorded, test.input.tracker.recorded); diff != "" {
+				t.Errorf("batchHandler() = unexpected recorded usage, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestUsageHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			target  string
+			tracker UsageTracker
+		}
+		wantCode    int
+		wantBody    string
+		wantProblem string
+	}{
+		{
+			name: "With date",
+			input: struct {
+				target  string
+				tracker UsageTracker
+			}{
+				target: "/usage?date=2024-01-31",
+				tracker: &mockTracker{usage: map[string]usage.Count{
+					"2024-01-31": {Reports: 1, Bytes: 10},
+					"2024-01":    {Reports: 5, Bytes: 50},
+				}},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"client":"client","daily":{"client":"client","period":"2024-01-31","reports":1,"bytes":10},"monthly":{"client":"client","period":"2024-01","reports":5,"bytes":50},"quota":{"daily":{"reports":100,"bytes":0},"monthly":{"reports":0,"bytes":0}}}`,
+		},
+		{
+			name: "With invalid date",
+			input: struct {
+				target  string
+				tracker UsageTracker
+			}{
+				target:  "/usage?date=2024-01",
+				tracker: &mockTracker{},
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidParameter,
+		},
+		{
+			name: "With tracker error",
+			input: struct {
+				target  string
+				tracker UsageTracker
+			}{
+				target:  "/usage",
+				tracker: &mockTracker{getErr: errors.New("error")},
+			},
+			wantCode:    http.StatusServiceUnavailable,
+			wantProblem: codeUnavailable,
+		},
+		{
+			name: "Without tracker",
+			input: struct {
+				target  string
+				tracker UsageTracker
+			}{
+				target: "/usage",
+			},
+			wantCode:    http.StatusNotImplemented,
+			wantProblem: codeUsageDisabled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log:   &mockLogger{},
+				usage: Usage{Tracker: test.input.tracker, Quota: usage.Quota{Daily: usage.Count{Reports: 100}}},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, test.input.target, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Name: "client"}))
+			w := httptest.NewRecorder()
+
+			s.usageHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("usageHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("usageHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+				return
+			}
+			if string(body) != test.wantBody {
+				t.Errorf("usageHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+			}
+		})
+	}
+}
+
+func TestAdminUsageHandler(t *testing.T) {
+	var tests = []struct {
+		name        string
+		input       string
+		wantCode    int
+		wantBody    string
+		wantProblem string
+	}{
+		{
+			name:     "With month",
+			input:    "/admin/usage?period=2024-01",
+			wantCode: http.StatusOK,
+			wantBody: `{"period":"2024-01","usage":[{"client":"client","period":"2024-01","reports":5,"bytes":50}]}`,
+		},
+		{
+			name:     "Without usage",
+			input:    "/admin/usage?period=2024-02-01",
+			wantCode: http.StatusOK,
+			wantBody: `{"period":"2024-02-01","usage":[]}`,
+		},
+		{
+			name:        "With invalid period",
+			input:       "/admin/usage?period=2024",
+			wantCode:    http.StatusBadRequest,
+			wantProblem: codeInvalidParameter,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log: &mockLogger{},
+				usage: Usage{Tracker: &mockTracker{usage: map[string]usage.Count{
+					"2024-01": {Reports: 5, Bytes: 50},
+				}}},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, test.input, nil)
+			w := httptest.NewRecorder()
+
+			s.adminUsageHandler().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("adminUsageHandler() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if len(test.wantProblem) > 0 {
+				if got := problemCode(t, resp, body); got != test.wantProblem {
+					t.Errorf("adminUsageHandler() = unexpected problem, want %s, got: %s\n", test.wantProblem, got)
+				}
+				return
+			}
+			if string(body) != test.wantBody {
+				t.Errorf("adminUsageHandler() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+			}
+		})
+	}
+}
+
+// mockTracker is a UsageTracker with the usage of a single client,
+// "client", per period.
+type mockTracker struct {
+	usage     map[string]usage.Count
+	recorded  map[string]usage.Count
+	checked   usage.Count
+	recordErr error
+	getErr    error
+}
+
+func (t *mockTracker) Record(ctx context.Context, client string, quota usage.Quota, c usage.Count) (usage.Reservation, error) {
+	t.checked = c
+	if t.recordErr != nil {
+		return usage.Reservation{}, t.recordErr
+	}
+	t.add(client, c)
+	return usage.Reservation{Client: client, Day: "2024-01-31", Month: "2024-01", Count: c}, nil
+}
+
+func (t *mockTracker) Release(ctx context.Context, r usage.Reservation) error {
+	t.add(r.Client, usage.Count{Reports: -r.Reports, Bytes: -r.Bytes})
+	return nil
+}
+
+func (t *mockTracker) add(client string, c usage.Count) {
+	if t.recorded == nil {
+		t.recorded = make(map[string]usage.Count)
+	}
+	current := t.recorded[client]
+	t.recorded[client] = usage.Count{Reports: current.Reports + c.Reports, Bytes: current.Bytes + c.Bytes}
+}
+
+func (t *mockTracker) Get(client, period string) (usage.Usage, error) {
+	if t.getErr != nil {
+		return usage.Usage{}, t.getErr
+	}
+	return usage.Usage{Client: client, Period: period, Count: t.usage[period]}, nil
+}
+
+func (t *mockTracker) List(period string) ([]usage.Usage, error) {
+	if t.getErr != nil {
+		return nil, t.getErr
+	}
+	list := []usage.Usage{}
+	if c, ok := t.usage[period]; ok {
+		list = append(list, usage.Usage{Client: "client", Period: period, Count: c})
+	}
+	return list, nil
+}
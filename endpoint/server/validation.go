@@ -0,0 +1,225 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+)
+
+// Field error codes.
+const (
+	fieldCodeUnknown      = "unknown-field"
+	fieldCodeInvalidType  = "invalid-type"
+	fieldCodeInvalidValue = "invalid-value"
+	fieldCodeTooLarge     = "too-large"
+	fieldCodeRequired     = "required"
+)
+
+var (
+	// errReadBody is returned when a request body could not be read.
+	errReadBody = errors.New("read body")
+)
+
+// Validation contains limits for the validation of incoming requests. A
+// limit of 0 means no limit.
+type Validation struct {
+	// MaxBodySize is the maximum size in bytes of a JSON report request
+	// body. Raw and multipart uploads are limited by MaxDataSize.
+	MaxBodySize int64
+	// MaxBatchBodySize is the maximum size in bytes of a batch request body.
+	MaxBatchBodySize int64
+	// MaxDataSize is the maximum size in bytes of the decoded data of
+	// a report.
+	MaxDataSize int
+}
+
+// FieldError is a validation error for a field in a request.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// Limits of the routing attributes of a report.
+const (
+	maxTypeLength       = 64
+	maxPriority         = 9
+	maxLabels           = 16
+	maxLabelKeyLength   = 64
+	maxLabelValueLength = 256
+)
+
+// reportFields contains the fields allowed in a report.
+var reportFields = map[string]struct{}{
+	"id":       {},
+	"data":     {},
+	"type":     {},
+	"priority": {},
+	"labels":   {},
+}
+
+// limitBody limits the request body to the provided size.
+func limitBody(w http.ResponseWriter, r *http.Request, size int64) {
+	if size > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, size)
+	}
+	r.Body = bodyReader{ReadCloser: r.Body}
+}
+
+// bodyReader wraps errors from reading a request body with errReadBody, so
+// that they can be told apart from errors in the content of the body.
+type bodyReader struct {
+	io.ReadCloser
+}
+
+// Read reads from the body.
+func (b bodyReader) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%w: %w", errReadBody, err)
+	}
+	return n, err
+}
+
+// decodeReport strictly decodes and validates a report. Field names must
+// match exactly and unknown fields are not allowed. An error is returned
+// if b is not a JSON object, otherwise every field error is returned.
+func (v Validation) decodeReport(b []byte) (Report, []FieldError, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return Report{}, nil, err
+	}
+	if fields == nil {
+		return Report{}, nil, errors.New("report is null")
+	}
+
+	var re Report
+	var errs []FieldError
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := reportFields[name]; !ok {
+			errs = append(errs, FieldError{Field: name, Code: fieldCodeUnknown, Detail: "Field is not allowed."})
+		}
+	}
+
+	if raw, ok := fields["id"]; ok && !isNull(raw) {
+		if err := json.Unmarshal(raw, &re.ID); err != nil {
+			errs = append(errs, FieldError{Field: "id", Code: fieldCodeInvalidType, Detail: "Field must be a string."})
+		} else {
+			errs = append(errs, checkID(re.ID)...)
+		}
+	}
+
+	if raw, ok := fields["data"]; ok && !isNull(raw) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			errs = append(errs, FieldError{Field: "data", Code: fieldCodeInvalidType, Detail: "Field must be a base64 encoded string."})
+		} else if err := json.Unmarshal(raw, &re.Data); err != nil {
+			errs = append(errs, FieldError{Field: "data", Code: fieldCodeInvalidValue, Detail: "Field must be a base64 encoded string."})
+		} else {
+			errs = append(errs, v.checkDataSize(len(re.Data))...)
+		}
+	}
+
+	if raw, ok := fields["type"]; ok && !isNull(raw) {
+		if err := json.Unmarshal(raw, &re.Type); err != nil {
+			errs = append(errs, FieldError{Field: "type", Code: fieldCodeInvalidType, Detail: "Field must be a string."})
+		} else if len(re.Type) > maxTypeLength {
+			errs = append(errs, FieldError{Field: "type", Code: fieldCodeInvalidValue, Detail: "Field must not be longer than " + strconv.Itoa(maxTypeLength) + " characters."})
+		}
+	}
+
+	if raw, ok := fields["priority"]; ok && !isNull(raw) {
+		if err := json.Unmarshal(raw, &re.Priority); err != nil {
+			errs = append(errs, FieldError{Field: "priority", Code: fieldCodeInvalidType, Detail: "Field must be an integer."})
+		} else if re.Priority < 0 || re.Priority > maxPriority {
+			errs = append(errs, FieldError{Field: "priority", Code: fieldCodeInvalidValue, Detail: "Field must be between 0 and " + strconv.Itoa(maxPriority) + "."})
+		}
+	}
+
+	if raw, ok := fields["labels"]; ok && !isNull(raw) {
+		var labels map[string]string
+		if err := json.Unmarshal(raw, &labels); err != nil {
+			errs = append(errs, FieldError{Field: "labels", Code: fieldCodeInvalidType, Detail: "Field must be an object with string values."})
+		} else {
+			re.Labels = labels
+			errs = append(errs, checkLabels(labels)...)
+		}
+	}
+
+	return re, errs, nil
+}
+
+// checkLabels checks the number of labels of a report and the length of
+// their keys and values.
+func checkLabels(labels map[string]string) []FieldError {
+	if len(labels) > maxLabels {
+		return []FieldError{{Field: "labels", Code: fieldCodeTooLarge, Detail: "Field must not contain more than " + strconv.Itoa(maxLabels) + " labels."}}
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []FieldError
+	for _, k := range keys {
+		if len(k) == 0 || len(k) > maxLabelKeyLength {
+			errs = append(errs, FieldError{Field: "labels", Code: fieldCodeInvalidValue, Detail: "Label keys must be between 1 and " + strconv.Itoa(maxLabelKeyLength) + " characters."})
+			continue
+		}
+		if len(labels[k]) > maxLabelValueLength {
+			errs = append(errs, FieldError{Field: "labels." + k, Code: fieldCodeInvalidValue, Detail: "Label values must not be longer than " + strconv.Itoa(maxLabelValueLength) + " characters."})
+		}
+	}
+	return errs
+}
+
+// checkID checks the provided report ID. An empty ID is allowed and is
+// replaced with a generated ID.
+func checkID(id string) []FieldError {
+	if len(id) == 0 {
+		return nil
+	}
+	if err := report.ValidateID(id); err != nil {
+		return []FieldError{{Field: "id", Code: fieldCodeInvalidValue, Detail: idErrorDetail(err)}}
+	}
+	return nil
+}
+
+// checkDataSize checks the provided size of the data of a report.
+func (v Validation) checkDataSize(size int) []FieldError {
+	if v.MaxDataSize > 0 && size > v.MaxDataSize {
+		return []FieldError{{Field: "data", Code: fieldCodeTooLarge, Detail: "Field must not be larger than " + strconv.Itoa(v.MaxDataSize) + " bytes."}}
+	}
+	return nil
+}
+
+// validationProblem returns a Problem containing the provided field errors.
+func validationProblem(errs []FieldError) Problem {
+	p := newProblem(http.StatusBadRequest, codeInvalidReport, "Report is not valid.")
+	p.Errors = errs
+	return p
+}
+
+// idErrorDetail returns the detail of an error from report.ValidateID.
+func idErrorDetail(err error) string {
+	detail := strings.TrimPrefix(err.Error(), report.ErrInvalidID.Error()+": ")
+	return "Field " + detail + "."
+}
+
+// isNull returns true if the raw JSON value is null.
+func isNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
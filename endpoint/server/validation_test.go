@@ -0,0 +1,148 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidation_DecodeReport(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			validation Validation
+			body       string
+		}
+		want     Report
+		wantErrs []FieldError
+		wantErr  bool
+	}{
+		{
+			name: "With valid report",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				validation: Validation{MaxDataSize: 4},
+				body:       `{"id":"123","data":"ZGF0YQ=="}`,
+			},
+			want: Report{ID: "123", Data: []byte("data")},
+		},
+		{
+			name: "Without id",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"data":"ZGF0YQ=="}`,
+			},
+			want: Report{Data: []byte("data")},
+		},
+		{
+			name: "With every error",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				validation: Validation{MaxDataSize: 2},
+				body:       `{"ID":"123","id":"../123","data":"ZGF0YQ==","other":1}`,
+			},
+			want: Report{ID: "../123", Data: []byte("data")},
+			wantErrs: []FieldError{
+				{Field: "ID", Code: fieldCodeUnknown, Detail: "Field is not allowed."},
+				{Field: "other", Code: fieldCodeUnknown, Detail: "Field is not allowed."},
+				{Field: "id", Code: fieldCodeInvalidValue, Detail: "Field must start with a letter or digit and only contain letters, digits, '.', '_' and '-'."},
+				{Field: "data", Code: fieldCodeTooLarge, Detail: "Field must not be larger than 2 bytes."},
+			},
+		},
+		{
+			name: "With invalid types",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"id":123,"data":"not base64"}`,
+			},
+			wantErrs: []FieldError{
+				{Field: "id", Code: fieldCodeInvalidType, Detail: "Field must be a string."},
+				{Field: "data", Code: fieldCodeInvalidValue, Detail: "Field must be a base64 encoded string."},
+			},
+		},
+		{
+			name: "With routing attributes",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"id":"123","type":"alert","priority":7,"labels":{"region":"eu"}}`,
+			},
+			want: Report{ID: "123", Type: "alert", Priority: 7, Labels: map[string]string{"region": "eu"}},
+		},
+		{
+			name: "With invalid routing attributes",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"type":1,"priority":10,"labels":{"":"a","region":"` + strings.Repeat("a", 257) + `"}}`,
+			},
+			want: Report{Priority: 10, Labels: map[string]string{"": "a", "region": strings.Repeat("a", 257)}},
+			wantErrs: []FieldError{
+				{Field: "type", Code: fieldCodeInvalidType, Detail: "Field must be a string."},
+				{Field: "priority", Code: fieldCodeInvalidValue, Detail: "Field must be between 0 and 9."},
+				{Field: "labels", Code: fieldCodeInvalidValue, Detail: "Label keys must be between 1 and 64 characters."},
+				{Field: "labels.region", Code: fieldCodeInvalidValue, Detail: "Label values must not be longer than 256 characters."},
+			},
+		},
+		{
+			name: "With invalid labels type",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"priority":"high","labels":{"region":1}}`,
+			},
+			wantErrs: []FieldError{
+				{Field: "priority", Code: fieldCodeInvalidType, Detail: "Field must be an integer."},
+				{Field: "labels", Code: fieldCodeInvalidType, Detail: "Field must be an object with string values."},
+			},
+		},
+		{
+			name: "With invalid JSON",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `{"id":"123"} {}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "With null",
+			input: struct {
+				validation Validation
+				body       string
+			}{
+				body: `null`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErrs, gotErr := test.input.validation.decodeReport([]byte(test.input.body))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("decodeReport() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErrs, gotErrs); diff != "" {
+				t.Errorf("decodeReport() = unexpected field errors, (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("decodeReport() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
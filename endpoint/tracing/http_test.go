@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProvider_Instrument(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			traceparent string
+			status      int
+		}
+		wantName   string
+		wantParent bool
+		wantStatus codes.Code
+	}{
+		{
+			name: "Without parent",
+			input: struct {
+				traceparent string
+				status      int
+			}{
+				status: http.StatusAccepted,
+			},
+			wantName:   "POST /reports",
+			wantStatus: codes.Unset,
+		},
+		{
+			name: "With parent",
+			input: struct {
+				traceparent string
+				status      int
+			}{
+				traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+				status:      http.StatusServiceUnavailable,
+			},
+			wantName:   "POST /reports",
+			wantParent: true,
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, recorder := newTestProvider(t)
+
+			var spanContext trace.SpanContext
+			handler := p.Instrument("/reports", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				spanContext = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(test.input.status)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/reports", nil)
+			if len(test.input.traceparent) > 0 {
+				req.Header.Set("traceparent", test.input.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Instrument() = unexpected result, want 1 span, got: %d\n", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != test.wantName {
+				t.Errorf("Instrument() = unexpected name, want: %s, got: %s\n", test.wantName, span.Name())
+			}
+			if span.Parent().IsValid() != test.wantParent {
+				t.Errorf("Instrument() = unexpected parent, want parent: %v\n", test.wantParent)
+			}
+			if span.Status().Code != test.wantStatus {
+				t.Errorf("Instrument() = unexpected status, want: %v, got: %v\n", test.wantStatus, span.Status().Code)
+			}
+			if spanContext.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("Instrument() = unexpected result, want span in request context\n")
+			}
+		})
+	}
+}
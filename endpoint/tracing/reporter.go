@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reporter is a report.Reporter that creates a producer span for every
+// report run with the wrapped reporter.
+type reporter struct {
+	r         report.Reporter
+	p         Provider
+	typ       string
+	component string
+}
+
+// Reporter wraps the provided reporter and creates a producer span for
+// every report, with the trace context of the report as parent. The
+// trace context of the report is replaced with the one of the span before
+// the report is run, so that it is propagated to the worker. Batches are
+// run with the wrapped reporter if it is a report.BatchReporter,
+// otherwise the reports are run one by one.
+func (p Provider) Reporter(r report.Reporter, typ, component string) report.Reporter {
+	return &reporter{r: r, p: p, typ: typ, component: component}
+}
+
+// Run the report with the wrapped reporter.
+func (r reporter) Run(ctx context.Context, re report.Report) error {
+	ctx, span, re := r.start(ctx, re)
+	err := r.r.Run(ctx, re)
+	end(span, err)
+	return err
+}
+
+// RunBatch runs the reports with the wrapped reporter.
+func (r reporter) RunBatch(ctx context.Context, reports []report.Report) []error {
+	br, ok := r.r.(report.BatchReporter)
+	if !ok {
+		errs := make([]error, len(reports))
+		for i, re := range reports {
+			errs[i] = r.Run(ctx, re)
+		}
+		return errs
+	}
+
+	spans := make([]trace.Span, len(reports))
+	traced := make([]report.Report, len(reports))
+	for i, re := range reports {
+		_, spans[i], traced[i] = r.start(ctx, re)
+	}
+	errs := br.RunBatch(ctx, traced)
+	for i, span := range spans {
+		var err error
+		if i < len(errs) {
+			err = errs[i]
+		}
+		end(span, err)
+	}
+	return errs
+}
+
+// start a producer span for the report and return the context with the
+// span, the span and the report with the trace context of the span.
+func (r reporter) start(ctx context.Context, re report.Report) (context.Context, trace.Span, report.Report) {
+	ctx = Extract(ctx, re.TraceContext)
+	ctx, span := r.p.tracer.Start(ctx, "publish "+r.component,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "dapr"),
+			attribute.String("messaging.destination.name", r.component),
+			attribute.String("dapr.component.type", r.typ),
+			attribute.String("report.id", re.ID),
+		),
+	)
+	re.TraceContext = Inject(ctx)
+	return ctx, span, re
+}
+
+// end the span and record the error, if any.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
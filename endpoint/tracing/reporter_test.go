@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/report"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestProvider_Reporter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			reporter *mockReporter
+			batch    bool
+		}
+		wantStatus map[string]codes.Code
+	}{
+		{
+			name: "Reporter",
+			input: struct {
+				reporter *mockReporter
+				batch    bool
+			}{
+				reporter: &mockReporter{errs: map[string]error{"456": errors.New("error")}},
+			},
+			wantStatus: map[string]codes.Code{"123": codes.Unset, "456": codes.Error},
+		},
+		{
+			name: "Batch reporter",
+			input: struct {
+				reporter *mockReporter
+				batch    bool
+			}{
+				reporter: &mockReporter{errs: map[string]error{"123": errors.New("error")}},
+				batch:    true,
+			},
+			wantStatus: map[string]codes.Code{"123": codes.Error, "456": codes.Unset},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, recorder := newTestProvider(t)
+
+			parent := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+			reports := []report.Report{
+				{ID: "123", Data: []byte("data"), TraceContext: parent},
+				{ID: "456", Data: []byte("data"), TraceContext: parent},
+			}
+
+			var inner report.Reporter = test.input.reporter
+			if test.input.batch {
+				inner = &mockBatchReporter{test.input.reporter}
+			}
+			r := p.Reporter(inner, "queue", "reports")
+			r.(report.BatchReporter).RunBatch(context.Background(), reports)
+
+			spans := recorder.Ended()
+			if len(spans) != len(reports) {
+				t.Fatalf("RunBatch() = unexpected result, want %d spans, got: %d\n", len(reports), len(spans))
+			}
+			for _, span := range spans {
+				var id string
+				for _, attr := range span.Attributes() {
+					if attr.Key == "report.id" {
+						id = attr.Value.AsString()
+					}
+				}
+				if span.Status().Code != test.wantStatus[id] {
+					t.Errorf("RunBatch() = unexpected status of report %s, want: %v, got: %v\n", id, test.wantStatus[id], span.Status().Code)
+				}
+				if span.Parent().TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+					t.Errorf("RunBatch() = unexpected result, want span with the trace of the report\n")
+				}
+				want := "00-0af7651916cd43dd8448eb211c80319c-" + span.SpanContext().SpanID().String() + "-01"
+				if got := test.input.reporter.traceparents[id]; got != want {
+					t.Errorf("RunBatch() = unexpected traceparent, want: %s, got: %s\n", want, got)
+				}
+			}
+		})
+	}
+}
+
+type mockReporter struct {
+	errs         map[string]error
+	traceparents map[string]string
+}
+
+func (r *mockReporter) Run(ctx context.Context, re report.Report) error {
+	if r.traceparents == nil {
+		r.traceparents = map[string]string{}
+	}
+	r.traceparents[re.ID] = re.TraceContext["traceparent"]
+	return r.errs[re.ID]
+}
+
+type mockBatchReporter struct {
+	*mockReporter
+}
+
+func (r mockBatchReporter) RunBatch(ctx context.Context, reports []report.Report) []error {
+	errs := make([]error, len(reports))
+	for i, re := range reports {
+		errs[i] = r.Run(ctx, re)
+	}
+	return errs
+}
@@ -0,0 +1,380 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+)
+
+const (
+	defaultRetention = time.Hour * 24 * 400
+	// conflictBackoff is the backoff before an update is retried when the
+	// key is updated concurrently. It is doubled for every retry, up to
+	// maxConflictBackoff.
+	conflictBackoff    = time.Millisecond * 10
+	maxConflictBackoff = time.Second
+	// rollbackTimeout is the time a rejected count has to be removed from
+	// the periods it was added to.
+	rollbackTimeout = time.Second * 10
+)
+
+const (
+	keyPrefix     = "usage:"
+	clientsPrefix = "usage-clients:"
+	dayLayout     = "2006-01-02"
+	monthLayout   = "2006-01"
+)
+
+// Quota periods and kinds.
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+	KindReports   = "reports"
+	KindBytes     = "bytes"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a quota is used up.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrInvalidPeriod is returned when a period is not a day or a month.
+	ErrInvalidPeriod = errors.New("period must be a day (YYYY-MM-DD) or a month (YYYY-MM)")
+	// errNotIndexed is returned by an update of the usage of a client that
+	// is not in the index of the period yet.
+	errNotIndexed = errors.New("client not indexed")
+)
+
+// Count is a number of reports and the bytes of their data.
+type Count struct {
+	Reports int64 `json:"reports"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// add returns the sum of c and other.
+func (c Count) add(other Count) Count {
+	return Count{Reports: c.Reports + other.Reports, Bytes: c.Bytes + other.Bytes}
+}
+
+// negate returns c with negated values.
+func (c Count) negate() Count {
+	return Count{Reports: -c.Reports, Bytes: -c.Bytes}
+}
+
+// Usage is the usage of a client in a period, a day (YYYY-MM-DD) or a
+// month (YYYY-MM).
+type Usage struct {
+	Client string `json:"client"`
+	Period string `json:"period"`
+	Count
+}
+
+// Reservation is a count recorded to the usage of a client, with the day
+// and month it was recorded in, so that it is released from the same
+// periods.
+type Reservation struct {
+	Client string
+	Day    string
+	Month  string
+	Count
+}
+
+// Quota is the maximum usage of a client per day and month, in UTC. A
+// field of 0 is no quota.
+type Quota struct {
+	Daily   Count `json:"daily"`
+	Monthly Count `json:"monthly"`
+}
+
+// IsZero returns true if the quota has no limits.
+func (q Quota) IsZero() bool {
+	return q == Quota{}
+}
+
+// QuotaError is returned when a quota is used up. It wraps
+// ErrQuotaExceeded.
+type QuotaError struct {
+	// Period is "daily" or "monthly".
+	Period string
+	// Kind is "reports" or "bytes".
+	Kind  string
+	Limit int64
+	// Reset is the time the quota is reset.
+	Reset time.Time
+}
+
+// Error returns the error message.
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of %d %s is used up", e.Period, e.Limit, e.Kind)
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Day returns the daily period of t.
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// Month returns the monthly period of t.
+func Month(t time.Time) string {
+	return t.UTC().Format(monthLayout)
+}
+
+// ParsePeriod validates the provided period and returns it.
+func ParsePeriod(period string) (string, error) {
+	if _, err := time.Parse(dayLayout, period); err == nil {
+		return period, nil
+	}
+	if _, err := time.Parse(monthLayout, period); err == nil {
+		return period, nil
+	}
+	return "", ErrInvalidPeriod
+}
+
+// Tracker tracks the usage of clients per day and month in a state store.
+// The clients with usage in a period are kept in an index, so that the
+// usage of every client can be listed.
+type Tracker struct {
+	store     state.Store
+	retention time.Duration
+	now       func() time.Time
+}
+
+// TrackerOptions contains options for a Tracker.
+type TrackerOptions struct {
+	// Retention is the time usage is kept.
+	Retention time.Duration
+}
+
+// TrackerOption is a function that sets *TrackerOptions.
+type TrackerOption func(o *TrackerOptions)
+
+// NewTracker creates a new *Tracker with the provided store and options.
+func NewTracker(store state.Store, options ...TrackerOption) (*Tracker, error) {
+	if store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	opts := TrackerOptions{
+		Retention: defaultRetention,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Tracker{
+		store:     store,
+		retention: opts.Retention,
+		now:       time.Now,
+	}, nil
+}
+
+// Record adds the provided count to the usage of the client for the
+// current day and month, if it does not exceed the quota of the client.
+// The quota is checked and the usage is updated in the same update of
+// the store, so that concurrent requests cannot exceed the quota. Updates
+// that conflict with concurrent updates are retried until the context is
+// done. Returns a *QuotaError, without recording the count, if the quota
+// would be exceeded. The returned reservation is released with Release.
+func (t Tracker) Record(ctx context.Context, client string, quota Quota, c Count) (Reservation, error) {
+	var recorded []string
+	ps := periods(t.now(), quota)
+	for _, p := range ps {
+		if err := t.add(ctx, client, p.period, c, func(current Count) error {
+			return p.check(current.add(c))
+		}); err != nil {
+			// Release the count from the periods it was added to, so that
+			// a rejected count is not recorded.
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+			defer cancel()
+			for _, period := range recorded {
+				_ = t.add(ctx, client, period, c.negate(), nil)
+			}
+			var quotaErr *QuotaError
+			if errors.As(err, &quotaErr) {
+				return Reservation{}, err
+			}
+			return Reservation{}, fmt.Errorf("recording usage: %w", err)
+		}
+		recorded = append(recorded, p.period)
+	}
+	return Reservation{Client: client, Day: ps[0].period, Month: ps[1].period, Count: c}, nil
+}
+
+// Release removes the count of the provided reservation from the usage of
+// the client for the day and month it was recorded in. Used when the
+// reports the count was recorded for failed. The usage is not reduced
+// below 0.
+func (t Tracker) Release(ctx context.Context, r Reservation) error {
+	for _, period := range []string{r.Day, r.Month} {
+		if err := t.add(ctx, r.Client, period, r.Count.negate(), nil); err != nil {
+			return fmt.Errorf("releasing usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// add adds the provided count to the usage of the client for the provided
+// period. If check is not nil it is called with the current usage, and
+// the usage is not updated if it returns an error. The client is added to
+// the index of the period before its usage is created, so that there is
+// no usage that is not listed.
+func (t Tracker) add(ctx context.Context, client, period string, c Count, check func(current Count) error) error {
+	var indexed bool
+	for {
+		err := t.update(ctx, key(client, period), func(value []byte) ([]byte, error) {
+			var current Count
+			if value == nil && !indexed {
+				return nil, errNotIndexed
+			}
+			if value != nil {
+				if err := json.Unmarshal(value, &current); err != nil {
+					return nil, err
+				}
+			}
+			if check != nil {
+				if err := check(current); err != nil {
+					return nil, err
+				}
+			}
+			next := current.add(c)
+			next.Reports, next.Bytes = max(next.Reports, 0), max(next.Bytes, 0)
+			return json.Marshal(next)
+		})
+		if !errors.Is(err, errNotIndexed) {
+			return err
+		}
+		if err := t.index(ctx, client, period); err != nil {
+			return err
+		}
+		indexed = true
+	}
+}
+
+// update updates the value of the provided key with fn. The update is
+// retried with backoff and jitter while the key is updated concurrently,
+// until the context is done.
+func (t Tracker) update(ctx context.Context, key string, fn func(value []byte) ([]byte, error)) error {
+	backoff := conflictBackoff
+	for {
+		err := t.store.Update(key, t.retention, fn)
+		if !errors.Is(err, state.ErrConflict) {
+			return err
+		}
+		timer := time.NewTimer(backoff/2 + rand.N(backoff/2))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxConflictBackoff)
+	}
+}
+
+// period is a day or month with its quota.
+type period struct {
+	name   string
+	period string
+	limit  Count
+	reset  time.Time
+}
+
+// periods returns the day and month of now with the provided quota.
+func periods(now time.Time, quota Quota) []period {
+	now = now.UTC()
+	return []period{
+		{name: PeriodDaily, period: Day(now), limit: quota.Daily, reset: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)},
+		{name: PeriodMonthly, period: Month(now), limit: quota.Monthly, reset: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+// check returns a *QuotaError if the provided total exceeds the quota
+// of the period.
+func (p period) check(total Count) error {
+	if p.limit.Reports > 0 && total.Reports > p.limit.Reports {
+		return &QuotaError{Period: p.name, Kind: KindReports, Limit: p.limit.Reports, Reset: p.reset}
+	}
+	if p.limit.Bytes > 0 && total.Bytes > p.limit.Bytes {
+		return &QuotaError{Period: p.name, Kind: KindBytes, Limit: p.limit.Bytes, Reset: p.reset}
+	}
+	return nil
+}
+
+// Get the usage of the client for the provided period.
+func (t Tracker) Get(client, period string) (Usage, error) {
+	u := Usage{Client: client, Period: period}
+	b, err := t.store.Get(key(client, period))
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return u, nil
+		}
+		return Usage{}, fmt.Errorf("getting usage: %w", err)
+	}
+	if err := json.Unmarshal(b, &u.Count); err != nil {
+		return Usage{}, fmt.Errorf("getting usage: %w", err)
+	}
+	return u, nil
+}
+
+// List the usage of every client with usage in the provided period,
+// sorted by client.
+func (t Tracker) List(period string) ([]Usage, error) {
+	clients, err := t.clients(period)
+	if err != nil {
+		return nil, fmt.Errorf("listing usage: %w", err)
+	}
+	usage := make([]Usage, 0, len(clients))
+	for _, client := range clients {
+		u, err := t.Get(client, period)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// index adds the client to the index of the provided period.
+func (t Tracker) index(ctx context.Context, client, period string) error {
+	return t.update(ctx, clientsPrefix+period, func(value []byte) ([]byte, error) {
+		var clients []string
+		if value != nil {
+			if err := json.Unmarshal(value, &clients); err != nil {
+				return nil, err
+			}
+		}
+		if i, found := slices.BinarySearch(clients, client); !found {
+			clients = slices.Insert(clients, i, client)
+		}
+		return json.Marshal(clients)
+	})
+}
+
+// clients returns the clients in the index of the provided period.
+func (t Tracker) clients(period string) ([]string, error) {
+	b, err := t.store.Get(clientsPrefix + period)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var clients []string
+	if err := json.Unmarshal(b, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// key returns the key of the usage of a client in a period.
+func key(client, period string) string {
+	return keyPrefix + period + ":" + client
+}
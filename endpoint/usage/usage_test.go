@@ -0,0 +1,364 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/endpoint/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker_Record(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	tracker, err := NewTracker(&mockStore{})
+	if err != nil {
+		t.Fatalf("NewTracker() = unexpected error: %v\n", err)
+	}
+	tracker.now = func() time.Time { return now }
+
+	for _, client := range []string{"client-b", "client-a", "client-b"} {
+		if _, err := tracker.Record(context.Background(), client, Quota{}, Count{Reports: 1, Bytes: 10}); err != nil {
+			t.Fatalf("Record() = unexpected error: %v\n", err)
+		}
+	}
+
+	var tests = []struct {
+		name  string
+		input string
+		want  []Usage
+	}{
+		{
+			name:  "Day",
+			input: "2024-01-31",
+			want: []Usage{
+				{Client: "client-a", Period: "2024-01-31", Count: Count{Reports: 1, Bytes: 10}},
+				{Client: "client-b", Period: "2024-01-31", Count: Count{Reports: 2, Bytes: 20}},
+			},
+		},
+		{
+			name:  "Month",
+			input: "2024-01",
+			want: []Usage{
+				{Client: "client-a", Period: "2024-01", Count: Count{Reports: 1, Bytes: 10}},
+				{Client: "client-b", Period: "2024-01", Count: Count{Reports: 2, Bytes: 20}},
+			},
+		},
+		{
+			name:  "Without usage",
+			input: "2024-02-01",
+			want:  []Usage{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := tracker.List(test.input)
+			if err != nil {
+				t.Fatalf("List() = unexpected error: %v\n", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("List() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTracker_Record_Quota(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name  string
+		input struct {
+			quota Quota
+			count Count
+		}
+		want      error
+		wantDaily Count
+	}{
+		{
+			name: "Without quota",
+			input: struct {
+				quota Quota
+				count Count
+			}{
+				count: Count{Reports: 1000},
+			},
+			wantDaily: Count{Reports: 1009, Bytes: 90},
+		},
+		{
+			name: "Within quota",
+			input: struct {
+				quota Quota
+				count Count
+			}{
+				quota: Quota{Daily: Count{Reports: 10}, Monthly: Count{Reports: 100}},
+				count: Count{Reports: 1, Bytes: 10},
+			},
+			wantDaily: Count{Reports: 10, Bytes: 100},
+		},
+		{
+			name: "Daily reports used up",
+			input: struct {
+				quota Quota
+				count Count
+			}{
+				quota: Quota{Daily: Count{Reports: 10}},
+				count: Count{Reports: 2},
+			},
+			want:      &QuotaError{Period: PeriodDaily, Kind: KindReports, Limit: 10, Reset: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			wantDaily: Count{Reports: 9, Bytes: 90},
+		},
+		{
+			name: "Monthly bytes used up",
+			input: struct {
+				quota Quota
+				count Count
+			}{
+				quota: Quota{Monthly: Count{Bytes: 1000}},
+				count: Count{Reports: 1, Bytes: 11},
+			},
+			want:      &QuotaError{Period: PeriodMonthly, Kind: KindBytes, Limit: 1000, Reset: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			wantDaily: Count{Reports: 9, Bytes: 90},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &mockStore{data: map[string][]byte{
+				key("client", "2024-01-31"): []byte(`{"reports":9,"bytes":90}`),
+				key("client", "2024-01"):    []byte(`{"reports":99,"bytes":990}`),
+			}}
+			tracker, _ := NewTracker(store)
+			tracker.now = func() time.Time { return now }
+
+			_, got := tracker.Record(context.Background(), "client", test.input.quota, test.input.count)
+
+			if diff := cmp.Diff(test.want, got, cmp.Comparer(func(a, b error) bool {
+				return a.Error() == b.Error()
+			})); diff != "" {
+				t.Errorf("Record() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+			if test.want != nil && !errors.Is(got, ErrQuotaExceeded) {
+				t.Errorf("Record() = unexpected result, want error to wrap ErrQuotaExceeded\n")
+			}
+			daily, _ := tracker.Get("client", "2024-01-31")
+			if diff := cmp.Diff(test.wantDaily, daily.Count); diff != "" {
+				t.Errorf("Record() = unexpected daily usage, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTracker_Record_Concurrent(t *testing.T) {
+	tracker, _ := NewTracker(&mockStore{})
+	quota := Quota{Daily: Count{Reports: 5}}
+
+	n := 20
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tracker.Record(context.Background(), "client", quota, Count{Reports: 1})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var recorded int
+	for err := range errs {
+		if err == nil {
+			recorded++
+		} else if !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Record() = unexpected error: %v\n", err)
+		}
+	}
+	if recorded != 5 {
+		t.Errorf("Record() = unexpected number of recorded counts, want: 5, got: %d\n", recorded)
+	}
+}
+
+func TestTracker_Record_Conflict(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			conflicts int
+			timeout   time.Duration
+		}
+		want    Count
+		wantErr error
+	}{
+		{
+			name: "Retried",
+			input: struct {
+				conflicts int
+				timeout   time.Duration
+			}{
+				conflicts: 3,
+				timeout:   time.Second * 10,
+			},
+			want: Count{Reports: 1},
+		},
+		{
+			name: "Context done",
+			input: struct {
+				conflicts int
+				timeout   time.Duration
+			}{
+				conflicts: 1000,
+				timeout:   time.Millisecond * 50,
+			},
+			wantErr: state.ErrConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker, _ := NewTracker(&mockStore{conflicts: test.input.conflicts})
+			ctx, cancel := context.WithTimeout(context.Background(), test.input.timeout)
+			defer cancel()
+
+			_, gotErr := tracker.Record(ctx, "client", Quota{}, Count{Reports: 1})
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Record() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			got, _ := tracker.Get("client", Day(time.Now()))
+			if diff := cmp.Diff(test.want, got.Count); diff != "" {
+				t.Errorf("Record() = unexpected usage, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTracker_Record_IndexError(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	store := &mockStore{updateErrs: map[string]error{clientsPrefix + "2024-01-31": errors.New("error")}}
+	tracker, _ := NewTracker(store)
+	tracker.now = func() time.Time { return now }
+
+	if _, err := tracker.Record(context.Background(), "client", Quota{}, Count{Reports: 1}); err == nil {
+		t.Fatalf("Record() = expected error\n")
+	}
+	if diff := cmp.Diff(map[string][]byte(nil), store.data); diff != "" {
+		t.Errorf("Record() = unexpected stored usage, (-want +got):\n%s\n", diff)
+	}
+}
+
+func TestTracker_Release(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	tracker, _ := NewTracker(&mockStore{})
+	tracker.now = func() time.Time { return now }
+
+	reserved, err := tracker.Record(context.Background(), "client", Quota{}, Count{Reports: 2, Bytes: 20})
+	if err != nil {
+		t.Fatalf("Record() = unexpected error: %v\n", err)
+	}
+	// The reservation is released from the periods it was recorded in,
+	// after the day and month have changed.
+	tracker.now = func() time.Time { return now.Add(time.Hour * 2) }
+	reserved.Count = Count{Reports: 1, Bytes: 30}
+	if err := tracker.Release(context.Background(), reserved); err != nil {
+		t.Fatalf("Release() = unexpected error: %v\n", err)
+	}
+
+	for _, period := range []string{"2024-01-31", "2024-01"} {
+		got, _ := tracker.Get("client", period)
+		if diff := cmp.Diff(Count{Reports: 1}, got.Count); diff != "" {
+			t.Errorf("Release() = unexpected usage, (-want +got):\n%s\n", diff)
+		}
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	for _, period := range []string{"2024-01-31", "2024-01"} {
+		if _, err := ParsePeriod(period); err != nil {
+			t.Errorf("ParsePeriod(%q) = unexpected error: %v\n", period, err)
+		}
+	}
+	for _, period := range []string{"", "2024", "2024-13", "2024-01-32", "today"} {
+		if _, err := ParsePeriod(period); !errors.Is(err, ErrInvalidPeriod) {
+			t.Errorf("ParsePeriod(%q) = unexpected result, want: %v, got: %v\n", period, ErrInvalidPeriod, err)
+		}
+	}
+}
+
+type mockStore struct {
+	mu         sync.Mutex
+	data       map[string][]byte
+	err        error
+	updateErrs map[string]error
+	conflicts  int
+}
+
+func (s *mockStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	b, ok := s.data[key]
+	if !ok {
+		return nil, state.ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *mockStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value)
+}
+
+func (s *mockStore) Create(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok && s.err == nil {
+		return state.ErrExists
+	}
+	return s.set(key, value)
+}
+
+func (s *mockStore) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.updateErrs[key]; err != nil {
+		return err
+	}
+	if s.conflicts > 0 {
+		s.conflicts--
+		return state.ErrConflict
+	}
+	value, err := fn(s.data[key])
+	if err != nil {
+		return err
+	}
+	return s.set(key, value)
+}
+
+func (s *mockStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mockStore) set(key string, value []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = value
+	return nil
+}
@@ -2,15 +2,25 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
+	"github.com/RedeployAB/container-apps-dapr/worker/health"
+	"github.com/RedeployAB/container-apps-dapr/worker/metrics"
 	"github.com/RedeployAB/container-apps-dapr/worker/report"
+	"github.com/RedeployAB/container-apps-dapr/worker/state"
+	"github.com/RedeployAB/container-apps-dapr/worker/tracing"
 	"github.com/caarlos0/env/v10"
 )
 
 const (
-	defaultHost = "0.0.0.0"
-	defaultPort = 3001
+	defaultHost            = "0.0.0.0"
+	defaultPort            = 3001
+	defaultHealthPort      = 3002
+	defaultShutdownDelay   = time.Second * 5
+	defaultShutdownTimeout = time.Second * 10
+	defaultHealthTimeout   = time.Second * 2
 )
 
 const (
@@ -35,20 +45,62 @@ const (
 	defaultStorerTimeout = time.Second * 10
 )
 
+const (
+	defaultStateTimeout         = time.Second * 10
+	defaultStateStatusRetention = time.Hour * 24 * 7
+)
+
+const (
+	defaultClaimCheckTimeout = time.Second * 10
+)
+
+const (
+	defaultTracingSampleRatio = 1.0
+)
+
 // Configuration contains the configuration for the application.
 type Configuration struct {
-	Server Server
-	Storer Storer
+	Server     Server
+	Storer     Storer
+	State      State
+	ClaimCheck ClaimCheck
+	Health     Health
+	Tracing    Tracing
 }
 
 // Server contains the configuration for the server.
 type Server struct {
-	Host  string `env:"WORKER_HOST"`
-	Port  int    `env:"WORKER_PORT"`
-	Type  string `env:"WORKER_TYPE"`
-	Name  string `env:"WORKER_NAME"`
-	Queue string `env:"WORKER_QUEUE"`
-	Topic string `env:"WORKER_TOPIC"`
+	Host    string `env:"WORKER_HOST"`
+	Port    int    `env:"WORKER_PORT"`
+	Type    string `env:"WORKER_TYPE"`
+	Name    string `env:"WORKER_NAME"`
+	Queue   string `env:"WORKER_QUEUE"`
+	Topic   string `env:"WORKER_TOPIC"`
+	Metrics Metrics
+	// RedactFields are the names of binding metadata fields with values
+	// that are redacted in the log.
+	RedactFields map[string]struct{} `env:"WORKER_LOG_REDACT_FIELDS"`
+	// HealthPort is the port of the HTTP server for liveness and
+	// readiness checks.
+	HealthPort int `env:"WORKER_HEALTH_PORT"`
+	// ShutdownDelay is the time the worker reports not ready before it
+	// stops.
+	ShutdownDelay time.Duration `env:"WORKER_SHUTDOWN_DELAY"`
+	// ShutdownTimeout is the time in-flight messages have to complete
+	// when the worker stops.
+	ShutdownTimeout time.Duration `env:"WORKER_SHUTDOWN_TIMEOUT"`
+}
+
+// Metrics contains the configuration for Prometheus metrics, served
+// on /metrics of the health port.
+type Metrics struct {
+	Enabled bool `env:"WORKER_METRICS_ENABLED"`
+}
+
+// Health contains the configuration for readiness checks of the DAPR
+// sidecar.
+type Health struct {
+	Timeout time.Duration `env:"WORKER_HEALTH_TIMEOUT"`
 }
 
 // Storer contains the configuration for the storer.
@@ -58,34 +110,166 @@ type Storer struct {
 	Timeout time.Duration `env:"WORKER_STORER_TIMEOUT"`
 }
 
+// State contains the configuration for the state store. State is
+// disabled if no name is set.
+type State struct {
+	Name            string        `env:"WORKER_STATE_NAME"`
+	Timeout         time.Duration `env:"WORKER_STATE_TIMEOUT"`
+	StatusRetention time.Duration `env:"WORKER_STATE_STATUS_RETENTION"`
+}
+
+// ClaimCheck contains the configuration for claim checks. Claim checks
+// are disabled if no name is set.
+type ClaimCheck struct {
+	Name    string        `env:"WORKER_CLAIM_CHECK_NAME"`
+	Timeout time.Duration `env:"WORKER_CLAIM_CHECK_TIMEOUT"`
+}
+
+// Tracing contains the configuration for OpenTelemetry tracing. Tracing
+// is disabled if no exporter is set.
+type Tracing struct {
+	// Exporter is the exporter of spans: otlp, stdout or file.
+	Exporter string `env:"WORKER_TRACING_EXPORTER"`
+	// Endpoint is the address of the OTLP collector. The OpenTelemetry
+	// environment variables are used if empty.
+	Endpoint    string  `env:"WORKER_TRACING_ENDPOINT"`
+	Insecure    bool    `env:"WORKER_TRACING_INSECURE"`
+	File        string  `env:"WORKER_TRACING_FILE"`
+	SampleRatio float64 `env:"WORKER_TRACING_SAMPLE_RATIO"`
+}
+
 // New creates a new *Configuration based on environment variables
 // and default values.
 func New() (*Configuration, error) {
 	c := &Configuration{
 		Server: Server{
-			Host:  defaultHost,
-			Port:  defaultPort,
-			Type:  defaultType,
-			Name:  defaultName,
-			Queue: defaultQueue,
-			Topic: defaultTopic,
+			Host:            defaultHost,
+			Port:            defaultPort,
+			Type:            defaultType,
+			Name:            defaultName,
+			Queue:           defaultQueue,
+			Topic:           defaultTopic,
+			HealthPort:      defaultHealthPort,
+			ShutdownDelay:   defaultShutdownDelay,
+			ShutdownTimeout: defaultShutdownTimeout,
+			Metrics: Metrics{
+				Enabled: true,
+			},
 		},
 		Storer: Storer{
 			Type:    defaultStorerType,
 			Name:    defaultStorerName,
 			Timeout: defaultStorerTimeout,
 		},
+		State: State{
+			Timeout:         defaultStateTimeout,
+			StatusRetention: defaultStateStatusRetention,
+		},
+		ClaimCheck: ClaimCheck{
+			Timeout: defaultClaimCheckTimeout,
+		},
+		Health: Health{
+			Timeout: defaultHealthTimeout,
+		},
+		Tracing: Tracing{
+			SampleRatio: defaultTracingSampleRatio,
+		},
 	}
 
-	if err := env.Parse(c); err != nil {
+	if err := parseEnv(c); err != nil {
 		return nil, err
 	}
 
 	return c, nil
 }
 
+// Components returns the DAPR components the application uses: the
+// binding or pubsub of the server, the binding of the storer, and the
+// claim check binding and state store if configured.
+func (c Configuration) Components() []health.Component {
+	server := health.Component{Kind: health.KindBindings, Name: c.Server.Name}
+	if c.Server.Type == typeServerPubsub {
+		server.Kind = health.KindPubsub
+	}
+	components := []health.Component{server, {Kind: health.KindBindings, Name: c.Storer.Name}}
+	if len(c.ClaimCheck.Name) > 0 {
+		components = append(components, health.Component{Kind: health.KindBindings, Name: c.ClaimCheck.Name})
+	}
+	if len(c.State.Name) > 0 {
+		components = append(components, health.Component{Kind: health.KindState, Name: c.State.Name})
+	}
+	return components
+}
+
+// SetupHealth creates a new *health.DaprChecker for the provided
+// components.
+func SetupHealth(c Health, components []health.Component) (*health.DaprChecker, error) {
+	checker, err := health.NewDaprChecker(func(o *health.DaprCheckerOptions) {
+		o.Components = components
+		o.Timeout = c.Timeout
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup health: %w", err)
+	}
+	return checker, nil
+}
+
+// SetupTracing creates a new *tracing.Provider based on the provided
+// configuration. Returns nil if no exporter is configured.
+func SetupTracing(c Tracing) (*tracing.Provider, error) {
+	if len(c.Exporter) == 0 {
+		return nil, nil
+	}
+	p, err := tracing.NewProvider(func(o *tracing.ProviderOptions) {
+		o.Exporter = c.Exporter
+		o.Endpoint = c.Endpoint
+		o.Insecure = c.Insecure
+		o.File = c.File
+		o.SampleRatio = c.SampleRatio
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup tracing: %w", err)
+	}
+	return p, nil
+}
+
+// SetupState creates a new state.Store based on the provided configuration.
+// Returns nil if no state store name is configured.
+func SetupState(c State) (state.Store, error) {
+	if len(c.Name) == 0 {
+		return nil, nil
+	}
+	s, err := state.NewDaprStore(func(o *state.DaprStoreOptions) {
+		o.Name = c.Name
+		o.Timeout = c.Timeout
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup state: %w", err)
+	}
+	return s, nil
+}
+
+// SetupClaimChecker creates a new report.ClaimChecker based on the provided
+// configuration. Returns nil if no claim check name is configured.
+func SetupClaimChecker(c ClaimCheck) (report.ClaimChecker, error) {
+	if len(c.Name) == 0 {
+		return nil, nil
+	}
+	claims, err := report.NewBlobClaimChecker(func(o *report.BlobClaimCheckerOptions) {
+		o.Name = c.Name
+		o.Timeout = c.Timeout
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup claim checker: %w", err)
+	}
+	return claims, nil
+}
+
 // SetupReporter creates a new report.Service based on the provided configuration.
-func SetupReporter(c Storer) (report.Service, error) {
+// If store is not nil it is used to track the status of reports, which are
+// kept for retention, and onStatusError is called when a status could not
+// be set. If m or t is not nil the storer and service are instrumented.
+func SetupReporter(c Storer, store state.Store, retention time.Duration, onStatusError func(id string, err error), m *metrics.Metrics, t *tracing.Provider) (report.Service, error) {
 	var err error
 	var storer report.Storer
 	if c.Type == storerTypeBlob {
@@ -100,5 +284,42 @@ func SetupReporter(c Storer) (report.Service, error) {
 		return nil, fmt.Errorf("setup service: unknown storer type: %q", c.Type)
 	}
 
-	return report.NewService(storer)
+	if t != nil {
+		storer = t.Storer(storer, c.Type, c.Name)
+	}
+	if m != nil {
+		storer = m.Storer(storer, c.Type, c.Name)
+	}
+
+	svc, err := report.NewService(storer, func(o *report.ServiceOptions) {
+		o.Store = store
+		o.Retention = retention
+		o.OnStatusError = onStatusError
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return m.Service(svc), nil
+	}
+	return svc, nil
+}
+
+// parseEnv parses the provided value using the env package.
+func parseEnv(v any) error {
+	return env.ParseWithOptions(v, env.Options{
+		FuncMap: map[reflect.Type]env.ParserFunc{
+			reflect.TypeOf(map[string]struct{}{}): parseStructMap,
+		},
+	})
+}
+
+// parseStructMap parses the provided comma separated string into a map[string]struct{}.
+func parseStructMap(v string) (any, error) {
+	parts := strings.Split(strings.ReplaceAll(v, " ", ""), ",")
+	m := make(map[string]struct{}, len(parts))
+	for _, v := range parts {
+		m[v] = struct{}{}
+	}
+	return m, nil
 }
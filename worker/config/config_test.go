@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/RedeployAB/container-apps-dapr/worker/health"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -21,47 +22,101 @@ func TestNew(t *testing.T) {
 			input: map[string]string{},
 			want: &Configuration{
 				Server: Server{
-					Host:  defaultHost,
-					Port:  defaultPort,
-					Type:  defaultType,
-					Name:  defaultName,
-					Queue: defaultQueue,
-					Topic: defaultTopic,
+					Host:            defaultHost,
+					Port:            defaultPort,
+					Type:            defaultType,
+					Name:            defaultName,
+					Queue:           defaultQueue,
+					Topic:           defaultTopic,
+					HealthPort:      defaultHealthPort,
+					ShutdownDelay:   defaultShutdownDelay,
+					ShutdownTimeout: defaultShutdownTimeout,
+					Metrics: Metrics{
+						Enabled: true,
+					},
 				},
 				Storer: Storer{
 					Type:    defaultStorerType,
 					Name:    defaultStorerName,
 					Timeout: defaultStorerTimeout,
 				},
+				State: State{
+					Timeout:         defaultStateTimeout,
+					StatusRetention: defaultStateStatusRetention,
+				},
+				ClaimCheck: ClaimCheck{
+					Timeout: defaultClaimCheckTimeout,
+				},
+				Health: Health{
+					Timeout: defaultHealthTimeout,
+				},
+				Tracing: Tracing{
+					SampleRatio: defaultTracingSampleRatio,
+				},
 			},
 		},
 		{
 			name: "With environment variables",
 			input: map[string]string{
-				"WORKER_HOST":           "localhost",
-				"WORKER_PORT":           "3001",
-				"WORKER_NAME":           "reports-test",
-				"WORKER_TYPE":           "pubsub",
-				"WORKER_QUEUE":          "create-test",
-				"WORKER_TOPIC":          "create-test",
-				"WORKER_STORER_TYPE":    "blob-test",
-				"WORKER_STORER_NAME":    "reports-test",
-				"WORKER_STORER_TIMEOUT": "5s",
+				"WORKER_HOST":                   "localhost",
+				"WORKER_PORT":                   "3001",
+				"WORKER_NAME":                   "reports-test",
+				"WORKER_TYPE":                   "pubsub",
+				"WORKER_QUEUE":                  "create-test",
+				"WORKER_TOPIC":                  "create-test",
+				"WORKER_STORER_TYPE":            "blob-test",
+				"WORKER_STORER_NAME":            "reports-test",
+				"WORKER_STORER_TIMEOUT":         "5s",
+				"WORKER_STATE_NAME":             "reports-state-test",
+				"WORKER_STATE_TIMEOUT":          "5s",
+				"WORKER_STATE_STATUS_RETENTION": "24h",
+				"WORKER_CLAIM_CHECK_NAME":       "reports-claims-test",
+				"WORKER_CLAIM_CHECK_TIMEOUT":    "5s",
+				"WORKER_HEALTH_PORT":            "3003",
+				"WORKER_HEALTH_TIMEOUT":         "1s",
+				"WORKER_SHUTDOWN_DELAY":         "10s",
+				"WORKER_SHUTDOWN_TIMEOUT":       "20s",
+				"WORKER_METRICS_ENABLED":        "false",
+				"WORKER_LOG_REDACT_FIELDS":      "LockToken,Label",
+				"WORKER_TRACING_EXPORTER":       "file",
+				"WORKER_TRACING_FILE":           "traces.json",
+				"WORKER_TRACING_SAMPLE_RATIO":   "0.5",
 			},
 			want: &Configuration{
 				Server: Server{
-					Host:  "localhost",
-					Port:  3001,
-					Type:  "pubsub",
-					Name:  "reports-test",
-					Queue: "create-test",
-					Topic: "create-test",
+					Host:            "localhost",
+					Port:            3001,
+					Type:            "pubsub",
+					Name:            "reports-test",
+					Queue:           "create-test",
+					Topic:           "create-test",
+					RedactFields:    map[string]struct{}{"LockToken": {}, "Label": {}},
+					HealthPort:      3003,
+					ShutdownDelay:   time.Second * 10,
+					ShutdownTimeout: time.Second * 20,
 				},
 				Storer: Storer{
 					Type:    "blob-test",
 					Name:    "reports-test",
 					Timeout: time.Second * 5,
 				},
+				State: State{
+					Name:            "reports-state-test",
+					Timeout:         time.Second * 5,
+					StatusRetention: time.Hour * 24,
+				},
+				ClaimCheck: ClaimCheck{
+					Name:    "reports-claims-test",
+					Timeout: time.Second * 5,
+				},
+				Health: Health{
+					Timeout: time.Second,
+				},
+				Tracing: Tracing{
+					Exporter:    "file",
+					File:        "traces.json",
+					SampleRatio: 0.5,
+				},
 			},
 		},
 		{
@@ -93,6 +148,50 @@ func TestNew(t *testing.T) {
 
 }
 
+func TestConfiguration_Components(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Configuration
+		want  []health.Component
+	}{
+		{
+			name: "Queue",
+			input: Configuration{
+				Server: Server{Type: typeServerQueue, Name: "reports"},
+				Storer: Storer{Name: "reports-output"},
+			},
+			want: []health.Component{
+				{Kind: health.KindBindings, Name: "reports"},
+				{Kind: health.KindBindings, Name: "reports-output"},
+			},
+		},
+		{
+			name: "Pubsub with claim checks and state",
+			input: Configuration{
+				Server:     Server{Type: typeServerPubsub, Name: "reports"},
+				Storer:     Storer{Name: "reports-output"},
+				ClaimCheck: ClaimCheck{Name: "reports-claims"},
+				State:      State{Name: "reports-state"},
+			},
+			want: []health.Component{
+				{Kind: health.KindPubsub, Name: "reports"},
+				{Kind: health.KindBindings, Name: "reports-output"},
+				{Kind: health.KindBindings, Name: "reports-claims"},
+				{Kind: health.KindState, Name: "reports-state"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.Components()
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Components() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
 func setEnvVars(vars map[string]string) {
 	os.Clearenv()
 	for k, v := range vars {
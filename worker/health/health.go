@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	defaultCheckerTimeout = time.Second * 2
+)
+
+// Component kinds.
+const (
+	KindBindings = "bindings"
+	KindPubsub   = "pubsub"
+	KindState    = "state"
+)
+
+var (
+	// ErrComponentNotFound is returned when a component is not registered
+	// with the DAPR sidecar.
+	ErrComponentNotFound = errors.New("component not found")
+)
+
+// Component is a DAPR component. Kind is the start of the type of the
+// component, such as "bindings" for "bindings.azure.servicebusqueues".
+type Component struct {
+	Kind string
+	Name string
+}
+
+// String returns the kind and name of the component.
+func (c Component) String() string {
+	return c.Kind + "/" + c.Name
+}
+
+// client is the interface that wraps around method GetMetadata.
+type client interface {
+	GetMetadata(ctx context.Context) (*dapr.GetMetadataResponse, error)
+}
+
+// DaprChecker checks that the DAPR sidecar is reachable and that the
+// components are registered with it.
+type DaprChecker struct {
+	client
+	components []Component
+	timeout    time.Duration
+}
+
+// DaprCheckerOptions contains settings for a DaprChecker.
+type DaprCheckerOptions struct {
+	Components []Component
+	Timeout    time.Duration
+}
+
+// DaprCheckerOption is a function that sets *DaprCheckerOptions.
+type DaprCheckerOption func(o *DaprCheckerOptions)
+
+// NewDaprChecker creates a new *DaprChecker with the provided options.
+func NewDaprChecker(options ...DaprCheckerOption) (*DaprChecker, error) {
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := newDaprChecker(options...)
+	c.client = client
+
+	return c, nil
+}
+
+// newDaprChecker creates a new *DaprChecker with the provided options.
+func newDaprChecker(options ...DaprCheckerOption) *DaprChecker {
+	opts := DaprCheckerOptions{
+		Timeout: defaultCheckerTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &DaprChecker{
+		components: opts.Components,
+		timeout:    opts.Timeout,
+	}
+}
+
+// Check gets the metadata of the DAPR sidecar and returns an error if the
+// sidecar is unreachable or a component is not registered.
+func (c DaprChecker) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	metadata, err := c.GetMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("getting sidecar metadata: %w", err)
+	}
+	if metadata == nil {
+		return errors.New("getting sidecar metadata: empty response")
+	}
+
+	for _, component := range c.components {
+		if !registered(metadata.RegisteredComponents, component) {
+			return fmt.Errorf("%w: %s", ErrComponentNotFound, component)
+		}
+	}
+	return nil
+}
+
+// registered returns true if the component is in the provided registered
+// components.
+func registered(components []*dapr.MetadataRegisteredComponents, component Component) bool {
+	for _, c := range components {
+		if c != nil && c.Name == component.Name && strings.HasPrefix(c.Type, component.Kind+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/RedeployAB/container-apps-dapr/worker/config"
+	"github.com/RedeployAB/container-apps-dapr/worker/metrics"
 	"github.com/RedeployAB/container-apps-dapr/worker/server"
 )
 
@@ -18,24 +23,83 @@ func main() {
 		os.Exit(1)
 	}
 
-	reporter, err := config.SetupReporter(cfg.Storer)
+	store, err := config.SetupState(cfg.State)
+	if err != nil {
+		log.Error("Error setting up state store.", "error", err)
+		os.Exit(1)
+	}
+
+	provider, err := config.SetupTracing(cfg.Tracing)
+	if err != nil {
+		log.Error("Error setting up tracing.", "error", err)
+		os.Exit(1)
+	}
+	var serverTracing server.Tracing
+	if provider != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+			if err := provider.Shutdown(ctx); err != nil {
+				log.Error("Error shutting down tracing.", "error", err)
+			}
+		}()
+		serverTracing = provider
+	}
+
+	var m *metrics.Metrics
+	var serverMetrics server.Metrics
+	if cfg.Server.Metrics.Enabled {
+		m = metrics.New()
+		serverMetrics = m
+	}
+
+	reporter, err := config.SetupReporter(cfg.Storer, store, cfg.State.StatusRetention, func(id string, err error) {
+		log.Error("Error setting report status.", "error", err, "id", id)
+	}, m, provider)
 	if err != nil {
 		log.Error("Error setting up reporter.", "error", err)
 		os.Exit(1)
 	}
 
+	claims, err := config.SetupClaimChecker(cfg.ClaimCheck)
+	if err != nil {
+		log.Error("Error setting up claim checker.", "error", err)
+		os.Exit(1)
+	}
+
+	checker, err := config.SetupHealth(cfg.Health, cfg.Components())
+	if err != nil {
+		log.Error("Error setting up health checks.", "error", err)
+		os.Exit(1)
+	}
+
 	srv, err := server.New(server.Options{
-		Reporter: reporter,
-		Logger:   log,
-		Address:  cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.Port),
-		Type:     server.Type(cfg.Server.Type),
-		Name:     cfg.Server.Name,
-		Queue:    cfg.Server.Queue,
-		Topic:    cfg.Server.Topic,
+		Reporter:        reporter,
+		ClaimChecker:    claims,
+		Logger:          log,
+		Address:         cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.Port),
+		HealthAddress:   cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.HealthPort),
+		Health:          checker,
+		Metrics:         serverMetrics,
+		Tracing:         serverTracing,
+		RedactFields:    cfg.Server.RedactFields,
+		ShutdownDelay:   cfg.Server.ShutdownDelay,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		Type:            server.Type(cfg.Server.Type),
+		Name:            cfg.Server.Name,
+		Queue:           cfg.Server.Queue,
+		Topic:           cfg.Server.Topic,
 	})
 	if err != nil {
 		log.Error("Error creating server.", "error", err)
+		os.Exit(1)
 	}
 
-	srv.Start()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		log.Error("Server error.", "error", err)
+		os.Exit(1)
+	}
 }
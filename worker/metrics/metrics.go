@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "worker"
+)
+
+// Outcomes of an operation.
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+// Metrics contains the Prometheus collectors of the worker and the
+// registry they are registered with.
+type Metrics struct {
+	registry       *prometheus.Registry
+	received       *prometheus.CounterVec
+	inFlight       *prometheus.GaugeVec
+	createDuration *prometheus.HistogramVec
+	storeDuration  *prometheus.HistogramVec
+}
+
+// New creates a new *Metrics with the collectors registered with a new
+// registry, together with the Go and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_received_total",
+			Help:      "Number of messages received by type, component and topic.",
+		}, []string{"type", "component", "topic"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "messages_in_flight",
+			Help:      "Number of messages being handled by type, component and topic.",
+		}, []string{"type", "component", "topic"}),
+		createDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "service_create_duration_seconds",
+			Help:      "Latency of report creation by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storer_store_duration_seconds",
+			Help:      "Latency of storing reports by storer type, component and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type", "component", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.received,
+		m.inFlight,
+		m.createDuration,
+		m.storeDuration,
+	)
+	return m
+}
+
+// Handler returns a handler that serves the metrics in the Prometheus
+// exposition format.
+func (m Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Received records a received message of the provided type ("queue" or
+// "pubsub"), component and topic, and counts it as in flight until the
+// returned function is called.
+func (m Metrics) Received(typ, component, topic string) func() {
+	m.received.WithLabelValues(typ, component, topic).Inc()
+	inFlight := m.inFlight.WithLabelValues(typ, component, topic)
+	inFlight.Inc()
+	return inFlight.Dec
+}
+
+// outcome returns the outcome of an operation that returned err.
+func outcome(err error) string {
+	if err != nil {
+		return outcomeError
+	}
+	return outcomeSuccess
+}
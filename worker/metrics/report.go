@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/report"
+)
+
+// service is a report.Service that records the latency and outcome of
+// the wrapped service.
+type service struct {
+	s report.Service
+	m Metrics
+}
+
+// Service wraps the provided service and records the latency and outcome
+// of creating reports.
+func (m Metrics) Service(s report.Service) report.Service {
+	return &service{s: s, m: m}
+}
+
+// Create the report with the wrapped service.
+func (s service) Create(ctx context.Context, r report.Report) error {
+	start := time.Now()
+	err := s.s.Create(ctx, r)
+	s.m.createDuration.WithLabelValues(outcome(err)).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// Fail records the report as failed with the wrapped service.
+func (s service) Fail(id string, reason string) {
+	s.s.Fail(id, reason)
+}
+
+// storer is a report.Storer that records the latency and outcome of the
+// wrapped storer.
+type storer struct {
+	s         report.Storer
+	m         Metrics
+	typ       string
+	component string
+}
+
+// Storer wraps the provided storer and records the latency and outcome of
+// storing reports with the storer type and component name as labels.
+func (m Metrics) Storer(s report.Storer, typ, component string) report.Storer {
+	return &storer{s: s, m: m, typ: typ, component: component}
+}
+
+// Store the report with the wrapped storer.
+func (s storer) Store(ctx context.Context, r report.Report) error {
+	start := time.Now()
+	err := s.s.Store(ctx, r)
+	s.m.storeDuration.WithLabelValues(s.typ, s.component, outcome(err)).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/report"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_Service(t *testing.T) {
+	var tests = []struct {
+		name        string
+		input       error
+		wantOutcome string
+	}{
+		{
+			name:        "Success",
+			wantOutcome: outcomeSuccess,
+		},
+		{
+			name:        "Error",
+			input:       errors.New("error"),
+			wantOutcome: outcomeError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New()
+			s := m.Service(mockService{err: test.input})
+
+			if err := s.Create(context.Background(), report.Report{ID: "123"}); !errors.Is(err, test.input) {
+				t.Errorf("Create() = unexpected error, want: %v, got: %v\n", test.input, err)
+			}
+			if got := testutil.CollectAndCount(m.createDuration.MustCurryWith(map[string]string{"outcome": test.wantOutcome})); got != 1 {
+				t.Errorf("Create() = unexpected result, want outcome %s\n", test.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestMetrics_Storer(t *testing.T) {
+	m := New()
+	s := m.Storer(mockStorer{err: errors.New("error")}, "blob", "reports-output")
+
+	if err := s.Store(context.Background(), report.Report{ID: "123"}); err == nil {
+		t.Errorf("Store() = unexpected result, want error\n")
+	}
+	if got := testutil.CollectAndCount(m.storeDuration.MustCurryWith(map[string]string{"type": "blob", "component": "reports-output", "outcome": outcomeError})); got != 1 {
+		t.Errorf("Store() = unexpected result, want labels of storer and outcome %s\n", outcomeError)
+	}
+}
+
+func TestMetrics_Received(t *testing.T) {
+	m := New()
+
+	done := m.Received("pubsub", "reports", "create")
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues("pubsub", "reports", "create")); got != 1 {
+		t.Errorf("Received() = unexpected in flight, want: 1, got: %v\n", got)
+	}
+	done()
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues("pubsub", "reports", "create")); got != 0 {
+		t.Errorf("Received() = unexpected in flight, want: 0, got: %v\n", got)
+	}
+	if got := testutil.ToFloat64(m.received.WithLabelValues("pubsub", "reports", "create")); got != 1 {
+		t.Errorf("Received() = unexpected received, want: 1, got: %v\n", got)
+	}
+}
+
+type mockService struct {
+	err error
+}
+
+func (s mockService) Create(ctx context.Context, r report.Report) error {
+	return s.err
+}
+
+func (s mockService) Fail(id string, reason string) {}
+
+type mockStorer struct {
+	err error
+}
+
+func (s mockStorer) Store(ctx context.Context, r report.Report) error {
+	return s.err
+}
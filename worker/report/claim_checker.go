@@ -0,0 +1,162 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+)
+
+const (
+	defaultClaimCheckerName    = "reports-claims"
+	defaultClaimCheckerTimeout = time.Second * 10
+)
+
+// ClaimChecker is the interface that wraps around methods Resolve and Release.
+type ClaimChecker interface {
+	Resolve(ctx context.Context, r Report) (Report, error)
+	Release(ctx context.Context, name string, parts int) error
+}
+
+// BlobClaimChecker is a claim checker that gets the data of reports with a
+// claim check from a blob storage.
+type BlobClaimChecker struct {
+	client
+	name    string
+	timeout time.Duration
+}
+
+// BlobClaimCheckerOptions contains options for BlobClaimChecker.
+type BlobClaimCheckerOptions struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// BlobClaimCheckerOption is a function that sets *BlobClaimCheckerOptions.
+type BlobClaimCheckerOption func(o *BlobClaimCheckerOptions)
+
+// NewBlobClaimChecker creates a BlobClaimChecker with the provided options.
+func NewBlobClaimChecker(options ...BlobClaimCheckerOption) (*BlobClaimChecker, error) {
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := newBlobClaimChecker(options...)
+	c.client = client
+
+	return c, nil
+}
+
+// newBlobClaimChecker creates a new *BlobClaimChecker with the provided options.
+func newBlobClaimChecker(options ...BlobClaimCheckerOption) *BlobClaimChecker {
+	opts := BlobClaimCheckerOptions{
+		Name:    defaultClaimCheckerName,
+		Timeout: defaultClaimCheckerTimeout,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &BlobClaimChecker{
+		name:    opts.Name,
+		timeout: opts.Timeout,
+	}
+}
+
+// Resolve gets the data of a report with a claim check and returns the
+// report with the data in place of the claim check. Data stored in parts
+// is joined in order. A report without a claim check is returned as it is.
+func (c BlobClaimChecker) Resolve(ctx context.Context, r Report) (Report, error) {
+	if len(r.ClaimCheck) == 0 {
+		return r, nil
+	}
+
+	var data []byte
+	if r.ClaimCheckParts == 0 {
+		var err error
+		if data, err = c.get(ctx, r.ClaimCheck); err != nil {
+			return Report{}, err
+		}
+	} else {
+		data = make([]byte, 0, r.Size)
+		for _, name := range partNames(r.ClaimCheck, r.ClaimCheckParts) {
+			part, err := c.get(ctx, name)
+			if err != nil {
+				return Report{}, err
+			}
+			data = append(data, part...)
+		}
+	}
+	r.Data = data
+	r.ClaimCheck = ""
+	r.ClaimCheckParts = 0
+	r.Size = 0
+	return r, nil
+}
+
+// Release removes the stored data of a claim check with the provided
+// number of parts. Data stored as a whole has 0 parts.
+func (c BlobClaimChecker) Release(ctx context.Context, name string, parts int) error {
+	for _, name := range partNames(name, parts) {
+		if err := c.invoke(ctx, "delete", name); err != nil {
+			return errors.New("releasing claim check: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// get returns the stored data with the provided name.
+func (c BlobClaimChecker) get(ctx context.Context, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	out, err := c.client.InvokeBinding(ctx, &dapr.InvokeBindingRequest{
+		Name:      c.name,
+		Operation: "get",
+		Metadata:  blobMetadata(name),
+	})
+	if err != nil {
+		return nil, errors.New("getting claim check: " + err.Error())
+	}
+	return out.Data, nil
+}
+
+// invoke invokes the provided operation on the stored data with the
+// provided name.
+func (c BlobClaimChecker) invoke(ctx context.Context, operation, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.InvokeBinding(ctx, &dapr.InvokeBindingRequest{
+		Name:      c.name,
+		Operation: operation,
+		Metadata:  blobMetadata(name),
+	})
+	return err
+}
+
+// partNames returns the names of the parts of stored data. Data stored
+// as a whole has 0 parts and is stored with the name itself.
+func partNames(name string, parts int) []string {
+	if parts == 0 {
+		return []string{name}
+	}
+	names := make([]string, parts)
+	for i := range parts {
+		names[i] = name + "." + strconv.Itoa(i)
+	}
+	return names
+}
+
+// blobMetadata returns the metadata of a binding call for the stored data
+// with the provided name.
+func blobMetadata(name string) map[string]string {
+	return map[string]string{
+		"key":      name,
+		"blobName": name,
+	}
+}
@@ -0,0 +1,181 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewBlobClaimChecker(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []BlobClaimCheckerOption
+		want  *BlobClaimChecker
+	}{
+		{
+			name:  "With empty options",
+			input: []BlobClaimCheckerOption{},
+			want: &BlobClaimChecker{
+				name:    defaultClaimCheckerName,
+				timeout: defaultClaimCheckerTimeout,
+			},
+		},
+		{
+			name: "With options",
+			input: []BlobClaimCheckerOption{
+				func(o *BlobClaimCheckerOptions) {
+					o.Name = "test"
+					o.Timeout = time.Second * 30
+				},
+			},
+			want: &BlobClaimChecker{
+				name:    "test",
+				timeout: time.Second * 30,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newBlobClaimChecker(test.input...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(BlobClaimChecker{})); diff != "" {
+				t.Errorf("newBlobClaimChecker(%+v) = unexpected result (-want +got):\n%s\n", test.input, diff)
+			}
+		})
+	}
+}
+
+func TestBlobClaimChecker_Resolve(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client *mockClient
+			report Report
+		}
+		want           Report
+		wantOperations []string
+		wantErr        error
+	}{
+		{
+			name: "Without claim check",
+			input: struct {
+				client *mockClient
+				report Report
+			}{
+				client: &mockClient{},
+				report: NewReport("123", []byte("test")),
+			},
+			want: NewReport("123", []byte("test")),
+		},
+		{
+			name: "With claim check",
+			input: struct {
+				client *mockClient
+				report Report
+			}{
+				client: &mockClient{data: []byte("test")},
+				report: Report{ID: "123", ClaimCheck: "123.data"},
+			},
+			want:           NewReport("123", []byte("test")),
+			wantOperations: []string{"get 123.data"},
+		},
+		{
+			name: "With claim check in parts",
+			input: struct {
+				client *mockClient
+				report Report
+			}{
+				client: &mockClient{data: []byte("test")},
+				report: Report{ID: "123", ClaimCheck: "123.data", ClaimCheckParts: 2, Size: 8},
+			},
+			want:           NewReport("123", []byte("testtest")),
+			wantOperations: []string{"get 123.data.0", "get 123.data.1"},
+		},
+		{
+			name: "With claim check and attributes",
+			input: struct {
+				client *mockClient
+				report Report
+			}{
+				client: &mockClient{data: []byte("test")},
+				report: Report{ID: "123", ClaimCheck: "123.data", Type: "alert", Tenant: "client", Priority: 5, Labels: map[string]string{"region": "eu"}, Route: "urgent", RequestID: "abc"},
+			},
+			want:           Report{ID: "123", Data: []byte("test"), Type: "alert", Tenant: "client", Priority: 5, Labels: map[string]string{"region": "eu"}, Route: "urgent", RequestID: "abc"},
+			wantOperations: []string{"get 123.data"},
+		},
+		{
+			name: "With error",
+			input: struct {
+				client *mockClient
+				report Report
+			}{
+				client: &mockClient{err: errors.New("error")},
+				report: Report{ID: "123", ClaimCheck: "123.data"},
+			},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &BlobClaimChecker{
+				client:  test.input.client,
+				name:    "test",
+				timeout: time.Second * 30,
+			}
+
+			got, gotErr := c.Resolve(context.Background(), test.input.report)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Resolve() = unexpected result (-want +got):\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantOperations, test.input.client.operations); diff != "" {
+				t.Errorf("Resolve() = unexpected operations (-want +got):\n%s\n", diff)
+			}
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("Resolve() = unexpected result, want error %v, got nil\n", test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlobClaimChecker_Release(t *testing.T) {
+	var tests = []struct {
+		name           string
+		input          int
+		wantOperations []string
+	}{
+		{
+			name:           "Whole data",
+			input:          0,
+			wantOperations: []string{"delete 123.data"},
+		},
+		{
+			name:           "Data in parts",
+			input:          2,
+			wantOperations: []string{"delete 123.data.0", "delete 123.data.1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &mockClient{}
+			c := &BlobClaimChecker{
+				client:  client,
+				name:    "test",
+				timeout: time.Second * 30,
+			}
+
+			if err := c.Release(context.Background(), "123.data", test.input); err != nil {
+				t.Fatalf("Release() = unexpected error: %v\n", err)
+			}
+			if diff := cmp.Diff(test.wantOperations, client.operations); diff != "" {
+				t.Errorf("Release() = unexpected operations (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
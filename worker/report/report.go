@@ -2,12 +2,34 @@ package report
 
 import (
 	"encoding/json"
+	"time"
 )
 
-// Report represents a report with an ID and data.
+// Report represents a report with an ID and data. A report with a
+// claim check has its data stored separately, and ClaimCheck is the
+// name of the stored data. Data that was streamed into storage by the
+// endpoint is stored in ClaimCheckParts parts, and Size is the size of
+// the stored data. Type, Tenant, Priority and Labels are the
+// attributes the endpoint routes reports on, and Route is the name of the
+// route the report was sent with. RequestID is the ID of the request the
+// report was received with by the endpoint, Deadline is the time after
+// which the report should no longer be processed, and TraceContext carries
+// the W3C trace context (traceparent and tracestate) of the report from
+// the endpoint.
 type Report struct {
-	ID   string
-	Data []byte
+	ID              string
+	Data            []byte
+	ClaimCheck      string            `json:",omitempty"`
+	ClaimCheckParts int               `json:",omitempty"`
+	Size            int               `json:",omitempty"`
+	Type            string            `json:",omitempty"`
+	Tenant          string            `json:",omitempty"`
+	Priority        int               `json:",omitempty"`
+	Labels          map[string]string `json:",omitempty"`
+	Route           string            `json:",omitempty"`
+	RequestID       string            `json:",omitempty"`
+	Deadline        *time.Time        `json:",omitempty"`
+	TraceContext    map[string]string `json:",omitempty"`
 }
 
 // NewReport creates a new Report.
@@ -1,41 +1,101 @@
 package report
 
 import (
+	"context"
 	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/state"
 )
 
 // Storer is the interface that wraps around method Store.
 type Storer interface {
-	Store(r Report) error
+	Store(ctx context.Context, r Report) error
 }
 
-// Service is the interface that wraps around method Create.
+// Service is the interface that wraps around methods Create and Fail.
 type Service interface {
-	Create(r Report) error
+	Create(ctx context.Context, r Report) error
+	Fail(id string, reason string)
 }
 
 // service is the implementation of the Service interface.
 type service struct {
-	s Storer
+	s             Storer
+	statuses      *statuses
+	onStatusError func(id string, err error)
+}
+
+// ServiceOptions contains options for a service.
+type ServiceOptions struct {
+	// Store is the state store used to track the status of reports.
+	// Status tracking is disabled if nil.
+	Store state.Store
+	// Retention is the time statuses are kept. Statuses are kept until
+	// removed if 0.
+	Retention time.Duration
+	// OnStatusError is called when the status of a report could not be
+	// set. Optional.
+	OnStatusError func(id string, err error)
 }
 
+// ServiceOption is a function that sets *ServiceOptions.
+type ServiceOption func(o *ServiceOptions)
+
 // NewService creates a Service.
-func NewService(s Storer) (*service, error) {
+func NewService(s Storer, options ...ServiceOption) (*service, error) {
 	if s == nil {
 		return nil, errors.New("error")
 	}
 
-	return &service{
-		s: s,
-	}, nil
+	opts := ServiceOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	svc := &service{
+		s:             s,
+		onStatusError: opts.OnStatusError,
+	}
+	if opts.Store != nil {
+		svc.statuses = &statuses{store: opts.Store, retention: opts.Retention}
+	}
+
+	return svc, nil
 }
 
-// Create a report and stores it at the target for the reporter.
-func (s service) Create(r Report) error {
+// Create a report and stores it at the target for the reporter. If status
+// tracking is enabled the report is recorded as processing before it is
+// stored, and as stored or failed after. Status tracking is best-effort,
+// a status that could not be set does not fail the report.
+func (s service) Create(ctx context.Context, r Report) error {
 	if s.s == nil {
 		return errors.New("storer is nil")
 	}
+	s.setStatus(r.ID, StateProcessing, "")
 	// Do reporting work...
 	// Store the report.
-	return s.s.Store(r)
+	if err := s.s.Store(ctx, r); err != nil {
+		s.setStatus(r.ID, StateFailed, err.Error())
+		return err
+	}
+	s.setStatus(r.ID, StateStored, "")
+	return nil
+}
+
+// Fail records the report with the provided ID as failed with the provided
+// reason, without creating it. Used for reports that are skipped.
+func (s service) Fail(id string, reason string) {
+	s.setStatus(id, StateFailed, reason)
+}
+
+// setStatus sets the status of the report if status tracking is enabled.
+// Errors are passed to onStatusError, if set.
+func (s service) setStatus(id string, st State, reason string) {
+	if s.statuses == nil {
+		return
+	}
+	if err := s.statuses.set(id, st, reason); err != nil && s.onStatusError != nil {
+		s.onStatusError(id, errors.New("setting status: "+err.Error()))
+	}
 }
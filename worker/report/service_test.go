@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -71,7 +72,7 @@ func TestService_Create(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			service := service{s: test.input}
 
-			gotErr := service.Create(Report{})
+			gotErr := service.Create(context.Background(), Report{})
 
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("Create(%v) = unexpected result, want error %v, got nil\n", test.input, test.wantErr)
@@ -81,10 +82,94 @@ func TestService_Create(t *testing.T) {
 	}
 }
 
+func TestService_Create_Status(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			storer Storer
+			store  *mockStore
+		}
+		wantState       State
+		wantStatusError bool
+		wantErr         error
+	}{
+		{
+			name: "Stored",
+			input: struct {
+				storer Storer
+				store  *mockStore
+			}{
+				storer: &mockStorer{},
+				store:  &mockStore{},
+			},
+			wantState: StateStored,
+		},
+		{
+			name: "Failed",
+			input: struct {
+				storer Storer
+				store  *mockStore
+			}{
+				storer: &mockStorer{err: errors.New("error")},
+				store:  &mockStore{},
+			},
+			wantState: StateFailed,
+			wantErr:   errors.New("error"),
+		},
+		{
+			name: "With store error",
+			input: struct {
+				storer Storer
+				store  *mockStore
+			}{
+				storer: &mockStorer{},
+				store:  &mockStore{err: errors.New("error")},
+			},
+			wantStatusError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotStatusError bool
+			svc, _ := NewService(test.input.storer, func(o *ServiceOptions) {
+				o.Store = test.input.store
+				o.OnStatusError = func(id string, err error) {
+					gotStatusError = true
+				}
+			})
+
+			gotErr := svc.Create(context.Background(), NewReport("id", []byte("data")))
+
+			if (test.wantErr != nil) != (gotErr != nil) {
+				t.Errorf("Create() = unexpected result, want error %v, got %v\n", test.wantErr, gotErr)
+			}
+			if gotStatusError != test.wantStatusError {
+				t.Errorf("Create() = unexpected status error, want: %t, got: %t\n", test.wantStatusError, gotStatusError)
+			}
+
+			if len(test.wantState) == 0 {
+				return
+			}
+
+			got, err := svc.statuses.get("id")
+			if err != nil {
+				t.Fatalf("Create() = unexpected error getting status: %v\n", err)
+			}
+			if got.State != test.wantState {
+				t.Errorf("Create() = unexpected state, want: %s, got: %s\n", test.wantState, got.State)
+			}
+			if _, ok := got.Timestamps[StateProcessing]; !ok {
+				t.Errorf("Create() = unexpected result, want processing timestamp\n")
+			}
+		})
+	}
+}
+
 type mockStorer struct {
 	err error
 }
 
-func (s mockStorer) Store(r Report) error {
+func (s mockStorer) Store(ctx context.Context, r Report) error {
 	return s.err
 }
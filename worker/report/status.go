@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/state"
+)
+
+const (
+	statusKeyPrefix = "status:"
+)
+
+var (
+	// ErrStatusNotFound is returned when no status exists for a report.
+	ErrStatusNotFound = errors.New("status not found")
+)
+
+// State is the lifecycle state of a report.
+type State string
+
+const (
+	StateAccepted   State = "accepted"
+	StateQueued     State = "queued"
+	StateProcessing State = "processing"
+	StateStored     State = "stored"
+	StateFailed     State = "failed"
+)
+
+// stateOrder contains the order of the lifecycle states. Used to prevent
+// late updates from moving a status backwards.
+var stateOrder = map[State]int{
+	StateAccepted:   1,
+	StateQueued:     2,
+	StateProcessing: 3,
+	StateStored:     4,
+}
+
+// Status represents the lifecycle status of a report. Tenant is the client
+// that sent the report, Reporter is the name of the endpoint reporter that
+// accepted the report, and Deliveries are the results of the endpoint
+// reporters a report was sent with. They are kept as they are.
+type Status struct {
+	ID         string              `json:"id"`
+	Tenant     string              `json:"tenant,omitempty"`
+	State      State               `json:"state"`
+	Reason     string              `json:"reason,omitempty"`
+	Reporter   string              `json:"reporter,omitempty"`
+	Deliveries json.RawMessage     `json:"deliveries,omitempty"`
+	Timestamps map[State]time.Time `json:"timestamps"`
+	Updated    time.Time           `json:"updated"`
+}
+
+// Set the state of the status and record the time it was reached. A state
+// that comes before the current state is recorded, but does not replace
+// the current state. A failed state always replaces the current state,
+// and is only replaced by a state that comes after every state reached
+// before it, so that late updates do not hide a failure.
+func (s *Status) Set(state State, reason string, t time.Time) {
+	reached := s.reached()
+	if s.Timestamps == nil {
+		s.Timestamps = make(map[State]time.Time)
+	}
+	s.Timestamps[state] = t
+	s.Updated = t
+
+	switch {
+	case state == StateFailed:
+	case s.State == StateFailed && stateOrder[state] <= reached:
+		return
+	case s.State != StateFailed && stateOrder[state] < stateOrder[s.State]:
+		return
+	}
+	s.State = state
+	if state == StateFailed {
+		s.Reason = reason
+	} else {
+		s.Reason = ""
+	}
+}
+
+// reached returns the order of the latest state that has been reached.
+func (s Status) reached() int {
+	var order int
+	for state := range s.Timestamps {
+		order = max(order, stateOrder[state])
+	}
+	return order
+}
+
+// JSON returns a JSON representation of a Status.
+func (s Status) JSON() []byte {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// statuses handles the persistence of report statuses in a state store.
+// Statuses expire after retention, if set.
+type statuses struct {
+	store     state.Store
+	retention time.Duration
+}
+
+// get the status for the report with the provided ID.
+func (s statuses) get(id string) (Status, error) {
+	b, err := s.store.Get(statusKeyPrefix + id)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return Status{}, ErrStatusNotFound
+		}
+		return Status{}, err
+	}
+	var status Status
+	if err := json.Unmarshal(b, &status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+// set the state for the report with the provided ID. The status is
+// updated with optimistic concurrency, so that concurrent updates from the
+// endpoint are not overwritten.
+func (s statuses) set(id string, st State, reason string) error {
+	return s.store.Update(statusKeyPrefix+id, s.retention, func(value []byte) ([]byte, error) {
+		var status Status
+		if value != nil {
+			if err := json.Unmarshal(value, &status); err != nil {
+				return nil, err
+			}
+		}
+		status.ID = id
+		status.Set(st, reason, time.Now().UTC())
+		return status.JSON(), nil
+	})
+}
@@ -64,8 +64,8 @@ func newBlobStorer(options ...BlobStorerOption) *BlobStorer {
 }
 
 // Store a report in a blob storage.
-func (s BlobStorer) Store(r Report) error {
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+func (s BlobStorer) Store(ctx context.Context, r Report) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	if _, err := s.client.InvokeBinding(ctx, &dapr.InvokeBindingRequest{
@@ -79,7 +79,7 @@ func TestBlogStorer_Store(t *testing.T) {
 				timeout: time.Second * 30,
 			}
 
-			gotErr := storer.Store(NewReport("123", []byte("test")))
+			gotErr := storer.Store(context.Background(), NewReport("123", []byte("test")))
 
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("Store() = unexpected result, want error %v, got nil\n", test.wantErr)
@@ -89,9 +89,15 @@ func TestBlogStorer_Store(t *testing.T) {
 }
 
 type mockClient struct {
-	err error
+	err        error
+	data       []byte
+	operations []string
 }
 
 func (c *mockClient) InvokeBinding(ctx context.Context, in *dapr.InvokeBindingRequest) (*dapr.BindingEvent, error) {
-	return nil, c.err
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.operations = append(c.operations, in.Operation+" "+in.Metadata["blobName"])
+	return &dapr.BindingEvent{Data: c.data}, nil
 }
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HealthChecker is the interface that wraps around method Check.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthResponse is the response for a health or readiness check.
+type HealthResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// JSON returns the JSON representation of the health response.
+func (r HealthResponse) JSON() []byte {
+	b, _ := json.Marshal(&r)
+	return b
+}
+
+// healthRoutes returns a handler with the routes for liveness and
+// readiness checks, and metrics if enabled.
+func (s server) healthRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", s.healthHandler())
+	mux.Handle("/readyz", s.readyHandler())
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics.Handler())
+	}
+	return mux
+}
+
+// healthHandler returns a handler for liveness checks. The worker is
+// alive as long as it can respond.
+func (s server) healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+	})
+}
+
+// readyHandler returns a handler for readiness checks. The worker is not
+// ready while shutting down or if the health check of the DAPR sidecar
+// fails.
+func (s server) readyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.shuttingDown() {
+			writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "not ready", Reason: "The worker is shutting down."})
+			return
+		}
+		if s.health != nil {
+			if err := s.health.Check(r.Context()); err != nil {
+				s.log.Error("Readiness check failed.", "error", err)
+				writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "not ready", Reason: "The DAPR sidecar or a component is not ready."})
+				return
+			}
+		}
+		writeHealth(w, http.StatusOK, HealthResponse{Status: "ready"})
+	})
+}
+
+// shuttingDown returns true if the worker is shutting down.
+func (s server) shuttingDown() bool {
+	return s.shutdown != nil && s.shutdown.Load()
+}
+
+// writeHealth writes the health response with the provided status code.
+func writeHealth(w http.ResponseWriter, code int, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(resp.JSON())
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			path     string
+			health   HealthChecker
+			metrics  Metrics
+			shutdown bool
+		}
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "Alive",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path:     "/healthz",
+				health:   mockHealth{err: errors.New("error")},
+				shutdown: true,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Ready",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path:   "/readyz",
+				health: mockHealth{},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ready"}`,
+		},
+		{
+			name: "Sidecar not ready",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path:   "/readyz",
+				health: mockHealth{err: errors.New("error")},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"status":"not ready","reason":"The DAPR sidecar or a component is not ready."}`,
+		},
+		{
+			name: "Shutting down",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path:     "/readyz",
+				health:   mockHealth{},
+				shutdown: true,
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"status":"not ready","reason":"The worker is shutting down."}`,
+		},
+		{
+			name: "Metrics",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path:    "/metrics",
+				metrics: mockMetrics{},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `worker_messages_received_total 1`,
+		},
+		{
+			name: "Metrics disabled",
+			input: struct {
+				path     string
+				health   HealthChecker
+				metrics  Metrics
+				shutdown bool
+			}{
+				path: "/metrics",
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: "404 page not found\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log:      mockLogger{},
+				health:   test.input.health,
+				metrics:  test.input.metrics,
+				shutdown: &atomic.Bool{},
+			}
+			s.shutdown.Store(test.input.shutdown)
+
+			req := httptest.NewRequest(http.MethodGet, test.input.path, nil)
+			w := httptest.NewRecorder()
+
+			s.healthRoutes().ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != test.wantCode {
+				t.Errorf("healthRoutes() = unexpected result, want %d, got: %d\n", test.wantCode, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != test.wantBody {
+				t.Errorf("healthRoutes() = unexpected result, want %s, got: %s\n", test.wantBody, string(body))
+			}
+		})
+	}
+}
+
+type mockHealth struct {
+	err error
+}
+
+func (h mockHealth) Check(ctx context.Context) error {
+	return h.err
+}
+
+type mockMetrics struct{}
+
+func (m mockMetrics) Received(typ, component, topic string) func() {
+	return func() {}
+}
+
+func (m mockMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`worker_messages_received_total 1`))
+	})
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"strings"
+)
+
+const (
+	// redacted replaces the values of redacted fields in the log.
+	redacted = "[REDACTED]"
+)
+
+// redact returns a copy of the metadata with the values of the fields to
+// redact replaced. Field names are matched without regard to case.
+func (s server) redact(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 || len(s.redactFields) == 0 {
+		return metadata
+	}
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if _, ok := s.redactFields[strings.ToLower(k)]; ok {
+			m[k] = redacted
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServer_Redact(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			fields   map[string]struct{}
+			metadata map[string]string
+		}
+		want map[string]string
+	}{
+		{
+			name: "Without fields",
+			input: struct {
+				fields   map[string]struct{}
+				metadata map[string]string
+			}{
+				metadata: map[string]string{"LockToken": "token", "MessageId": "1"},
+			},
+			want: map[string]string{"LockToken": "token", "MessageId": "1"},
+		},
+		{
+			name: "With fields",
+			input: struct {
+				fields   map[string]struct{}
+				metadata map[string]string
+			}{
+				fields:   map[string]struct{}{"LockToken": {}},
+				metadata: map[string]string{"LockToken": "token", "MessageId": "1"},
+			},
+			want: map[string]string{"LockToken": redacted, "MessageId": "1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, _ := new(Options{Reporter: &mockReporter{}, RedactFields: test.input.fields})
+			got := s.redact(test.input.metadata)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("redact() = unexpected result, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -12,6 +12,8 @@ import (
 // pubsubReportHandler is the handler for the report topic.
 func (s server) pubsubReportHandler(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
 	s.log.Info("Event received.", "id", e.ID, "pubsub", e.PubsubName, "topic", e.Topic)
+	done := s.received(TypePubsub, s.topic)
+	defer done()
 
 	data, ok := e.Data.(string)
 	if !ok {
@@ -25,11 +27,22 @@ func (s server) pubsubReportHandler(ctx context.Context, e *common.TopicEvent) (
 		return false, err
 	}
 
-	if err := s.reporter.Create(r); err != nil {
-		s.log.Error("Failed to create report.", "error", err, "id", e.ID, "pubsub", e.PubsubName, "topic", e.Topic)
+	ctx, cancel, ok := withDeadline(ctx, r, nil)
+	defer cancel()
+	if !ok {
+		s.log.Info("Report deadline has passed, skipping.", "id", e.ID, "request_id", r.RequestID, "pubsub", e.PubsubName, "topic", e.Topic)
+		s.expire(ctx, r)
+		return false, nil
+	}
+
+	ctx, r, end := s.trace(ctx, TypePubsub, r, nil)
+	err = s.create(ctx, r)
+	end(err)
+	if err != nil {
+		s.log.Error("Failed to create report.", "error", err, "id", e.ID, "request_id", r.RequestID, "pubsub", e.PubsubName, "topic", e.Topic)
 		return false, err
 	}
-	s.log.Info("Report created.", "id", e.ID, "pubsub", e.PubsubName, "topic", e.Topic)
+	s.log.Info("Report created.", "id", e.ID, "request_id", r.RequestID, "pubsub", e.PubsubName, "topic", e.Topic)
 
 	return false, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/dapr/go-sdk/service/common"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestPubsubReportHandler(t *testing.T) {
@@ -15,8 +16,9 @@ func TestPubsubReportHandler(t *testing.T) {
 			reporter mockReporter
 			e        *common.TopicEvent
 		}
-		wantRetry bool
-		wantErr   error
+		wantRetry  bool
+		wantFailed []string
+		wantErr    error
 	}{
 		{
 			name: "Success",
@@ -82,6 +84,24 @@ func TestPubsubReportHandler(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Deadline in report has passed",
+			input: struct {
+				reporter mockReporter
+				e        *common.TopicEvent
+			}{
+				reporter: mockReporter{
+					err: errors.New("failed to create report"),
+				},
+				e: &common.TopicEvent{
+					ID:         "test",
+					PubsubName: "test",
+					Topic:      "test",
+					Data:       `{"id":"123","data":"testdata","deadline":"2024-01-02T03:04:05Z"}`,
+				},
+			},
+			wantFailed: []string{"123: deadline exceeded"},
+		},
 	}
 
 	for _, test := range tests {
@@ -99,6 +119,9 @@ func TestPubsubReportHandler(t *testing.T) {
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("pubsubReportHandler(%+v, %+v) = unexpected result, want error %v, got nil\n", test.input.reporter, test.input.e, test.wantErr)
 			}
+			if diff := cmp.Diff(test.wantFailed, test.input.reporter.failed); diff != "" {
+				t.Errorf("pubsubReportHandler() = unexpected failed reports, (-want +got):\n%s\n", diff)
+			}
 		})
 	}
 }
@@ -10,16 +10,30 @@ import (
 
 // queueReportHandler is the handler for the report queue.
 func (s server) queueReportHandler(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
-	s.log.Info("Message received.", "metadata", in.Metadata)
+	done := s.received(TypeQueue, s.queue)
+	defer done()
 
+	metadata := s.redact(in.Metadata)
 	var r report.Report
 	if err := json.Unmarshal(in.Data, &r); err != nil {
-		s.log.Error("Failed to deserialize report.", "error", err, "metadata", in.Metadata)
+		s.log.Error("Failed to deserialize report.", "error", err, "metadata", metadata)
 		return nil, err
 	}
+	s.log.Info("Message received.", "id", r.ID, "request_id", r.RequestID, "metadata", metadata)
 
-	if err := s.reporter.Create(r); err != nil {
-		s.log.Error("Failed to create report.", "error", err, "metadata", in.Metadata)
+	ctx, cancel, ok := withDeadline(ctx, r, in.Metadata)
+	defer cancel()
+	if !ok {
+		s.log.Info("Report deadline has passed, skipping.", "id", r.ID, "request_id", r.RequestID)
+		s.expire(ctx, r)
+		return []byte(`Message skipped.`), nil
+	}
+
+	ctx, r, end := s.trace(ctx, TypeQueue, r, in.Metadata)
+	err = s.create(ctx, r)
+	end(err)
+	if err != nil {
+		s.log.Error("Failed to create report.", "error", err, "id", r.ID, "request_id", r.RequestID, "metadata", metadata)
 		return nil, err
 	}
 
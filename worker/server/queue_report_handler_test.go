@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/dapr/go-sdk/service/common"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestQueueReportHandler(t *testing.T) {
@@ -15,8 +16,9 @@ func TestQueueReportHandler(t *testing.T) {
 			reporter mockReporter
 			in       *common.BindingEvent
 		}
-		want    []byte
-		wantErr error
+		want       []byte
+		wantFailed []string
+		wantErr    error
 	}{
 		{
 			name: "Success",
@@ -61,6 +63,40 @@ func TestQueueReportHandler(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Deadline in report has passed",
+			input: struct {
+				reporter mockReporter
+				in       *common.BindingEvent
+			}{
+				reporter: mockReporter{
+					err: errors.New("failed to create report"),
+				},
+				in: &common.BindingEvent{
+					Data:     []byte(`{"id":"123","data":"testdata","deadline":"2024-01-02T03:04:05Z"}`),
+					Metadata: map[string]string{},
+				},
+			},
+			want:       []byte(`Message skipped.`),
+			wantFailed: []string{"123: deadline exceeded"},
+		},
+		{
+			name: "Deadline in metadata has passed",
+			input: struct {
+				reporter mockReporter
+				in       *common.BindingEvent
+			}{
+				reporter: mockReporter{
+					err: errors.New("failed to create report"),
+				},
+				in: &common.BindingEvent{
+					Data:     []byte(`{"id":"123","data":"testdata"}`),
+					Metadata: map[string]string{"deadline": "2024-01-02T03:04:05Z"},
+				},
+			},
+			want:       []byte(`Message skipped.`),
+			wantFailed: []string{"123: deadline exceeded"},
+		},
 	}
 
 	for _, test := range tests {
@@ -78,6 +114,39 @@ func TestQueueReportHandler(t *testing.T) {
 			if test.wantErr != nil && gotErr == nil {
 				t.Errorf("queueReportHandler(%+v, %+v) = unexpected result, want error %v, got nil\n", test.input.reporter, test.input.in, test.wantErr)
 			}
+			if diff := cmp.Diff(test.wantFailed, test.input.reporter.failed); diff != "" {
+				t.Errorf("queueReportHandler() = unexpected failed reports, (-want +got):\n%s\n", diff)
+			}
 		})
 	}
 }
+
+func TestQueueReportHandler_Received(t *testing.T) {
+	metrics := &mockReceivedMetrics{}
+	s := &server{
+		log:      mockLogger{},
+		reporter: &mockReporter{},
+		metrics:  metrics,
+		name:     "reports",
+		queue:    "reports-queue",
+	}
+
+	if _, err := s.queueReportHandler(context.Background(), &common.BindingEvent{Data: []byte(`{"id":"123","data":"testdata"}`)}); err != nil {
+		t.Fatalf("queueReportHandler() = unexpected error: %v\n", err)
+	}
+
+	want := []string{"queue", "reports", "reports-queue"}
+	if diff := cmp.Diff(want, metrics.labels); diff != "" {
+		t.Errorf("queueReportHandler() = unexpected received labels, (-want +got):\n%s\n", diff)
+	}
+}
+
+type mockReceivedMetrics struct {
+	mockMetrics
+	labels []string
+}
+
+func (m *mockReceivedMetrics) Received(typ, component, topic string) func() {
+	m.labels = []string{typ, component, topic}
+	return func() {}
+}
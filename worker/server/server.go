@@ -1,20 +1,39 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/RedeployAB/container-apps-dapr/worker/report"
+	"github.com/RedeployAB/container-apps-dapr/worker/tracing"
 	"github.com/dapr/go-sdk/service/common"
 	daprd "github.com/dapr/go-sdk/service/grpc"
 )
 
 const (
-	defaultAddress = "0.0.0.0:3001"
+	defaultAddress       = "0.0.0.0:3001"
+	defaultHealthAddress = "0.0.0.0:3002"
+)
+
+const (
+	defaultShutdownTimeout = time.Second * 10
+)
+
+const (
+	// deadlineMetadataKey is the key of the deadline of a report in the
+	// metadata of messages.
+	deadlineMetadataKey = "deadline"
+	// reasonDeadlineExceeded is the reason of the failed status of a
+	// report that is skipped because its deadline has passed.
+	reasonDeadlineExceeded = "deadline exceeded"
 )
 
 // Type is the type of the server.
@@ -38,36 +57,82 @@ type log interface {
 	Info(msg string, args ...any)
 }
 
-// service is the interface that wraps around methods Start, Stop and
-// AddTopicEventHandler.
+// service is the interface that wraps around methods Start, Stop,
+// GracefulStop, AddBindingInvocationHandler and AddTopicEventHandler.
 type service interface {
 	Start() error
 	Stop() error
+	GracefulStop() error
 	AddBindingInvocationHandler(name string, fn common.BindingInvocationHandler) error
 	AddTopicEventHandler(sub *common.Subscription, fn common.TopicEventHandler) error
 }
 
+// Metrics is the interface that wraps around methods Received and Handler.
+type Metrics interface {
+	Received(typ, component, topic string) func()
+	Handler() http.Handler
+}
+
+// Tracing is the interface that wraps around method Start.
+type Tracing interface {
+	Start(ctx context.Context, typ, component string, carriers ...map[string]string) (context.Context, func(err error))
+}
+
 // server is the implementation of the Server interface. It contains
 // the common.Service from the Dapr SDK.
 type server struct {
-	service  service
-	reporter report.Service
-	log      log
-	address  string
-	name     string
-	queue    string
-	topic    string
+	service         service
+	reporter        report.Service
+	claims          report.ClaimChecker
+	log             log
+	health          HealthChecker
+	metrics         Metrics
+	tracing         Tracing
+	redactFields    map[string]struct{}
+	healthServer    *http.Server
+	shutdown        *atomic.Bool
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	address         string
+	healthAddress   string
+	name            string
+	queue           string
+	topic           string
 }
 
 // Options for the server.
 type Options struct {
 	Reporter report.Service
-	Logger   log
-	Type     Type
-	Address  string
-	Name     string
-	Queue    string
-	Topic    string
+	// ClaimChecker resolves reports with a claim check. Reports with a
+	// claim check fail if nil.
+	ClaimChecker report.ClaimChecker
+	Logger       log
+	// Health checks the readiness of the DAPR sidecar. Readiness only
+	// depends on shutdown if nil.
+	Health HealthChecker
+	// Metrics records received messages and is served on the health
+	// address. Disabled if nil.
+	Metrics Metrics
+	// Tracing creates a span for every received report. Tracing is
+	// disabled if nil.
+	Tracing Tracing
+	// RedactFields are the names of binding metadata fields with values
+	// that are redacted in the log.
+	RedactFields map[string]struct{}
+	// ShutdownDelay is the time the worker reports not ready before it
+	// stops.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout is the time in-flight messages have to complete
+	// when the worker stops.
+	ShutdownTimeout time.Duration
+	Type            Type
+	Address         string
+	// HealthAddress is the address of the HTTP server for liveness and
+	// readiness checks.
+	HealthAddress string
+	Name          string
+	Queue         string
+	Topic         string
 }
 
 // New creates and returns a server.
@@ -82,6 +147,11 @@ func New(options Options) (*server, error) {
 		return nil, err
 	}
 	s.service = ds
+	s.healthServer = &http.Server{
+		Addr:              s.healthAddress,
+		Handler:           s.healthRoutes(),
+		ReadHeaderTimeout: time.Second * 5,
+	}
 
 	if options.Type == TypeQueue {
 		if err := s.service.AddBindingInvocationHandler(s.name, s.queueReportHandler); err != nil {
@@ -112,6 +182,9 @@ func new(options Options) (*server, error) {
 	if len(options.Address) == 0 {
 		options.Address = defaultAddress
 	}
+	if len(options.HealthAddress) == 0 {
+		options.HealthAddress = defaultHealthAddress
+	}
 	if options.Logger == nil {
 		options.Logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	}
@@ -124,41 +197,194 @@ func new(options Options) (*server, error) {
 	if len(options.Topic) == 0 {
 		options.Topic = defaultTopic
 	}
+	if options.ShutdownTimeout == 0 {
+		options.ShutdownTimeout = defaultShutdownTimeout
+	}
+	var redactFields map[string]struct{}
+	for field := range options.RedactFields {
+		if redactFields == nil {
+			redactFields = make(map[string]struct{}, len(options.RedactFields))
+		}
+		redactFields[strings.ToLower(field)] = struct{}{}
+	}
 
 	return &server{
-		reporter: options.Reporter,
-		log:      options.Logger,
-		address:  options.Address,
-		name:     options.Name,
-		queue:    options.Queue,
-		topic:    options.Topic,
+		reporter:        options.Reporter,
+		claims:          options.ClaimChecker,
+		log:             options.Logger,
+		health:          options.Health,
+		metrics:         options.Metrics,
+		tracing:         options.Tracing,
+		redactFields:    redactFields,
+		shutdown:        &atomic.Bool{},
+		shutdownDelay:   options.ShutdownDelay,
+		shutdownTimeout: options.ShutdownTimeout,
+		address:         options.Address,
+		healthAddress:   options.HealthAddress,
+		name:            options.Name,
+		queue:           options.Queue,
+		topic:           options.Topic,
 	}, nil
 }
 
-// Start the server.
-func (s server) Start() {
+// create resolves the claim check of the report, if any, and creates the
+// report. The stored data of the claim check is released after the report
+// has been created, even if the context is cancelled.
+func (s server) create(ctx context.Context, r report.Report) error {
+	claimCheck, parts := r.ClaimCheck, r.ClaimCheckParts
+	if len(claimCheck) > 0 {
+		if s.claims == nil {
+			return errors.New("report has claim check but claim checks are not enabled")
+		}
+		var err error
+		if r, err = s.claims.Resolve(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	if err := s.reporter.Create(ctx, r); err != nil {
+		return err
+	}
+
+	if len(claimCheck) > 0 {
+		if err := s.claims.Release(context.WithoutCancel(ctx), claimCheck, parts); err != nil {
+			s.log.Error("Failed to release claim check.", "error", err, "id", r.ID)
+		}
+	}
+	return nil
+}
+
+// expire records a report whose deadline has passed as failed, and
+// releases the stored data of its claim check, if any, so that the report
+// can be skipped.
+func (s server) expire(ctx context.Context, r report.Report) {
+	s.reporter.Fail(r.ID, reasonDeadlineExceeded)
+	if len(r.ClaimCheck) > 0 && s.claims != nil {
+		if err := s.claims.Release(context.WithoutCancel(ctx), r.ClaimCheck, r.ClaimCheckParts); err != nil {
+			s.log.Error("Failed to release claim check.", "error", err, "id", r.ID)
+		}
+	}
+}
+
+// received records a received message of the server's type if metrics
+// are enabled. The returned function marks the message as handled.
+func (s server) received(typ Type, topic string) func() {
+	if s.metrics == nil {
+		return func() {}
+	}
+	return s.metrics.Received(string(typ), s.name, topic)
+}
+
+// trace starts a span for the report if tracing is enabled, with the trace
+// context of the metadata and the report as parent. Returns a copy of ctx
+// with the span, the report with the trace context of the span and a
+// function that ends the span.
+func (s server) trace(ctx context.Context, typ Type, r report.Report, metadata map[string]string) (context.Context, report.Report, func(err error)) {
+	if s.tracing == nil {
+		return ctx, r, func(err error) {}
+	}
+	ctx, end := s.tracing.Start(ctx, string(typ), s.name, metadata, r.TraceContext)
+	r.TraceContext = tracing.Inject(ctx)
+	return ctx, r, end
+}
+
+// withDeadline returns a copy of ctx with the deadline of the report, or
+// else the deadline in the metadata of the message, if any. Returns false
+// if the deadline has passed.
+func withDeadline(ctx context.Context, r report.Report, metadata map[string]string) (context.Context, context.CancelFunc, bool) {
+	var deadline time.Time
+	if r.Deadline != nil {
+		deadline = *r.Deadline
+	} else if v, ok := metadata[deadlineMetadataKey]; ok {
+		deadline, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	if deadline.IsZero() {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, true
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, deadline.After(time.Now())
+}
+
+// Run the server until the context is cancelled or the server fails.
+// When the context is cancelled the server reports not ready for the
+// shutdown delay, and in-flight messages are drained within the shutdown
+// timeout before it stops.
+func (s server) Run(ctx context.Context) error {
+	var ln net.Listener
+	if s.healthServer != nil {
+		var err error
+		if ln, err = net.Listen("tcp", s.healthServer.Addr); err != nil {
+			return fmt.Errorf("health server: %w", err)
+		}
+	}
+
+	errs := make(chan error, 2)
 	go func() {
 		if err := s.service.Start(); err != nil {
-			s.log.Error("Server failed to start.", "error", err)
-			os.Exit(1)
+			errs <- fmt.Errorf("server: %w", err)
 		}
 	}()
+	if s.healthServer != nil {
+		go func() {
+			if err := s.healthServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("health server: %w", err)
+			}
+		}()
+	}
 	s.log.Info("Server started.", "type", "server", "address", s.address)
-	sig, err := s.stop()
-	if err != nil {
-		s.log.Error("Error stopping server.", "error", err)
+
+	select {
+	case err := <-errs:
+		return errors.Join(err, s.stop())
+	case <-ctx.Done():
+	}
+
+	if s.shutdown != nil {
+		s.shutdown.Store(true)
 	}
-	s.log.Info("Server stopped.", "type", "server", "reason", sig.String())
+	time.Sleep(s.shutdownDelay)
+
+	if err := s.stop(); err != nil {
+		return err
+	}
+	s.log.Info("Server stopped.", "type", "server", "reason", context.Cause(ctx).Error())
+	return nil
 }
 
-// stop the server.
-func (s server) stop() (os.Signal, error) {
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-stop
+// stop the service and the health server. In-flight messages are drained
+// within the shutdown timeout. If they are not, the service is stopped
+// without waiting for them and the health server is closed.
+func (s server) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
 
-	if err := s.service.Stop(); err != nil {
-		return nil, err
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- s.service.GracefulStop()
+	}()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			return fmt.Errorf("stopping server: %w", err)
+		}
+	case <-ctx.Done():
+		errs := []error{fmt.Errorf("stopping server: %w", ctx.Err())}
+		if err := s.service.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("stopping server: %w", err))
+		}
+		if s.healthServer != nil {
+			if err := s.healthServer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("stopping health server: %w", err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	if s.healthServer != nil {
+		if err := s.healthServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("stopping health server: %w", err)
+		}
 	}
-	return sig, nil
+	return nil
 }
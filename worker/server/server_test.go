@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"log/slog"
-	"syscall"
+	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -32,32 +34,44 @@ func TestServerNew(t *testing.T) {
 				Reporter: &mockReporter{},
 			},
 			want: &server{
-				reporter: &mockReporter{},
-				log:      &slog.Logger{},
-				address:  defaultAddress,
-				name:     defaultName,
-				queue:    defaultQueue,
-				topic:    defaultTopic,
+				reporter:        &mockReporter{},
+				log:             &slog.Logger{},
+				shutdown:        &atomic.Bool{},
+				shutdownTimeout: defaultShutdownTimeout,
+				address:         defaultAddress,
+				healthAddress:   defaultHealthAddress,
+				name:            defaultName,
+				queue:           defaultQueue,
+				topic:           defaultTopic,
 			},
 			wantErr: nil,
 		},
 		{
 			name: "With options",
 			input: Options{
-				Reporter: &mockReporter{},
-				Logger:   &mockLogger{},
-				Address:  "localhost:3002",
-				Name:     "reports-test",
-				Queue:    "create-test",
-				Topic:    "create-test",
+				Reporter:        &mockReporter{},
+				Logger:          &mockLogger{},
+				Health:          mockHealth{},
+				ShutdownDelay:   time.Second,
+				ShutdownTimeout: time.Second * 5,
+				Address:         "localhost:3002",
+				HealthAddress:   "localhost:3003",
+				Name:            "reports-test",
+				Queue:           "create-test",
+				Topic:           "create-test",
 			},
 			want: &server{
-				reporter: &mockReporter{},
-				log:      &mockLogger{},
-				address:  "localhost:3002",
-				name:     "reports-test",
-				queue:    "create-test",
-				topic:    "create-test",
+				reporter:        &mockReporter{},
+				log:             &mockLogger{},
+				health:          mockHealth{},
+				shutdown:        &atomic.Bool{},
+				shutdownDelay:   time.Second,
+				shutdownTimeout: time.Second * 5,
+				address:         "localhost:3002",
+				healthAddress:   "localhost:3003",
+				name:            "reports-test",
+				queue:           "create-test",
+				topic:           "create-test",
 			},
 		},
 	}
@@ -65,7 +79,7 @@ func TestServerNew(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got, gotErr := new(test.input)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, mockService{}, mockReporter{}), cmpopts.IgnoreUnexported(slog.Logger{})); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, mockService{}, mockReporter{}, mockHealth{}), cmpopts.IgnoreUnexported(slog.Logger{}, atomic.Bool{})); diff != "" {
 				t.Errorf("New() = unexpected result, (-want +got):\n%s\n", diff)
 			}
 
@@ -76,41 +90,238 @@ func TestServerNew(t *testing.T) {
 	}
 }
 
-func TestServer_Start(t *testing.T) {
+func TestServer_Run(t *testing.T) {
 	var tests = []struct {
-		name string
-		want []string
+		name    string
+		input   *mockService
+		want    []string
+		wantErr bool
 	}{
 		{
-			name: "Start",
+			name:  "Run",
+			input: &mockService{},
 			want: []string{
 				"Server started.",
 				"Server stopped.",
 			},
 		},
+		{
+			name:  "Service error",
+			input: &mockService{startErr: errors.New("error")},
+			want: []string{
+				"Server started.",
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Stop error",
+			input: &mockService{stopErr: errors.New("error")},
+			want: []string{
+				"Server started.",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			logMessages = []string{}
 			srv := &server{
-				service: &mockService{},
-				log:     &mockLogger{},
+				service:         test.input,
+				log:             &mockLogger{},
+				healthServer:    &http.Server{Addr: "localhost:0"},
+				shutdownTimeout: time.Second,
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			err := srv.Run(ctx)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Run() = unexpected error, want error: %v, got: %v\n", test.wantErr, err)
 			}
-			go func() {
-				time.Sleep(time.Millisecond * 100)
-				syscall.Kill(syscall.Getpid(), syscall.SIGINT)
-			}()
-			srv.Start()
 
 			if diff := cmp.Diff(test.want, logMessages); diff != "" {
-				t.Errorf("Start() = unexpected result, (-want +got):\n%s\n", diff)
+				t.Errorf("Run() = unexpected result, (-want +got):\n%s\n", diff)
 			}
 			logMessages = []string{}
 		})
 	}
 }
 
+func TestServer_Create(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			claims *mockClaimChecker
+			report report.Report
+		}
+		wantReleased []string
+		wantErr      bool
+	}{
+		{
+			name: "Without claim check",
+			input: struct {
+				claims *mockClaimChecker
+				report report.Report
+			}{
+				report: report.NewReport("123", []byte("data")),
+			},
+		},
+		{
+			name: "With claim check",
+			input: struct {
+				claims *mockClaimChecker
+				report report.Report
+			}{
+				claims: &mockClaimChecker{},
+				report: report.Report{ID: "123", ClaimCheck: "123.data"},
+			},
+			wantReleased: []string{"123.data"},
+		},
+		{
+			name: "With claim check not enabled",
+			input: struct {
+				claims *mockClaimChecker
+				report report.Report
+			}{
+				report: report.Report{ID: "123", ClaimCheck: "123.data"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "With resolve error",
+			input: struct {
+				claims *mockClaimChecker
+				report report.Report
+			}{
+				claims: &mockClaimChecker{err: errors.New("error")},
+				report: report.Report{ID: "123", ClaimCheck: "123.data"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{
+				log:      mockLogger{},
+				reporter: &mockReporter{},
+			}
+			if test.input.claims != nil {
+				s.claims = test.input.claims
+			}
+
+			gotErr := s.create(context.Background(), test.input.report)
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("create() = unexpected error, want error %t, got: %v\n", test.wantErr, gotErr)
+			}
+			if test.input.claims != nil {
+				if diff := cmp.Diff(test.wantReleased, test.input.claims.released); diff != "" {
+					t.Errorf("create() = unexpected released claim checks, (-want +got):\n%s\n", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Trace(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			tracing  *mockTracing
+			report   report.Report
+			metadata map[string]string
+		}
+		wantCarriers []map[string]string
+	}{
+		{
+			name: "Tracing disabled",
+			input: struct {
+				tracing  *mockTracing
+				report   report.Report
+				metadata map[string]string
+			}{
+				report: report.Report{ID: "123", TraceContext: map[string]string{"traceparent": "report"}},
+			},
+		},
+		{
+			name: "Tracing enabled",
+			input: struct {
+				tracing  *mockTracing
+				report   report.Report
+				metadata map[string]string
+			}{
+				tracing:  &mockTracing{},
+				report:   report.Report{ID: "123", TraceContext: map[string]string{"traceparent": "report"}},
+				metadata: map[string]string{"traceparent": "metadata"},
+			},
+			wantCarriers: []map[string]string{{"traceparent": "metadata"}, {"traceparent": "report"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{name: "reports"}
+			if test.input.tracing != nil {
+				s.tracing = test.input.tracing
+			}
+
+			_, _, end := s.trace(context.Background(), TypeQueue, test.input.report, test.input.metadata)
+			end(errors.New("error"))
+
+			if test.input.tracing == nil {
+				return
+			}
+			if diff := cmp.Diff(test.wantCarriers, test.input.tracing.carriers); diff != "" {
+				t.Errorf("trace() = unexpected carriers, (-want +got):\n%s\n", diff)
+			}
+			if test.input.tracing.err == nil {
+				t.Errorf("trace() = unexpected result, want span ended with error\n")
+			}
+		})
+	}
+}
+
+func TestServer_Stop_Timeout(t *testing.T) {
+	service := &mockBlockingService{stopped: make(chan struct{})}
+	srv := &server{
+		service:         service,
+		log:             &mockLogger{},
+		healthServer:    &http.Server{Addr: "localhost:0"},
+		shutdownTimeout: time.Millisecond * 50,
+	}
+
+	if err := srv.stop(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stop() = unexpected error, want: %v, got: %v\n", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-service.stopped:
+	default:
+		t.Errorf("stop() = service not stopped after timeout\n")
+	}
+	if err := srv.healthServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("stop() = health server not closed after timeout, got: %v\n", err)
+	}
+}
+
+// mockBlockingService is a service with in-flight messages that do not
+// complete until the service is stopped.
+type mockBlockingService struct {
+	mockService
+	stopped chan struct{}
+}
+
+func (s *mockBlockingService) Stop() error {
+	close(s.stopped)
+	return nil
+}
+
+func (s *mockBlockingService) GracefulStop() error {
+	<-s.stopped
+	return nil
+}
+
 type mockService struct {
 	err      error
 	startErr error
@@ -125,6 +336,10 @@ func (s mockService) Stop() error {
 	return s.stopErr
 }
 
+func (s mockService) GracefulStop() error {
+	return s.stopErr
+}
+
 func (s mockService) AddBindingInvocationHandler(name string, fn common.BindingInvocationHandler) error {
 	return s.err
 }
@@ -134,13 +349,35 @@ func (s mockService) AddTopicEventHandler(sub *common.Subscription, fn common.To
 }
 
 type mockReporter struct {
-	err error
+	err    error
+	failed []string
 }
 
-func (r mockReporter) Create(report.Report) error {
+func (r mockReporter) Create(context.Context, report.Report) error {
 	return r.err
 }
 
+func (r *mockReporter) Fail(id string, reason string) {
+	r.failed = append(r.failed, id+": "+reason)
+}
+
+type mockClaimChecker struct {
+	err      error
+	released []string
+}
+
+func (c *mockClaimChecker) Resolve(ctx context.Context, r report.Report) (report.Report, error) {
+	if c.err != nil {
+		return report.Report{}, c.err
+	}
+	return report.NewReport(r.ID, []byte("data")), nil
+}
+
+func (c *mockClaimChecker) Release(ctx context.Context, name string, parts int) error {
+	c.released = append(c.released, name)
+	return nil
+}
+
 type mockLogger struct{}
 
 var logMessages = []string{}
@@ -152,3 +389,15 @@ func (l mockLogger) Error(msg string, args ...any) {
 func (l mockLogger) Info(msg string, args ...any) {
 	logMessages = append(logMessages, msg)
 }
+
+type mockTracing struct {
+	carriers []map[string]string
+	err      error
+}
+
+func (m *mockTracing) Start(ctx context.Context, typ, component string, carriers ...map[string]string) (context.Context, func(err error)) {
+	m.carriers = carriers
+	return ctx, func(err error) {
+		m.err = err
+	}
+}
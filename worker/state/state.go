@@ -0,0 +1,185 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultStoreName    = "reports-state"
+	defaultStoreTimeout = time.Second * 10
+	// maxUpdateAttempts is the number of times an update is attempted
+	// when the key is updated concurrently.
+	maxUpdateAttempts = 5
+)
+
+var (
+	// ErrNotFound is returned when a key does not exist in the store.
+	ErrNotFound = errors.New("key not found")
+	// ErrConflict is returned when a key could not be updated because it
+	// was updated concurrently.
+	ErrConflict = errors.New("key updated concurrently")
+)
+
+// Store is the interface that wraps around methods Get, Set, Update and
+// Delete.
+type Store interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error
+	Delete(key string) error
+}
+
+// client is the interface that wraps around methods GetState, SaveState,
+// SaveStateWithETag and DeleteState.
+type client interface {
+	GetState(ctx context.Context, storeName, key string, meta map[string]string) (*dapr.StateItem, error)
+	SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...dapr.StateOption) error
+	SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...dapr.StateOption) error
+	DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error
+}
+
+// DaprStore is a store that uses a state store component with DAPR.
+type DaprStore struct {
+	client
+	name    string
+	timeout time.Duration
+}
+
+// DaprStoreOptions contains settings for a DaprStore.
+type DaprStoreOptions struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// DaprStoreOption is a function that sets *DaprStoreOptions.
+type DaprStoreOption func(o *DaprStoreOptions)
+
+// NewDaprStore creates a new *DaprStore with the provided options.
+func NewDaprStore(options ...DaprStoreOption) (*DaprStore, error) {
+	client, err := dapr.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	s := newDaprStore(options...)
+	s.client = client
+
+	return s, nil
+}
+
+// newDaprStore creates a new *DaprStore with the provided options.
+func newDaprStore(options ...DaprStoreOption) *DaprStore {
+	opts := DaprStoreOptions{
+		Name:    defaultStoreName,
+		Timeout: defaultStoreTimeout,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &DaprStore{
+		name:    opts.Name,
+		timeout: opts.Timeout,
+	}
+}
+
+// Get the value for the provided key. Returns ErrNotFound if
+// the key does not exist.
+func (s DaprStore) Get(key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	item, err := s.GetState(ctx, s.name, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || len(item.Value) == 0 {
+		return nil, ErrNotFound
+	}
+	return item.Value, nil
+}
+
+// Set the value for the provided key. A ttl greater than 0 sets
+// the time to live for the key.
+func (s DaprStore) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.SaveState(ctx, s.name, key, value, ttlMetadata(ttl))
+}
+
+// Update the value of the provided key with fn, with optimistic concurrency.
+// fn is called with the current value, or nil if the key does not exist, and
+// is called again if the key is updated concurrently. Returns ErrConflict if
+// the key could not be updated after a number of attempts. A ttl greater
+// than 0 sets the time to live for the key.
+func (s DaprStore) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	for i := 0; i < maxUpdateAttempts; i++ {
+		if err := s.update(key, ttl, fn); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+// update attempts to update the value of the provided key once. Returns
+// ErrConflict if the key was updated concurrently.
+func (s DaprStore) update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	item, err := s.GetState(ctx, s.name, key, nil)
+	if err != nil {
+		return err
+	}
+	var value []byte
+	var etag string
+	if item != nil && len(item.Value) > 0 {
+		value, etag = item.Value, item.Etag
+	}
+	value, err = fn(value)
+	if err != nil {
+		return err
+	}
+	// Without an ETag the value is only saved if the key does not exist.
+	err = s.SaveStateWithETag(ctx, s.name, key, value, etag, ttlMetadata(ttl), dapr.WithConcurrency(dapr.StateConcurrencyFirstWrite))
+	if isConflict(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+// Delete the provided key.
+func (s DaprStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.DeleteState(ctx, s.name, key, nil)
+}
+
+// isConflict returns true if err is from a write with a mismatching ETag,
+// or to an existing key without an ETag.
+func isConflict(err error) bool {
+	code := status.Code(err)
+	return code == codes.Aborted || code == codes.FailedPrecondition
+}
+
+// ttlMetadata returns the metadata for the provided ttl, or nil
+// if ttl is 0.
+func ttlMetadata(ttl time.Duration) map[string]string {
+	if ttl <= 0 {
+		return nil
+	}
+	return map[string]string{
+		"ttlInSeconds": strconv.Itoa(int(math.Ceil(ttl.Seconds()))),
+	}
+}
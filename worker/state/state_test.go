@@ -0,0 +1,317 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	dapr "github.com/dapr/go-sdk/client"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewDaprStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []DaprStoreOption
+		want  *DaprStore
+	}{
+		{
+			name:  "Empty",
+			input: nil,
+			want: &DaprStore{
+				name:    defaultStoreName,
+				timeout: defaultStoreTimeout,
+			},
+		},
+		{
+			name: "With options",
+			input: []DaprStoreOption{
+				func(o *DaprStoreOptions) {
+					o.Name = "name"
+					o.Timeout = time.Second * 5
+				},
+			},
+			want: &DaprStore{
+				name:    "name",
+				timeout: time.Second * 5,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newDaprStore(test.input...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(DaprStore{})); diff != "" {
+				t.Errorf("newDaprStore() = unexpected, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDaprStore_Get(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   *mockClient
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "With value",
+			input: &mockClient{
+				state: map[string][]byte{
+					"key": []byte("value"),
+				},
+			},
+			want: []byte("value"),
+		},
+		{
+			name:    "Not found",
+			input:   &mockClient{},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "With error",
+			input: &mockClient{
+				err: errors.New("error"),
+			},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &DaprStore{
+				client:  test.input,
+				name:    defaultStoreName,
+				timeout: defaultStoreTimeout,
+			}
+
+			got, gotErr := s.Get("key")
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Get() = unexpected, (-want +got):\n%s\n", diff)
+			}
+
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("Get() = unexpected, want error, got nil\n")
+			}
+
+			if errors.Is(test.wantErr, ErrNotFound) && !errors.Is(gotErr, ErrNotFound) {
+				t.Errorf("Get() = unexpected, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestDaprStore_Set(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client *mockClient
+			ttl    time.Duration
+		}
+		wantMeta map[string]string
+		wantErr  error
+	}{
+		{
+			name: "Without ttl",
+			input: struct {
+				client *mockClient
+				ttl    time.Duration
+			}{
+				client: &mockClient{},
+			},
+		},
+		{
+			name: "With ttl",
+			input: struct {
+				client *mockClient
+				ttl    time.Duration
+			}{
+				client: &mockClient{},
+				ttl:    time.Millisecond * 1500,
+			},
+			wantMeta: map[string]string{
+				"ttlInSeconds": "2",
+			},
+		},
+		{
+			name: "With error",
+			input: struct {
+				client *mockClient
+				ttl    time.Duration
+			}{
+				client: &mockClient{err: errors.New("error")},
+			},
+			wantErr: errors.New("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &DaprStore{
+				client:  test.input.client,
+				name:    defaultStoreName,
+				timeout: defaultStoreTimeout,
+			}
+
+			gotErr := s.Set("key", []byte("value"), test.input.ttl)
+
+			if test.wantErr != nil {
+				if gotErr == nil {
+					t.Errorf("Set() = unexpected, want error, got nil\n")
+				}
+				return
+			}
+
+			if diff := cmp.Diff(test.wantMeta, test.input.client.meta); diff != "" {
+				t.Errorf("Set() = unexpected metadata, (-want +got):\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDaprStore_Update(t *testing.T) {
+	increment := func(value []byte) ([]byte, error) {
+		n, _ := strconv.Atoi(string(value))
+		return []byte(strconv.Itoa(n + 1)), nil
+	}
+
+	var tests = []struct {
+		name    string
+		input   *mockClient
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "New key",
+			input: &mockClient{},
+			want:  "1",
+		},
+		{
+			name:  "Existing key",
+			input: &mockClient{state: map[string][]byte{"key": []byte("1")}, etags: map[string]int{"key": 1}},
+			want:  "2",
+		},
+		{
+			name:  "With conflicts",
+			input: &mockClient{state: map[string][]byte{"key": []byte("1")}, etags: map[string]int{"key": 1}, conflicts: 2},
+			want:  "2",
+		},
+		{
+			name:    "With too many conflicts",
+			input:   &mockClient{state: map[string][]byte{"key": []byte("1")}, etags: map[string]int{"key": 1}, conflicts: maxUpdateAttempts},
+			want:    "1",
+			wantErr: ErrConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &DaprStore{
+				client:  test.input,
+				name:    defaultStoreName,
+				timeout: defaultStoreTimeout,
+			}
+
+			gotErr := s.Update("key", time.Minute, increment)
+
+			if got := string(test.input.state["key"]); got != test.want {
+				t.Errorf("Update() = unexpected value, want: %q, got: %q\n", test.want, got)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Update() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestDaprStore_Delete(t *testing.T) {
+	s := &DaprStore{
+		client: &mockClient{
+			state: map[string][]byte{
+				"key": []byte("value"),
+			},
+		},
+		name:    defaultStoreName,
+		timeout: defaultStoreTimeout,
+	}
+
+	if err := s.Delete("key"); err != nil {
+		t.Errorf("Delete() = unexpected error: %v\n", err)
+	}
+
+	if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() = unexpected, want: %v, got: %v\n", ErrNotFound, err)
+	}
+}
+
+// mockClient is a state client where the ETag of a key is the number of
+// times it has been saved. If conflicts is set, that many saves with an
+// ETag fail as if the key was updated concurrently.
+type mockClient struct {
+	state     map[string][]byte
+	etags     map[string]int
+	meta      map[string]string
+	conflicts int
+	err       error
+}
+
+func (c *mockClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (*dapr.StateItem, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	item := &dapr.StateItem{Key: key, Value: c.state[key]}
+	if _, ok := c.state[key]; ok {
+		item.Etag = strconv.Itoa(c.etags[key])
+	}
+	return item, nil
+}
+
+func (c *mockClient) SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...dapr.StateOption) error {
+	return c.SaveStateWithETag(ctx, storeName, key, data, "", meta, so...)
+}
+
+func (c *mockClient) SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...dapr.StateOption) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.state == nil {
+		c.state = make(map[string][]byte)
+	}
+	if c.etags == nil {
+		c.etags = make(map[string]int)
+	}
+	var opts dapr.StateOptions
+	for _, o := range so {
+		o(&opts)
+	}
+	_, exists := c.state[key]
+	if len(etag) > 0 && c.conflicts > 0 {
+		c.conflicts--
+		return status.Error(codes.Aborted, "possible etag mismatch")
+	}
+	if len(etag) > 0 && etag != strconv.Itoa(c.etags[key]) {
+		return status.Error(codes.Aborted, "possible etag mismatch")
+	}
+	if len(etag) == 0 && exists && opts.Concurrency == dapr.StateConcurrencyFirstWrite {
+		return status.Error(codes.Aborted, "possible etag mismatch")
+	}
+	c.state[key] = data
+	c.etags[key]++
+	c.meta = meta
+	return nil
+}
+
+func (c *mockClient) DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.state, key)
+	return nil
+}
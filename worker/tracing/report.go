@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/report"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Start creates a consumer span for a message received by the binding or
+// pubsub of the provided type and component. The trace context of the
+// carriers is the parent of the span, where a later carrier takes
+// precedence over an earlier one. Returns a copy of ctx with the span and
+// a function that ends the span with the error, if any.
+func (p Provider) Start(ctx context.Context, typ, component string, carriers ...map[string]string) (context.Context, func(err error)) {
+	for _, carrier := range carriers {
+		ctx = Extract(ctx, carrier)
+	}
+	ctx, span := p.tracer.Start(ctx, "process "+component,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "dapr"),
+			attribute.String("messaging.destination.name", component),
+			attribute.String("dapr.component.type", typ),
+		),
+	)
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+// storer is a report.Storer that creates a span for every report stored
+// with the wrapped storer.
+type storer struct {
+	s         report.Storer
+	p         Provider
+	typ       string
+	component string
+}
+
+// Storer wraps the provided storer and creates a client span for every
+// report, with the trace context of the report, or else of ctx, as parent.
+func (p Provider) Storer(s report.Storer, typ, component string) report.Storer {
+	return &storer{s: s, p: p, typ: typ, component: component}
+}
+
+// Store the report with the wrapped storer.
+func (s storer) Store(ctx context.Context, r report.Report) error {
+	ctx, span := s.p.tracer.Start(Extract(ctx, r.TraceContext), "store "+s.component,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("dapr.component.name", s.component),
+			attribute.String("dapr.component.type", s.typ),
+			attribute.String("report.id", r.ID),
+		),
+	)
+	err := s.s.Store(ctx, r)
+	end(span, err)
+	return err
+}
+
+// end the span and record the error, if any.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RedeployAB/container-apps-dapr/worker/report"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProvider_Start(t *testing.T) {
+	var tests = []struct {
+		name        string
+		input       []map[string]string
+		wantTraceID string
+	}{
+		{
+			name:  "Without carriers",
+			input: nil,
+		},
+		{
+			name: "With carriers",
+			input: []map[string]string{
+				{"traceparent": "00-11111111111111111111111111111111-b7ad6b7169203331-01"},
+				{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+			},
+			wantTraceID: "0af7651916cd43dd8448eb211c80319c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, recorder := newTestProvider(t)
+
+			ctx, end := p.Start(context.Background(), "queue", "reports", test.input...)
+			end(errors.New("error"))
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Start() = unexpected result, want 1 span, got: %d\n", len(spans))
+			}
+			span := spans[0]
+			if trace.SpanContextFromContext(ctx).SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("Start() = unexpected result, want span in context\n")
+			}
+			if span.SpanKind() != trace.SpanKindConsumer {
+				t.Errorf("Start() = unexpected kind, want: %v, got: %v\n", trace.SpanKindConsumer, span.SpanKind())
+			}
+			if span.Status().Code != codes.Error {
+				t.Errorf("Start() = unexpected status, want: %v, got: %v\n", codes.Error, span.Status().Code)
+			}
+			if len(test.wantTraceID) > 0 && span.SpanContext().TraceID().String() != test.wantTraceID {
+				t.Errorf("Start() = unexpected trace, want: %s, got: %s\n", test.wantTraceID, span.SpanContext().TraceID())
+			}
+		})
+	}
+}
+
+func TestProvider_Storer(t *testing.T) {
+	p, recorder := newTestProvider(t)
+
+	s := p.Storer(mockStorer{}, "blob", "reports-output")
+	if err := s.Store(context.Background(), report.Report{ID: "123", TraceContext: map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}}); err != nil {
+		t.Fatalf("Store() = unexpected error: %v\n", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Store() = unexpected result, want 1 span, got: %d\n", len(spans))
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "b7ad6b7169203331" {
+		t.Errorf("Store() = unexpected parent, want: b7ad6b7169203331, got: %s\n", got)
+	}
+	if got := spans[0].Name(); got != "store reports-output" {
+		t.Errorf("Store() = unexpected name, want: store reports-output, got: %s\n", got)
+	}
+}
+
+type mockStorer struct {
+	err error
+}
+
+func (s mockStorer) Store(ctx context.Context, r report.Report) error {
+	return s.err
+}
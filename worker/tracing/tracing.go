@@ -0,0 +1,166 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/RedeployAB/container-apps-dapr/worker"
+)
+
+const (
+	exporterOTLP   = "otlp"
+	exporterStdout = "stdout"
+	exporterFile   = "file"
+)
+
+const (
+	defaultServiceName = "worker"
+	defaultExporter    = exporterOTLP
+	defaultSampleRatio = 1.0
+)
+
+// Provider creates spans and exports them with OpenTelemetry.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	closer   io.Closer
+}
+
+// ProviderOptions contains settings for a Provider.
+type ProviderOptions struct {
+	ServiceName string
+	// Exporter is the exporter of spans: otlp, stdout or file.
+	Exporter string
+	// Endpoint is the address of the OTLP collector. The OpenTelemetry
+	// environment variables are used if empty.
+	Endpoint string
+	Insecure bool
+	// File is the path of the file spans are written to with the file
+	// exporter.
+	File string
+	// SampleRatio is the ratio of traces that are sampled. Spans with a
+	// sampled parent are always sampled.
+	SampleRatio float64
+}
+
+// ProviderOption is a function that sets *ProviderOptions.
+type ProviderOption func(o *ProviderOptions)
+
+// NewProvider creates a new *Provider with the exporter of the provided
+// options. The provider and the W3C trace context propagator are
+// registered globally.
+func NewProvider(options ...ProviderOption) (*Provider, error) {
+	opts := ProviderOptions{
+		ServiceName: defaultServiceName,
+		Exporter:    defaultExporter,
+		SampleRatio: defaultSampleRatio,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	exporter, closer, err := newExporter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newProvider(exporter, opts)
+	p.closer = closer
+
+	otel.SetTracerProvider(p.provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return p, nil
+}
+
+// newProvider creates a new *Provider with the provided exporter and
+// options.
+func newProvider(exporter sdktrace.SpanExporter, opts ProviderOptions) *Provider {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	return &Provider{
+		provider: provider,
+		tracer:   provider.Tracer(instrumentationName),
+	}
+}
+
+// newExporter creates the exporter of the provided options. The returned
+// io.Closer is not nil if the exporter writes to a file.
+func newExporter(opts ProviderOptions) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case exporterOTLP:
+		var options []otlptracegrpc.Option
+		if len(opts.Endpoint) > 0 {
+			options = append(options, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(context.Background(), options...)
+		return exporter, nil, err
+	case exporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case exporterFile:
+		if len(opts.File) == 0 {
+			return nil, nil, errors.New("file exporter requires a file")
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported exporter: %q", opts.Exporter)
+	}
+}
+
+// Shutdown exports the remaining spans and stops the provider.
+func (p Provider) Shutdown(ctx context.Context) error {
+	err := p.provider.Shutdown(ctx)
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+	return err
+}
+
+// Inject returns the trace context of ctx as a map with the keys
+// traceparent and tracestate. Returns nil if ctx has no trace context or
+// if tracing is disabled.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns a copy of ctx with the trace context of the provided
+// carrier, if any.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
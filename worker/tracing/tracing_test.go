@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewProvider(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []ProviderOption
+		wantErr bool
+	}{
+		{
+			name: "File",
+			input: []ProviderOption{
+				func(o *ProviderOptions) {
+					o.Exporter = exporterFile
+					o.File = filepath.Join(t.TempDir(), "traces.json")
+				},
+			},
+		},
+		{
+			name: "File without file",
+			input: []ProviderOption{
+				func(o *ProviderOptions) {
+					o.Exporter = exporterFile
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unsupported exporter",
+			input: []ProviderOption{
+				func(o *ProviderOptions) {
+					o.Exporter = "unsupported"
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewProvider(test.input...)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("NewProvider() = unexpected error, want error: %v, got: %v\n", test.wantErr, err)
+			}
+			if got != nil {
+				if err := got.Shutdown(context.Background()); err != nil {
+					t.Errorf("Shutdown() = unexpected error: %v\n", err)
+				}
+			}
+		})
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	setupPropagator(t)
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	want := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+	got := Inject(ctx)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Inject() = unexpected result, (-want +got):\n%s\n", diff)
+	}
+
+	if got := trace.SpanContextFromContext(Extract(context.Background(), got)); got.TraceID() != traceID || got.SpanID() != spanID {
+		t.Errorf("Extract() = unexpected result, want trace %s and span %s, got: %s and %s\n", traceID, spanID, got.TraceID(), got.SpanID())
+	}
+
+	if got := Inject(context.Background()); got != nil {
+		t.Errorf("Inject() = unexpected result, want: nil, got: %v\n", got)
+	}
+}
+
+// setupPropagator registers the W3C trace context propagator globally
+// for the test.
+func setupPropagator(t *testing.T) {
+	t.Helper()
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+// newTestProvider creates a Provider that records spans in the returned
+// *tracetest.SpanRecorder.
+func newTestProvider(t *testing.T) (Provider, *tracetest.SpanRecorder) {
+	t.Helper()
+	setupPropagator(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return Provider{provider: provider, tracer: provider.Tracer(instrumentationName)}, recorder
+}